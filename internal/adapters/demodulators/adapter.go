@@ -0,0 +1,103 @@
+package demodulators
+
+import (
+	"fmt"
+
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/demod"
+)
+
+// DemodAdapter handles conversion between domain types and demod package types
+type DemodAdapter struct {
+	demodulator demod.Demodulator
+	config      demod.DemodulatorConfig
+	metrics     demod.GainMetrics
+	iq          bool // Set once I/Q input has been demodulated
+}
+
+// NewDemodAdapter creates an adapter for the named demodulation type
+func NewDemodAdapter(demodType string) (*DemodAdapter, error) {
+	t, err := demod.ParseDemodulationType(demodType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid demodulation type: %w", err)
+	}
+
+	config := demod.DemodulatorConfig{Type: t}
+	if t == demod.FM {
+		preset, _ := demod.ParseFMPreset(demodType)
+		preset.Apply(&config)
+	}
+	return &DemodAdapter{
+		demodulator: demod.NewDemodulator(config),
+		config:      config,
+	}, nil
+}
+
+// Configure replaces the demodulator settings other than the type, such as
+// the sample rate, FM deviation and de-emphasis, and resets the state
+func (a *DemodAdapter) Configure(config demod.DemodulatorConfig) error {
+	config.Type = a.config.Type
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid demodulator configuration: %w", err)
+	}
+	a.config = config
+	a.demodulator = demod.NewDemodulator(config)
+	a.metrics = demod.GainMetrics{}
+	a.iq = false
+	return nil
+}
+
+// Demodulate demodulates a real-valued signal
+func (a *DemodAdapter) Demodulate(signal *domain.Signal) (*domain.Signal, error) {
+	samples, metrics := a.demodulator.Demodulate(signal.Samples)
+	a.metrics = metrics
+	return domain.NewSignal(samples, signal.SampleRate), nil
+}
+
+// DemodulateIQ demodulates a complex baseband signal
+func (a *DemodAdapter) DemodulateIQ(signal *domain.IQSignal) (*domain.Signal, error) {
+	iq, ok := a.demodulator.(demod.IQDemodulator)
+	if !ok {
+		return nil, fmt.Errorf("%s demodulator does not support I/Q input", a.config.Type)
+	}
+
+	samples, metrics := iq.DemodulateIQ(signal.Samples)
+	a.metrics = metrics
+	a.iq = true
+	return domain.NewSignal(samples, signal.SampleRate), nil
+}
+
+// Normalize scales a whole demodulated signal so its peak is 0.7, as
+// recordings demodulated at once always were. FM output keeps its
+// deviation scale and AGC output its target level.
+func (a *DemodAdapter) Normalize(signal *domain.Signal) {
+	if a.config.Type == demod.FM || a.config.GainMode == demod.AGC {
+		return
+	}
+	a.metrics = demod.Normalize(signal.Samples)
+}
+
+// Reset clears the demodulator state so the next signal starts afresh
+func (a *DemodAdapter) Reset() {
+	a.demodulator.Reset()
+	a.metrics = demod.GainMetrics{}
+	a.iq = false
+}
+
+// Latency returns the output delay in samples for the input kind demodulated so far
+func (a *DemodAdapter) Latency() float64 {
+	if iq, ok := a.demodulator.(demod.IQDemodulator); ok && a.iq {
+		return iq.IQLatency()
+	}
+	return a.demodulator.Latency()
+}
+
+// GetMetrics returns the gain metrics of the last demodulation
+func (a *DemodAdapter) GetMetrics() demod.GainMetrics {
+	return a.metrics
+}
+
+// GetConfig returns the demodulator configuration
+func (a *DemodAdapter) GetConfig() demod.DemodulatorConfig {
+	return a.config
+}
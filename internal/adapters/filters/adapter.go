@@ -2,6 +2,7 @@ package filters
 
 import (
     "fmt"
+    "math"
     "github.com/Vivirinter/sdr-parser/internal/domain"
     "github.com/Vivirinter/sdr-parser/internal/ports"
     "github.com/Vivirinter/sdr-parser/pkg/filter"
@@ -10,6 +11,7 @@ import (
 // FilterAdapter handles conversion between domain types and filter package types
 type FilterAdapter struct {
     filter     filter.Filter
+    qFilter    filter.Filter // Separate instance for the quadrature channel
     filterType filter.FilterType
     config     filter.FilterConfig
     factory    *filter.FilterFactory
@@ -27,6 +29,16 @@ func NewFilterAdapter(filterType string) (ports.SignalFilter, error) {
     }, nil
 }
 
+// NewIQFilterAdapter creates an adapter that filters the I and Q channels
+// of a complex signal independently
+func NewIQFilterAdapter(filterType string) (ports.IQSignalFilter, error) {
+    f, err := NewFilterAdapter(filterType)
+    if err != nil {
+        return nil, err
+    }
+    return f.(*FilterAdapter), nil
+}
+
 func (a *FilterAdapter) validateParams(params map[string]interface{}) error {
     var err error
 
@@ -57,22 +69,118 @@ func (a *FilterAdapter) validateParams(params map[string]interface{}) error {
         return intVal, nil
     }
 
+    getString := func(params map[string]interface{}, key string) (string, error) {
+        value, ok := params[key]
+        if !ok {
+            return "", nil
+        }
+        strVal, ok := value.(string)
+        if !ok {
+            return "", fmt.Errorf("parameter %s must be a string", key)
+        }
+        return strVal, nil
+    }
+
     config := filter.FilterConfig{
         Type: a.filterType,
     }
 
+    // Band edges for band-pass and band-stop, a cutoff otherwise
+    readEdges := func() error {
+        response, err := getString(params, "response_type")
+        if err != nil {
+            return err
+        }
+        config.ResponseType = filter.ResponseType(response)
+
+        switch config.ResponseType {
+        case filter.BandPass, filter.BandStop:
+            if config.LowFreq, err = getFloat64(params, "low_freq"); err != nil {
+                return err
+            }
+            if config.HighFreq, err = getFloat64(params, "high_freq"); err != nil {
+                return err
+            }
+        default:
+            if config.CutoffFreq, err = getFloat64(params, "cutoff_freq"); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+
     switch a.filterType {
     case filter.MovingAverage, filter.Median:
         if config.WindowSize, err = getInt(params, "window_size"); err != nil {
             return err
         }
-    case filter.Butterworth:
-        if config.CutoffFreq, err = getFloat64(params, "cutoff_freq"); err != nil {
+    case filter.Butterworth, filter.ChebyshevI, filter.ChebyshevII, filter.Elliptic:
+        if config.Order, err = getInt(params, "order"); err != nil {
             return err
         }
-        if config.Order, err = getInt(params, "order"); err != nil {
+        if config.SampleRate, err = getFloat64(params, "sampleRate"); err != nil {
+            return err
+        }
+        if err := readEdges(); err != nil {
+            return err
+        }
+
+        if a.filterType == filter.ChebyshevI || a.filterType == filter.Elliptic {
+            if config.Ripple, err = getFloat64(params, "ripple"); err != nil {
+                return err
+            }
+        }
+        if a.filterType == filter.ChebyshevII || a.filterType == filter.Elliptic {
+            if config.Attenuation, err = getFloat64(params, "attenuation"); err != nil {
+                return err
+            }
+        }
+    case filter.FIRKaiser, filter.FIRRemez:
+        if config.SampleRate, err = getFloat64(params, "sampleRate"); err != nil {
+            return err
+        }
+        if err := readEdges(); err != nil {
+            return err
+        }
+        if config.TransitionWidth, err = getFloat64(params, "transition_width"); err != nil {
+            return err
+        }
+
+        // The length is estimated from the specification unless given
+        if _, ok := params["taps"]; ok {
+            if config.Taps, err = getInt(params, "taps"); err != nil {
+                return err
+            }
+        }
+        if _, ok := params["ripple"]; ok || a.filterType == filter.FIRRemez && config.Taps == 0 {
+            if config.Ripple, err = getFloat64(params, "ripple"); err != nil {
+                return err
+            }
+        }
+        if _, ok := params["attenuation"]; ok || a.filterType == filter.FIRKaiser || config.Taps == 0 {
+            if config.Attenuation, err = getFloat64(params, "attenuation"); err != nil {
+                return err
+            }
+        }
+    case filter.RootRaisedCosine, filter.Gaussian:
+        if config.SampleRate, err = getFloat64(params, "sampleRate"); err != nil {
+            return err
+        }
+        if config.SamplesPerSymbol, err = getInt(params, "samples_per_symbol"); err != nil {
             return err
         }
+        if config.SymbolSpan, err = getInt(params, "symbol_span"); err != nil {
+            return err
+        }
+        if a.filterType == filter.RootRaisedCosine {
+            if config.RollOff, err = getFloat64(params, "roll_off"); err != nil {
+                return err
+            }
+        } else {
+            if config.BT, err = getFloat64(params, "bt"); err != nil {
+                return err
+            }
+        }
     }
 
     a.config = config
@@ -89,7 +197,13 @@ func (a *FilterAdapter) Configure(params map[string]interface{}) error {
         return fmt.Errorf("failed to create filter: %w", err)
     }
 
+    qf, err := a.factory.CreateFilter(a.filterType, a.config)
+    if err != nil {
+        return fmt.Errorf("failed to create filter: %w", err)
+    }
+
     a.filter = f
+    a.qFilter = qf
     return nil
 }
 
@@ -109,6 +223,84 @@ func (a *FilterAdapter) Filter(signal *domain.Signal) (*domain.Signal, error) {
     }, nil
 }
 
+func (a *FilterAdapter) FilterIQ(signal *domain.IQSignal) (*domain.IQSignal, error) {
+    if a.filter == nil || a.qFilter == nil {
+        return nil, fmt.Errorf("filter not configured")
+    }
+
+    i, err := a.filter.Process(signal.InPhase().Samples)
+    if err != nil {
+        return nil, fmt.Errorf("filter processing failed on I channel: %w", err)
+    }
+    q, err := a.qFilter.Process(signal.Quadrature().Samples)
+    if err != nil {
+        return nil, fmt.Errorf("filter processing failed on Q channel: %w", err)
+    }
+
+    filtered, err := domain.NewIQSignalFromChannels(
+        domain.NewSignal(i, signal.SampleRate),
+        domain.NewSignal(q, signal.SampleRate),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("filter processing failed: %w", err)
+    }
+    filtered.CenterFreq = signal.CenterFreq
+    filtered.Timestamp = signal.Timestamp
+    return filtered, nil
+}
+
+// Reset clears the state of the I and Q filters
+func (a *FilterAdapter) Reset() {
+    if a.filter != nil {
+        a.filter.Reset()
+    }
+    if a.qFilter != nil {
+        a.qFilter.Reset()
+    }
+}
+
+// Latency returns the filter delay in samples
+func (a *FilterAdapter) Latency() float64 {
+    if a.filter == nil {
+        return 0
+    }
+    return a.filter.Latency()
+}
+
+// Alignment returns the number of leading output samples to drop so the
+// output lines up with the input: the delay of the median filter and of
+// odd-length linear-phase FIR filters, 0 for filters that stay causal
+func (a *FilterAdapter) Alignment() int {
+    if _, ok := a.filter.(filter.Flusher); !ok {
+        return 0
+    }
+    latency := a.filter.Latency()
+    if latency != math.Trunc(latency) {
+        return 0
+    }
+    return int(latency)
+}
+
+// Flush returns the output owed at the end of a real signal once the first
+// Alignment() samples were dropped
+func (a *FilterAdapter) Flush(sampleRate float64) *domain.Signal {
+    var samples []float64
+    if f, ok := a.filter.(filter.Flusher); ok && a.Alignment() > 0 {
+        samples = f.Flush()
+    }
+    return domain.NewSignal(samples, sampleRate)
+}
+
+// FlushIQ returns the I/Q output owed at the end of a signal once the first
+// Alignment() samples were dropped
+func (a *FilterAdapter) FlushIQ(sampleRate float64) (*domain.IQSignal, error) {
+    q := domain.NewSignal(nil, sampleRate)
+    if f, ok := a.qFilter.(filter.Flusher); ok && a.Alignment() > 0 {
+        q.Samples = f.Flush()
+    }
+    return domain.NewIQSignalFromChannels(a.Flush(sampleRate), q)
+}
+
 func (a *FilterAdapter) GetFilterType() string {
     return string(a.filterType)
 }
@@ -1,10 +1,23 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
+
 	"github.com/spf13/cobra"
+	"github.com/Vivirinter/sdr-parser/internal/adapters/demodulators"
+	"github.com/Vivirinter/sdr-parser/internal/adapters/filters"
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/internal/ports"
 	"github.com/Vivirinter/sdr-parser/pkg/demod"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+	"github.com/Vivirinter/sdr-parser/pkg/hilbert"
+	"github.com/Vivirinter/sdr-parser/pkg/mixer"
 	"github.com/Vivirinter/sdr-parser/pkg/reader"
+	"github.com/Vivirinter/sdr-parser/pkg/resample"
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
 )
 
 func getDemodCmd() *cobra.Command {
@@ -14,9 +27,24 @@ func getDemodCmd() *cobra.Command {
 		RunE:  demodulateSignal,
 	}
 
-	cmd.Flags().StringP("input", "i", "", "input WAV file")
+	cmd.Flags().StringP("input", "i", "", "input WAV, SigMF or raw I/Q file")
 	cmd.Flags().StringP("output", "o", "audio.wav", "output WAV file")
-	cmd.Flags().StringP("type", "t", "am", "demodulation type (am, fm, usb, lsb)")
+	cmd.Flags().StringP("type", "t", "am", "demodulation type (am, fm, nbfm, wbfm, usb, lsb); fm is wbfm")
+	cmd.Flags().Float64P("rate", "r", 0, "sample rate (Hz), required for raw I/Q input")
+	cmd.Flags().Bool("iq", false, "treat stereo WAV input as baseband I/Q (I left, Q right)")
+	cmd.Flags().Float64("deviation", 0, "FM peak deviation (Hz) giving full-scale audio; default 75000 for wbfm, 5000 for nbfm")
+	cmd.Flags().Float64("deemphasis", 0, "FM de-emphasis time constant (µs), e.g. 50 or 75, 0 for none; default 50 for wbfm, none for nbfm")
+	cmd.Flags().Bool("stereo", false, "decode broadcast FM stereo into a 2-channel WAV; mono while the pilot is weak")
+	cmd.Flags().String("gain", gainNormalize, "AM and SSB output level: normalize (peak 0.7 over the whole output), agc or fixed (unity gain)")
+	cmd.Flags().Float64("carrier", 0, "suppressed carrier (Hz) of real USB/LSB input, which SSB of real input needs")
+	cmd.Flags().Bool("analytic", false, "convert real input to its analytic signal (Hilbert transform) and demodulate it as I/Q")
+	cmd.Flags().Float64("audio-rate", 0, "resample the audio to this rate (Hz); default the input rate")
+	cmd.Flags().Float64("offset", 0, "demodulate the I/Q channel this far from the center (Hz)")
+	cmd.Flags().Float64("tune", 0, "demodulate the I/Q channel at this RF frequency (Hz); needs the center frequency")
+	cmd.Flags().Float64("center-freq", 0, "center frequency (Hz) of I/Q input; default from the file metadata")
+	cmd.Flags().Float64("bandwidth", 0, "low-pass the tuned I/Q channel to this bandwidth (Hz); default no channel filter")
+	addInputFlags(cmd)
+	addSigMFFlag(cmd)
 
 	cmd.MarkFlagRequired("input")
 	return cmd
@@ -26,28 +54,526 @@ func demodulateSignal(cmd *cobra.Command, args []string) error {
 	input, _ := cmd.Flags().GetString("input")
 	output, _ := cmd.Flags().GetString("output")
 	demodType, _ := cmd.Flags().GetString("type")
+	sampleRate, _ := cmd.Flags().GetFloat64("rate")
+	writeSigMF, _ := cmd.Flags().GetBool("sigmf")
+	iq, _ := cmd.Flags().GetBool("iq")
+	analytic, _ := cmd.Flags().GetBool("analytic")
+	stereo, _ := cmd.Flags().GetBool("stereo")
+	audioRate, _ := cmd.Flags().GetFloat64("audio-rate")
+	offset, _ := cmd.Flags().GetFloat64("offset")
+	tune, _ := cmd.Flags().GetFloat64("tune")
+	centerFreq, _ := cmd.Flags().GetFloat64("center-freq")
+	bandwidth, _ := cmd.Flags().GetFloat64("bandwidth")
+	gain, _ := cmd.Flags().GetString("gain")
+	if offset != 0 && tune != 0 {
+		return fmt.Errorf("--offset and --tune are mutually exclusive")
+	}
+	switch gain {
+	case gainNormalize, gainAGC, gainFixed:
+	default:
+		return fmt.Errorf("unknown gain mode: %s (use normalize, agc or fixed)", gain)
+	}
+
+	opts := getInputOptions(cmd, sampleRate)
+	opts.iq = iq
+
+	demodulator, err := demodulators.NewDemodAdapter(demodType)
+	if err != nil {
+		return err
+	}
+	if stereo && demodulator.GetConfig().Type != demod.FM {
+		return fmt.Errorf("--stereo needs FM demodulation")
+	}
+	if stereo && writeSigMF {
+		return fmt.Errorf("--stereo output is WAV only, not --sigmf")
+	}
+
+	// WAV and raw I/Q input is streamed unless SigMF output needs the whole signal
+	if !writeSigMF {
+		stream, err := openInputStream(input, opts)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		if stream != nil {
+			defer func() { stream.Close() }()
+			if centerFreq != 0 {
+				stream.centerFreq = centerFreq
+			}
+			var chain demodChain
+			if analytic {
+				if stream.iq {
+					return fmt.Errorf("--analytic needs real input")
+				}
+				chain.transformer = hilbert.NewTransformer()
+			}
+			if err := checkCarrier(cmd, demodulator, !stream.iq && !analytic); err != nil {
+				return err
+			}
+			if offset != 0 || tune != 0 || bandwidth > 0 {
+				if !stream.iq && !analytic {
+					return fmt.Errorf("--offset, --tune and --bandwidth need I/Q input or --analytic")
+				}
+				band := domain.IQSignal{SampleRate: stream.sampleRate, CenterFreq: stream.centerFreq}
+				if chain.channel, err = newChannelSelector(&band, offset, tune, bandwidth); err != nil {
+					return err
+				}
+			}
+			if chain.stereo, err = configureDemodulator(cmd, demodulator, stream.sampleRate, stereo); err != nil {
+				return err
+			}
+			if err := configureGain(demodulator, gain, stream.sampleRate); err != nil {
+				return err
+			}
+
+			// Normalizing needs the peak of the whole output, so the stream
+			// is demodulated once to find it and again with its gain
+			if config := demodulator.GetConfig(); gain == gainNormalize && config.Type != demod.FM {
+				peak, err := streamPeak(stream, demodulator, chain)
+				if err != nil {
+					return err
+				}
+				stream.Close()
+				if stream, err = openInputStream(input, opts); err != nil {
+					return fmt.Errorf("failed to read input file: %w", err)
+				}
+				if centerFreq != 0 {
+					stream.centerFreq = centerFreq
+				}
+				chain.reset()
+				config.ManualGain = demod.NormalizeGain(peak)
+				if err := demodulator.Configure(config); err != nil {
+					return err
+				}
+			}
+			return demodulateStream(stream, demodulator, chain, output, audioRate)
+		}
+	}
 
 	// Read input signal
-	samples, sampleRate, err := reader.ReadWavFile(input)
+	signal, err := readInput(input, opts)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
 	}
 
-	// Apply demodulation
-	var demodulated []float64
-	switch demodType {
-	case "am":
-		demodulated = demod.AmDemodulate(samples)
-	case "fm":
-		demodulated = demod.FmDemodulate(samples)
-	case "usb":
-		demodulated = demod.UsbDemodulate(samples)
-	case "lsb":
-		demodulated = demod.LsbDemodulate(samples)
-	default:
-		return fmt.Errorf("unknown demodulation type: %s", demodType)
+	if analytic {
+		if signal.iq != nil {
+			return fmt.Errorf("--analytic needs real input")
+		}
+		signal.iq = domain.NewIQSignal(hilbert.Analytic(signal.real.Samples), signal.real.SampleRate)
+	}
+	if err := checkCarrier(cmd, demodulator, signal.iq == nil); err != nil {
+		return err
+	}
+	if signal.iq != nil && centerFreq != 0 {
+		signal.iq.CenterFreq = centerFreq
+	}
+	if signal.iq != nil && signal.iq.CenterFreq != 0 {
+		fmt.Printf("Center frequency: %.0f Hz\n", signal.iq.CenterFreq)
+	}
+
+	// Tune to the channel before demodulating
+	var channel *channelSelector
+	if offset != 0 || tune != 0 || bandwidth > 0 {
+		if signal.iq == nil {
+			return fmt.Errorf("--offset, --tune and --bandwidth need I/Q input or --analytic")
+		}
+		if channel, err = newChannelSelector(signal.iq, offset, tune, bandwidth); err != nil {
+			return err
+		}
+		if signal.iq, err = channel.process(signal.iq); err != nil {
+			return err
+		}
+	}
+
+	// Apply demodulation, on true baseband when the input carries I/Q
+	decoder, err := configureDemodulator(cmd, demodulator, signal.sampleRate(), stereo)
+	if err != nil {
+		return err
+	}
+	if err := configureGain(demodulator, gain, signal.sampleRate()); err != nil {
+		return err
+	}
+	var demodulated *domain.Signal
+	if signal.iq != nil {
+		demodulated, err = demodulator.DemodulateIQ(signal.iq)
+	} else {
+		demodulated, err = demodulator.Demodulate(signal.real)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to demodulate signal: %w", err)
+	}
+	if gain == gainNormalize {
+		demodulator.Normalize(demodulated)
+	}
+	audio := [][]float64{demodulated.Samples}
+	if decoder != nil {
+		left, right := decoder.Process(demodulated.Samples)
+		audio = [][]float64{left, right}
+		reportStereo(decoder)
+	}
+	if audioRate > 0 {
+		for c := range audio {
+			conv, err := resample.New(demodulated.SampleRate, audioRate)
+			if err != nil {
+				return fmt.Errorf("failed to resample audio: %w", err)
+			}
+			audio[c] = conv.Process(audio[c])
+		}
+		demodulated = domain.NewSignal(audio[0], audioRate)
 	}
 
 	// Write to WAV file
-	return reader.WriteWavFile(output, demodulated, sampleRate)
+	if err := reader.WriteWavFileChannels(output, audio, demodulated.SampleRate, reader.PCM16); err != nil {
+		return err
+	}
+
+	if writeSigMF {
+		step := sigmf.HistoryEntry{
+			Command:    "demod",
+			Source:     input,
+			Parameters: map[string]interface{}{"type": demodType},
+		}
+		if signal.iq != nil && signal.iq.CenterFreq != 0 {
+			step.Parameters["center_freq"] = signal.iq.CenterFreq
+		}
+		if config := demodulator.GetConfig(); config.Type == demod.FM {
+			step.Parameters["deviation"] = config.Deviation
+			step.Parameters["deemphasis"] = config.Deemphasis
+		}
+		if demodulator.GetConfig().Type != demod.FM {
+			step.Parameters["gain"] = gain
+		}
+		if config := demodulator.GetConfig(); config.Carrier != 0 {
+			step.Parameters["carrier"] = config.Carrier * signal.sampleRate()
+		}
+		if analytic {
+			step.Parameters["analytic"] = true
+		}
+		if audioRate > 0 {
+			step.Parameters["audio_rate"] = audioRate
+		}
+		if channel != nil {
+			step.Parameters["offset"] = channel.offset
+			if bandwidth > 0 {
+				step.Parameters["bandwidth"] = bandwidth
+			}
+		}
+		meta := outputMeta(signal, demodulated.SampleRate, false, step)
+		if err := writeSigMFReal(sigmfName(output), demodulated, meta); err != nil {
+			return fmt.Errorf("failed to write SigMF output: %w", err)
+		}
+	}
+	return nil
+}
+
+// configureDemodulator sets the input sample rate and the FM deviation and
+// de-emphasis flags that were given, keeping the preset for the others.
+// For stereo it returns the MPX decoder, which takes over the de-emphasis
+// because the discriminator output must keep the pilot and subcarrier.
+func configureDemodulator(cmd *cobra.Command, demodulator *demodulators.DemodAdapter, sampleRate float64, stereo bool) (*demod.StereoDecoder, error) {
+	config := demodulator.GetConfig()
+	config.SampleRate = sampleRate
+	if cmd.Flags().Changed("deviation") {
+		config.Deviation, _ = cmd.Flags().GetFloat64("deviation")
+	}
+	if cmd.Flags().Changed("deemphasis") {
+		deemphasis, _ := cmd.Flags().GetFloat64("deemphasis")
+		config.Deemphasis = deemphasis * 1e-6
+	}
+	if cmd.Flags().Changed("carrier") {
+		carrier, _ := cmd.Flags().GetFloat64("carrier")
+		config.Carrier = carrier / sampleRate
+	}
+
+	var decoder *demod.StereoDecoder
+	if stereo {
+		var err error
+		if decoder, err = demod.NewStereoDecoder(sampleRate, config.Deemphasis); err != nil {
+			return nil, err
+		}
+		config.Deemphasis = 0
+	}
+	return decoder, demodulator.Configure(config)
+}
+
+// Gain modes of AM and SSB output
+const (
+	gainNormalize = "normalize"
+	gainAGC       = "agc"
+	gainFixed     = "fixed"
+)
+
+// configureGain sets the gain mode of AM and SSB demodulators: the AGC, or
+// unity gain for fixed output and for normalizing afterwards
+func configureGain(demodulator *demodulators.DemodAdapter, gain string, sampleRate float64) error {
+	config := demodulator.GetConfig()
+	if config.Type == demod.FM {
+		return nil
+	}
+	config.SampleRate = sampleRate
+	config.GainMode = demod.Manual
+	config.ManualGain = 0
+	if gain == gainAGC {
+		config.GainMode = demod.AGC
+		config.AGCConfig = demod.DefaultAGC
+	}
+	return demodulator.Configure(config)
+}
+
+// checkCarrier requires --carrier for SSB of real input, whose sidebands
+// lie either side of the carrier; at 0 Hz the lower sideband is empty.
+// I/Q input is already centred on the carrier.
+func checkCarrier(cmd *cobra.Command, demodulator *demodulators.DemodAdapter, realInput bool) error {
+	carrier := cmd.Flags().Changed("carrier")
+	if carrier && !realInput {
+		return fmt.Errorf("--carrier needs real input without --analytic; tune I/Q with --offset or --tune")
+	}
+	if t := demodulator.GetConfig().Type; realInput && !carrier && (t == demod.USB || t == demod.LSB) {
+		return fmt.Errorf("%s demodulation of real input needs --carrier", t)
+	}
+	return nil
+}
+
+// reportStereo prints whether the pilot was found by the end of the signal
+func reportStereo(decoder *demod.StereoDecoder) {
+	if decoder.Stereo() {
+		fmt.Printf("Stereo pilot detected (level %.2f)\n", decoder.PilotLevel())
+	} else {
+		fmt.Printf("No stereo pilot (level %.2f), decoded as mono\n", decoder.PilotLevel())
+	}
+}
+
+// demodChain holds the optional stages around the demodulator
+type demodChain struct {
+	transformer *hilbert.Transformer // Converts real input to I/Q
+	channel     *channelSelector     // Tunes I/Q to the channel
+	stereo      *demod.StereoDecoder // Splits FM MPX into left and right
+}
+
+// streamBuffers hold one block of real or I/Q input
+type streamBuffers struct {
+	real []float64
+	iq   []complex128
+}
+
+func newStreamBuffers() streamBuffers {
+	return streamBuffers{
+		real: make([]float64, streamBlockSize),
+		iq:   make([]complex128, streamBlockSize),
+	}
+}
+
+// demodulate reads the next block of the stream and demodulates it through
+// the transformer and channel selector that are set, returning io.EOF at
+// the end of the stream
+func (c demodChain) demodulate(stream *inputStream, demodulator *demodulators.DemodAdapter, buf streamBuffers) (*domain.Signal, error) {
+	var n int
+	var err error
+	if stream.iq {
+		n, err = stream.readIQ(buf.iq)
+	} else {
+		n, err = stream.readReal(buf.real)
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	var demodulated *domain.Signal
+	if stream.iq || c.transformer != nil {
+		block := domain.NewIQSignal(buf.iq[:n], stream.sampleRate)
+		if c.transformer != nil {
+			block.Samples = c.transformer.Process(buf.real[:n])
+		}
+		if c.channel != nil {
+			if block, err = c.channel.process(block); err != nil {
+				return nil, err
+			}
+		}
+		demodulated, err = demodulator.DemodulateIQ(block)
+	} else {
+		demodulated, err = demodulator.Demodulate(domain.NewSignal(buf.real[:n], stream.sampleRate))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to demodulate signal: %w", err)
+	}
+	return demodulated, nil
+}
+
+// reset clears the state of the stages so the input can be run again
+func (c demodChain) reset() {
+	if c.transformer != nil {
+		c.transformer.Reset()
+	}
+	if c.channel != nil {
+		c.channel.reset()
+	}
+	if c.stereo != nil {
+		c.stereo.Reset()
+	}
+}
+
+// streamPeak demodulates the whole stream and returns the peak magnitude
+// of the output, leaving the demodulator reset
+func streamPeak(stream *inputStream, demodulator *demodulators.DemodAdapter, chain demodChain) (float64, error) {
+	defer demodulator.Reset()
+	buf := newStreamBuffers()
+	peak := 0.0
+	for {
+		demodulated, err := chain.demodulate(stream, demodulator, buf)
+		if errors.Is(err, io.EOF) {
+			return peak, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range demodulated.Samples {
+			peak = math.Max(peak, math.Abs(v))
+		}
+	}
+}
+
+// demodulateStream demodulates the input block by block into a WAV file,
+// through the stages of chain that are set, and resamples the audio to
+// audioRate when it is set
+func demodulateStream(stream *inputStream, demodulator *demodulators.DemodAdapter, chain demodChain, output string, audioRate float64) error {
+	if stream.centerFreq != 0 {
+		fmt.Printf("Center frequency: %.0f Hz\n", stream.centerFreq)
+	}
+
+	numChannels := 1
+	if chain.stereo != nil {
+		numChannels = 2
+	}
+	var convs []resample.Converter
+	outRate := stream.sampleRate
+	if audioRate > 0 {
+		for range numChannels {
+			conv, err := resample.New(stream.sampleRate, audioRate)
+			if err != nil {
+				return fmt.Errorf("failed to resample audio: %w", err)
+			}
+			convs = append(convs, conv)
+		}
+		outRate = audioRate
+	}
+
+	out, err := reader.NewWAVWriterChannels(output, outRate, numChannels, reader.PCM16)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	buf := newStreamBuffers()
+	for {
+		demodulated, err := chain.demodulate(stream, demodulator, buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		audio := [][]float64{demodulated.Samples}
+		if chain.stereo != nil {
+			left, right := chain.stereo.Process(demodulated.Samples)
+			audio = [][]float64{left, right}
+		}
+		for c, conv := range convs {
+			audio[c] = conv.Process(audio[c])
+		}
+		if err := out.WriteChannels(audio); err != nil {
+			return err
+		}
+	}
+	if chain.stereo != nil {
+		reportStereo(chain.stereo)
+	}
+	return out.Close()
+}
+
+// channelSelector tunes a channel of an I/Q capture to DC with a mixer and
+// optionally low-pass filters it to the channel bandwidth
+type channelSelector struct {
+	offset float64 // Channel offset from the center in Hz
+	mixer  *mixer.Mixer
+	filter ports.IQSignalFilter // nil without a channel filter
+}
+
+// newChannelSelector selects the channel at offset Hz from the center of
+// band, or at the RF frequency tune, which needs the band's center frequency
+func newChannelSelector(band *domain.IQSignal, offset, tune, bandwidth float64) (*channelSelector, error) {
+	if tune != 0 {
+		var err error
+		if offset, err = band.BasebandOffset(tune); err != nil {
+			return nil, err
+		}
+	}
+	if offset < -band.SampleRate/2 || offset > band.SampleRate/2 {
+		return nil, fmt.Errorf("offset %g Hz is outside the captured band of ±%g Hz", offset, band.SampleRate/2)
+	}
+
+	m, err := mixer.NewMixer(-offset, band.SampleRate)
+	if err != nil {
+		return nil, err
+	}
+	c := &channelSelector{offset: offset, mixer: m}
+	if bandwidth > 0 {
+		// Linear-phase FIR channel filter passing ±bandwidth/2
+		if c.filter, err = filters.NewIQFilterAdapter(string(filter.FIRKaiser)); err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{
+			"sampleRate":       band.SampleRate,
+			"cutoff_freq":      bandwidth / 2,
+			"transition_width": bandwidth / 4,
+			"attenuation":      60.0,
+		}
+		if err := c.filter.Configure(params); err != nil {
+			return nil, fmt.Errorf("failed to configure channel filter: %w", err)
+		}
+	}
+	if band.CenterFreq != 0 {
+		fmt.Printf("Tuned to %.0f Hz (offset %.0f Hz)\n", band.CenterFreq+offset, offset)
+	}
+	return c, nil
+}
+
+// reset clears the mixer and channel filter state
+func (c *channelSelector) reset() {
+	c.mixer.Reset()
+	if f, ok := c.filter.(ports.StreamingProcessor); ok {
+		f.Reset()
+	}
+}
+
+// latency returns the delay of the channel filter in samples
+func (c *channelSelector) latency() float64 {
+	if f, ok := c.filter.(ports.StreamingProcessor); ok {
+		return f.Latency()
+	}
+	return 0
+}
+
+// process shifts a block of the capture so the channel is at DC and
+// applies the channel filter
+func (c *channelSelector) process(signal *domain.IQSignal) (*domain.IQSignal, error) {
+	tuned := &domain.IQSignal{
+		Samples:    c.mixer.Process(signal.Samples),
+		SampleRate: signal.SampleRate,
+		Timestamp:  signal.Timestamp,
+	}
+	if signal.CenterFreq != 0 {
+		tuned.CenterFreq = signal.CenterFreq + c.offset
+	}
+	if c.filter == nil {
+		return tuned, nil
+	}
+	filtered, err := c.filter.FilterIQ(tuned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter channel: %w", err)
+	}
+	filtered.CenterFreq = tuned.CenterFreq
+	return filtered, nil
 }
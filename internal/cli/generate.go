@@ -3,10 +3,14 @@ package cli
 import (
 	"fmt"
 	"math"
+	"math/rand"
 
 	"github.com/spf13/cobra"
+	"github.com/Vivirinter/sdr-parser/internal/domain"
 	"github.com/Vivirinter/sdr-parser/pkg/demod"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
 	"github.com/Vivirinter/sdr-parser/pkg/reader"
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
 )
 
 func getGenerateCmd() *cobra.Command {
@@ -19,7 +23,13 @@ func getGenerateCmd() *cobra.Command {
 	cmd.Flags().StringP("output", "o", "signal.wav", "output WAV file")
 	cmd.Flags().Float64P("freq", "f", 440.0, "frequency in Hz")
 	cmd.Flags().Float64P("duration", "d", 5.0, "duration in seconds")
-	cmd.Flags().StringP("mod", "m", "am", "modulation type (am, fm, usb, lsb)")
+	cmd.Flags().StringP("mod", "m", "am", "modulation type (am, fm, usb, lsb, bpsk, fsk)")
+	cmd.Flags().String("pulse", "rrc", "pulse shaping of bpsk/fsk symbols (rrc, gaussian, none)")
+	cmd.Flags().Int("sps", 32, "samples per symbol for bpsk/fsk")
+	cmd.Flags().Int("span", 8, "length of the pulse-shaping filter in symbols")
+	cmd.Flags().Float64("rolloff", 0.35, "roll-off factor of the rrc pulse")
+	cmd.Flags().Float64("bt", 0.5, "bandwidth-time product of the gaussian pulse")
+	addSigMFFlag(cmd)
 
 	return cmd
 }
@@ -29,6 +39,12 @@ func generateSignal(cmd *cobra.Command, args []string) error {
 	freq, _ := cmd.Flags().GetFloat64("freq")
 	duration, _ := cmd.Flags().GetFloat64("duration")
 	modType, _ := cmd.Flags().GetString("mod")
+	writeSigMF, _ := cmd.Flags().GetBool("sigmf")
+	pulse, _ := cmd.Flags().GetString("pulse")
+	sps, _ := cmd.Flags().GetInt("sps")
+	span, _ := cmd.Flags().GetInt("span")
+	rollOff, _ := cmd.Flags().GetFloat64("rolloff")
+	bt, _ := cmd.Flags().GetFloat64("bt")
 
 	// Generate carrier signal
 	sampleRate := 44100.0
@@ -58,10 +74,128 @@ func generateSignal(cmd *cobra.Command, args []string) error {
 		modulated = demod.UsbModulate(carrier, message)
 	case "lsb":
 		modulated = demod.LsbModulate(carrier, message)
+	case "bpsk", "fsk":
+		config := filter.FilterConfig{
+			Type:             filter.FilterType(pulse),
+			SymbolSpan:       span,
+			SamplesPerSymbol: sps,
+			RollOff:          rollOff,
+			BT:               bt,
+			SampleRate:       sampleRate,
+		}
+		baseband, err := shapeSymbols(randomSymbols(numSamples, sps), sps, config)
+		if err != nil {
+			return err
+		}
+		baseband = baseband[:numSamples]
+		if modType == "bpsk" {
+			modulated = make([]float64, numSamples)
+			for i := range modulated {
+				modulated[i] = carrier[i] * baseband[i]
+			}
+		} else {
+			// Modulation index 0.5: the symbol rate over four either side
+			modulated = fskModulate(baseband, freq, sampleRate/float64(sps)/4, sampleRate)
+		}
 	default:
 		return fmt.Errorf("unknown modulation type: %s", modType)
 	}
 
 	// Write to WAV file
-	return reader.WriteWavFile(output, modulated, sampleRate)
+	if err := reader.WriteWavFile(output, modulated, sampleRate); err != nil {
+		return err
+	}
+
+	if writeSigMF {
+		step := sigmf.HistoryEntry{
+			Command: "generate",
+			Parameters: map[string]interface{}{
+				"freq":     freq,
+				"duration": duration,
+				"mod":      modType,
+			},
+		}
+		if modType == "bpsk" || modType == "fsk" {
+			step.Parameters["pulse"] = pulse
+			step.Parameters["sps"] = sps
+			step.Parameters["span"] = span
+			step.Parameters["rolloff"] = rollOff
+			step.Parameters["bt"] = bt
+		}
+		meta := outputMeta(nil, sampleRate, false, step)
+		meta.Global.Description = fmt.Sprintf("Generated %s signal, %g Hz carrier", modType, freq)
+		if err := writeSigMFReal(sigmfName(output), domain.NewSignal(modulated, sampleRate), meta); err != nil {
+			return fmt.Errorf("failed to write SigMF output: %w", err)
+		}
+	}
+	return nil
+}
+
+// randomSymbols returns random ±1 symbols of sps samples covering
+// numSamples samples, from a fixed seed so output is reproducible
+func randomSymbols(numSamples, sps int) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	symbols := make([]float64, (numSamples+sps-1)/max(sps, 1))
+	for i := range symbols {
+		symbols[i] = float64(2*rng.Intn(2) - 1)
+	}
+	return symbols
+}
+
+// shapeSymbols returns the baseband waveform of symbols at sps samples per
+// symbol, with the pulse-shaping filter of config. The root-raised-cosine
+// pulse is applied to one impulse per symbol and the Gaussian pulse to held
+// (NRZ) symbols; "none" holds the symbols. The waveform is aligned with the
+// symbols and scaled to a peak of one.
+func shapeSymbols(symbols []float64, sps int, config filter.FilterConfig) ([]float64, error) {
+	if sps <= 0 {
+		return nil, fmt.Errorf("samples per symbol must be positive")
+	}
+	input := make([]float64, len(symbols)*sps)
+	for i := range input {
+		if config.Type != filter.RootRaisedCosine || i%sps == 0 {
+			input[i] = symbols[i/sps]
+		}
+	}
+	if config.Type == "none" {
+		return input, nil
+	}
+	if config.Type != filter.RootRaisedCosine && config.Type != filter.Gaussian {
+		return nil, fmt.Errorf("unknown pulse shape: %s", config.Type)
+	}
+
+	f, err := filter.NewFilterFactory().CreateFilter(config.Type, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pulse-shaping filter: %w", err)
+	}
+	// Run the tail of the filter out so the output lines up with the input
+	delay := int(math.Round(f.Latency()))
+	shaped, err := f.Process(append(input, make([]float64, delay)...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to shape symbols: %w", err)
+	}
+	shaped = shaped[delay:]
+
+	var peak float64
+	for _, v := range shaped {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	if peak > 0 {
+		for i := range shaped {
+			shaped[i] /= peak
+		}
+	}
+	return shaped, nil
+}
+
+// fskModulate shifts the carrier by deviation Hz times the baseband level,
+// keeping the phase continuous
+func fskModulate(baseband []float64, freq, deviation, sampleRate float64) []float64 {
+	result := make([]float64, len(baseband))
+	phase := 0.0
+	for i, v := range baseband {
+		result[i] = math.Sin(phase)
+		phase += 2 * math.Pi * (freq + deviation*v) / sampleRate
+	}
+	return result
 }
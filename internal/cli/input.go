@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/rawiq"
+	"github.com/Vivirinter/sdr-parser/pkg/reader"
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
+	"github.com/spf13/cobra"
+)
+
+// Input format names accepted by --format besides the raw I/Q formats
+const (
+	formatAuto  = "auto"
+	formatWAV   = "wav"
+	formatSigMF = "sigmf"
+)
+
+// inputOptions describes how an input capture is decoded
+type inputOptions struct {
+	format     string
+	sampleRate float64
+	byteOrder  string
+	iq         bool // Read stereo WAV input as I (left) and Q (right)
+}
+
+// inputSignal holds a decoded capture; exactly one of real and iq is set
+type inputSignal struct {
+	real *domain.Signal
+	iq   *domain.IQSignal
+	raw  rawiq.Config // Encoding of raw I/Q input, used to write matching output
+	meta *sigmf.Meta  // Metadata of SigMF input, nil for other formats
+}
+
+// sampleRate returns the sample rate of the decoded capture
+func (s *inputSignal) sampleRate() float64 {
+	if s.iq != nil {
+		return s.iq.SampleRate
+	}
+	return s.real.SampleRate
+}
+
+// addInputFlags registers the flags selecting the input encoding
+func addInputFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", formatAuto,
+		"input format (auto, wav, sigmf, cu8, cs8, cs16, cf32); auto detects from the file extension")
+	cmd.Flags().String("byte-order", "le", "byte order of raw I/Q input (le, be)")
+}
+
+// getInputOptions collects the input flags; sampleRate overrides the file's
+// rate and is required for raw I/Q input
+func getInputOptions(cmd *cobra.Command, sampleRate float64) inputOptions {
+	format, _ := cmd.Flags().GetString("format")
+	byteOrder, _ := cmd.Flags().GetString("byte-order")
+	return inputOptions{
+		format:     format,
+		sampleRate: sampleRate,
+		byteOrder:  byteOrder,
+	}
+}
+
+// detectFormat picks the input format from the file name
+func detectFormat(filename string) string {
+	if sigmf.IsRecording(filename) {
+		return formatSigMF
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	if rawiq.SampleFormat(ext).Validate() == nil {
+		return ext
+	}
+	return formatWAV
+}
+
+// rawConfig returns the raw I/Q configuration for the selected format
+func (o inputOptions) rawConfig() (rawiq.Config, error) {
+	order, err := rawiq.ParseByteOrder(o.byteOrder)
+	if err != nil {
+		return rawiq.Config{}, err
+	}
+	config := rawiq.Config{
+		Format:     rawiq.SampleFormat(o.format),
+		SampleRate: o.sampleRate,
+		ByteOrder:  order,
+	}
+	if config.SampleRate <= 0 {
+		return rawiq.Config{}, fmt.Errorf("sample rate (--rate) is required for %s input", o.format)
+	}
+	return config, config.Validate()
+}
+
+// readInput decodes the input capture according to opts
+func readInput(filename string, opts inputOptions) (*inputSignal, error) {
+	if opts.format == formatAuto {
+		opts.format = detectFormat(filename)
+	}
+
+	switch opts.format {
+	case formatWAV:
+		if opts.iq {
+			return readWAVIQInput(filename, opts)
+		}
+		samples, sampleRate, err := reader.ReadWavFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if opts.sampleRate > 0 {
+			sampleRate = opts.sampleRate
+		}
+		return &inputSignal{real: domain.NewSignal(samples, sampleRate)}, nil
+
+	case formatSigMF:
+		return readSigMFInput(filename, opts)
+	}
+
+	config, err := opts.rawConfig()
+	if err != nil {
+		return nil, err
+	}
+	samples, err := rawiq.ReadFile(filename, config)
+	if err != nil {
+		return nil, err
+	}
+	return &inputSignal{
+		iq:  domain.NewIQSignal(samples, config.SampleRate),
+		raw: config,
+	}, nil
+}
+
+// readWAVIQInput decodes a stereo baseband WAV recording as I/Q, taking
+// center frequency and start time from its auxi chunk when present
+func readWAVIQInput(filename string, opts inputOptions) (*inputSignal, error) {
+	wav, err := reader.NewWAVReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer wav.Close()
+
+	samples, err := wav.ReadIQ()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := float64(wav.Header.SampleRate)
+	if opts.sampleRate > 0 {
+		sampleRate = opts.sampleRate
+	}
+
+	iq := domain.NewIQSignal(samples, sampleRate)
+	if auxi := wav.Metadata.Auxi; auxi != nil {
+		iq.CenterFreq = float64(auxi.CenterFreq)
+		iq.Timestamp = auxi.StartTime
+	}
+	return &inputSignal{iq: iq}, nil
+}
+
+// readSigMFInput decodes a SigMF recording, taking sample rate, center
+// frequency and start time from its metadata
+func readSigMFInput(filename string, opts inputOptions) (*inputSignal, error) {
+	recording, err := sigmf.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := recording.Meta.Global.SampleRate
+	if opts.sampleRate > 0 {
+		sampleRate = opts.sampleRate
+	}
+
+	if !recording.Datatype().Complex {
+		samples, err := recording.ReadReal()
+		if err != nil {
+			return nil, err
+		}
+		return &inputSignal{
+			real: domain.NewSignal(samples, sampleRate),
+			meta: recording.Meta,
+		}, nil
+	}
+
+	samples, err := recording.ReadComplex()
+	if err != nil {
+		return nil, err
+	}
+	iq := domain.NewIQSignal(samples, sampleRate)
+	iq.CenterFreq = recording.Meta.CenterFrequency()
+	iq.Timestamp = recording.Meta.StartTime()
+	return &inputSignal{iq: iq, meta: recording.Meta}, nil
+}
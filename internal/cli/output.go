@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
+	"github.com/spf13/cobra"
+)
+
+// addSigMFFlag registers the flag requesting a SigMF copy of the output
+func addSigMFFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("sigmf", false,
+		"also write the output as a SigMF recording (.sigmf-meta/.sigmf-data) with processing history")
+}
+
+// sigmfName returns the SigMF recording name written alongside an output file
+func sigmfName(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output))
+}
+
+// outputMeta creates SigMF metadata for a processing result, carrying over
+// the description, history and annotations of SigMF input. Annotations are
+// rescaled to the output sample rate, and their frequency edges are dropped
+// when complex input becomes real output, which has no RF frequency.
+func outputMeta(input *inputSignal, sampleRate float64, iq bool, step sigmf.HistoryEntry) *sigmf.Meta {
+	meta := sigmf.NewMeta("", sampleRate)
+	if input != nil && input.meta != nil {
+		source := input.meta.Global
+		meta.Global.Description = source.Description
+		meta.Global.Author = source.Author
+		meta.Global.Recorder = source.Recorder
+		meta.Global.Hardware = source.Hardware
+		meta.Global.Extensions = append([]sigmf.Extension(nil), source.Extensions...)
+		meta.Global.History = append([]sigmf.HistoryEntry(nil), source.History...)
+		meta.Annotations = outputAnnotations(input, sampleRate, iq)
+	}
+	meta.AddHistory(step)
+	return meta
+}
+
+// outputAnnotations maps the annotations of SigMF input onto the output
+func outputAnnotations(input *inputSignal, sampleRate float64, iq bool) []sigmf.Annotation {
+	ratio := sampleRate / input.sampleRate()
+	annotations := make([]sigmf.Annotation, len(input.meta.Annotations))
+	for i, a := range input.meta.Annotations {
+		if ratio != 1 {
+			end := uint64(math.Round(float64(a.SampleStart+a.SampleCount) * ratio))
+			a.SampleStart = uint64(math.Round(float64(a.SampleStart) * ratio))
+			if a.SampleCount > 0 {
+				a.SampleCount = max(end-a.SampleStart, 1)
+			}
+		}
+		if input.iq != nil && !iq {
+			a.FreqLowerEdge, a.FreqUpperEdge = 0, 0
+		}
+		annotations[i] = a
+	}
+	return annotations
+}
+
+// writeSigMFReal writes a real signal as a SigMF recording
+func writeSigMFReal(name string, signal *domain.Signal, meta *sigmf.Meta) error {
+	return sigmf.WriteReal(name, signal.Samples, meta)
+}
+
+// writeSigMFIQ writes a complex signal as a SigMF recording, recording its
+// center frequency and start time in the first capture
+func writeSigMFIQ(name string, signal *domain.IQSignal, meta *sigmf.Meta) error {
+	meta.Captures[0].Frequency = signal.CenterFreq
+	if !signal.Timestamp.IsZero() {
+		meta.Captures[0].Datetime = signal.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	return sigmf.WriteComplex(name, signal.Samples, meta)
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Vivirinter/sdr-parser/internal/adapters/demodulators"
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/rds"
+	"github.com/spf13/cobra"
+)
+
+func getRDSCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rds",
+		Short: "Decode RDS/RBDS data from a broadcast FM capture",
+		Long: `Decode the Radio Data System on the 57 kHz subcarrier of a broadcast FM
+station and write the programme identification (PI), programme type (PTY),
+programme service name (PS), RadioText and clock time (CT) as JSON lines,
+one object per change.
+
+I/Q input is tuned to the station and FM-demodulated first; real input is
+taken to be the FM multiplex (MPX) already. Either must be sampled at
+119 kHz or more.`,
+		RunE: decodeRDS,
+	}
+
+	cmd.Flags().StringP("input", "i", "", "input WAV, SigMF or raw I/Q file")
+	cmd.Flags().StringP("output", "o", "", "output JSON lines file (default stdout)")
+	cmd.Flags().Float64P("rate", "r", 0, "sample rate (Hz), required for raw I/Q input")
+	cmd.Flags().Bool("iq", false, "treat stereo WAV input as baseband I/Q (I left, Q right)")
+	cmd.Flags().Float64("offset", 0, "decode the I/Q channel this far from the center (Hz)")
+	cmd.Flags().Float64("tune", 0, "decode the I/Q channel at this RF frequency (Hz); needs the center frequency")
+	cmd.Flags().Float64("center-freq", 0, "center frequency (Hz) of I/Q input; default from the file metadata")
+	cmd.Flags().Float64("bandwidth", 0, "low-pass the tuned I/Q channel to this bandwidth (Hz); default no channel filter")
+	cmd.Flags().Bool("rbds", false, "name programme types with the North American RBDS table")
+	addInputFlags(cmd)
+
+	cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+func decodeRDS(cmd *cobra.Command, args []string) error {
+	input, _ := cmd.Flags().GetString("input")
+	output, _ := cmd.Flags().GetString("output")
+	sampleRate, _ := cmd.Flags().GetFloat64("rate")
+	iq, _ := cmd.Flags().GetBool("iq")
+	offset, _ := cmd.Flags().GetFloat64("offset")
+	tune, _ := cmd.Flags().GetFloat64("tune")
+	centerFreq, _ := cmd.Flags().GetFloat64("center-freq")
+	bandwidth, _ := cmd.Flags().GetFloat64("bandwidth")
+	rbds, _ := cmd.Flags().GetBool("rbds")
+	if offset != 0 && tune != 0 {
+		return fmt.Errorf("--offset and --tune are mutually exclusive")
+	}
+
+	opts := getInputOptions(cmd, sampleRate)
+	opts.iq = iq
+	stream, err := openInput(input, opts)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	defer stream.Close()
+	if centerFreq != 0 {
+		stream.centerFreq = centerFreq
+	}
+
+	// I/Q is tuned and FM-demodulated to MPX, without de-emphasis
+	var channel *channelSelector
+	var discriminator *demodulators.DemodAdapter
+	if stream.iq {
+		if offset != 0 || tune != 0 || bandwidth > 0 {
+			// The offset is resolved here so the selector does not print
+			// into the JSON lines on stdout
+			band := domain.IQSignal{SampleRate: stream.sampleRate, CenterFreq: stream.centerFreq}
+			if tune != 0 {
+				if offset, err = band.BasebandOffset(tune); err != nil {
+					return err
+				}
+			}
+			band.CenterFreq = 0
+			if channel, err = newChannelSelector(&band, offset, 0, bandwidth); err != nil {
+				return err
+			}
+		}
+		if discriminator, err = demodulators.NewDemodAdapter("wbfm"); err != nil {
+			return err
+		}
+		config := discriminator.GetConfig()
+		config.SampleRate = stream.sampleRate
+		config.Deemphasis = 0
+		if err := discriminator.Configure(config); err != nil {
+			return err
+		}
+	} else if offset != 0 || tune != 0 || bandwidth > 0 {
+		return fmt.Errorf("--offset, --tune and --bandwidth need I/Q input")
+	}
+
+	demodulator, err := rds.NewDemodulator(stream.sampleRate)
+	if err != nil {
+		return err
+	}
+	decoder := rds.NewDecoder()
+	station := rds.NewStation(rbds)
+
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+
+	var groups, corrected, messages int
+	realBlock := make([]float64, streamBlockSize)
+	iqBlock := make([]complex128, streamBlockSize)
+	for {
+		var mpx []float64
+		var n int
+		if stream.iq {
+			if n, err = stream.readIQ(iqBlock); err == nil {
+				mpx, err = rdsMPX(domain.NewIQSignal(iqBlock[:n], stream.sampleRate), channel, discriminator)
+			}
+		} else if n, err = stream.readReal(realBlock); err == nil {
+			mpx = realBlock[:n]
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		for _, group := range decoder.Push(demodulator.Process(mpx)) {
+			groups++
+			corrected += group.Corrected
+			for _, m := range station.Update(group) {
+				// Report the time in the input rather than the bit stream
+				m.Time -= demodulator.Latency()
+				if discriminator != nil {
+					m.Time -= discriminator.Latency() / stream.sampleRate
+				}
+				if channel != nil {
+					m.Time -= channel.latency() / stream.sampleRate
+				}
+				if err := encoder.Encode(m); err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
+				messages++
+			}
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	if output != "" {
+		if groups == 0 {
+			fmt.Println("No RDS groups found")
+		} else {
+			fmt.Printf("Decoded %d groups (%d blocks corrected), %d messages; written to %s\n",
+				groups, corrected, messages, output)
+		}
+	}
+	return nil
+}
+
+// rdsMPX tunes a block of I/Q to the channel when it is set and
+// FM-demodulates it to the multiplex signal
+func rdsMPX(block *domain.IQSignal, channel *channelSelector, discriminator *demodulators.DemodAdapter) ([]float64, error) {
+	var err error
+	if channel != nil {
+		if block, err = channel.process(block); err != nil {
+			return nil, err
+		}
+	}
+	mpx, err := discriminator.DemodulateIQ(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to demodulate signal: %w", err)
+	}
+	return mpx.Samples, nil
+}
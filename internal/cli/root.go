@@ -7,11 +7,26 @@ import (
 
 var cfgFile string
 
-var rootCmd = &cobra.Command{
-	Use:   "sdrparser",
-	Short: "SDR Parser - tool for processing Software Defined Radio signals",
-	Long: `SDR Parser is a command line tool for processing Software Defined Radio signals.
+var rootCmd = newRootCmd()
+
+func newRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sdrparser",
+		Short: "SDR Parser - tool for processing Software Defined Radio signals",
+		Long: `SDR Parser is a command line tool for processing Software Defined Radio signals.
 It supports various operations like filtering, modulation/demodulation, and signal analysis.`,
+	}
+	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file")
+
+	// Add commands
+	cmd.AddCommand(getGenerateCmd())
+	cmd.AddCommand(getDemodCmd())
+	cmd.AddCommand(getFilterCmd())
+	cmd.AddCommand(getSpectrumCmd())
+	cmd.AddCommand(getSpectrogramCmd())
+	cmd.AddCommand(getResampleCmd())
+	cmd.AddCommand(getRDSCmd())
+	return cmd
 }
 
 // Execute starts the CLI application
@@ -19,14 +34,18 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// Run executes the CLI with args on a fresh command tree, so no flag
+// values carry over from earlier runs. Errors are returned, not printed.
+func Run(args []string) error {
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return cmd.Execute()
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file")
-
-	// Add commands
-	rootCmd.AddCommand(getGenerateCmd())
-	rootCmd.AddCommand(getDemodCmd())
-	rootCmd.AddCommand(getFilterCmd())
 }
 
 func initConfig() {
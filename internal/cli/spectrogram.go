@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/Vivirinter/sdr-parser/pkg/spectrum"
+	"github.com/Vivirinter/sdr-parser/pkg/waterfall"
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+	"github.com/spf13/cobra"
+)
+
+func getSpectrogramCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spectrogram",
+		Short: "Render a waterfall of a signal as a PNG image",
+		Long: `Render a short-time Fourier transform as a waterfall, with time running
+down and frequency across. I/Q input shows both sidebands, in absolute Hz when
+the center frequency is known; real input shows 0 Hz to Nyquist. Long captures
+are reduced to --max-rows rows by averaging consecutive spectra.`,
+		RunE: renderSpectrogram,
+	}
+
+	cmd.Flags().StringP("input", "i", "", "input WAV, SigMF or raw I/Q file")
+	cmd.Flags().StringP("output", "o", "", "output PNG file")
+	cmd.Flags().Float64P("rate", "r", 0, "sample rate (Hz), required for raw I/Q input")
+	cmd.Flags().Bool("iq", false, "treat stereo WAV input as baseband I/Q (I left, Q right)")
+	cmd.Flags().Float64("center-freq", 0, "center frequency (Hz) of I/Q input; default from the file metadata")
+	cmd.Flags().IntP("fft-size", "n", 1024, "FFT size in samples (frequency resolution is rate/size)")
+	cmd.Flags().Int("hop", 0, "samples between FFT frames (default half the FFT size)")
+	cmd.Flags().StringP("window", "w", string(window.Hann), "window (hann, hamming, blackman-harris, flattop, kaiser, rectangular)")
+	cmd.Flags().Float64("beta", window.DefaultKaiserBeta, "Kaiser window shape parameter")
+	cmd.Flags().Float64("db-min", 0, "level (dB) of the lowest colour (default near the noise floor)")
+	cmd.Flags().Float64("db-max", 0, "level (dB) of the highest colour (default the strongest level)")
+	cmd.Flags().StringP("colormap", "c", string(waterfall.Viridis), "colormap (viridis, inferno, grayscale)")
+	cmd.Flags().Int("max-rows", 2048, "maximum number of rows before spectra are averaged together")
+	cmd.Flags().Int("width", 0, "plot width in pixels (default one column per bin, at most 2048)")
+	cmd.Flags().Int("height", 0, "plot height in pixels (default one row per spectrum)")
+	addInputFlags(cmd)
+
+	cmd.MarkFlagRequired("input")
+	cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func renderSpectrogram(cmd *cobra.Command, args []string) error {
+	input, _ := cmd.Flags().GetString("input")
+	output, _ := cmd.Flags().GetString("output")
+	sampleRate, _ := cmd.Flags().GetFloat64("rate")
+	iq, _ := cmd.Flags().GetBool("iq")
+	centerFreq, _ := cmd.Flags().GetFloat64("center-freq")
+	fftSize, _ := cmd.Flags().GetInt("fft-size")
+	hop, _ := cmd.Flags().GetInt("hop")
+	windowType, _ := cmd.Flags().GetString("window")
+	beta, _ := cmd.Flags().GetFloat64("beta")
+	minDB, _ := cmd.Flags().GetFloat64("db-min")
+	maxDB, _ := cmd.Flags().GetFloat64("db-max")
+	colormap, _ := cmd.Flags().GetString("colormap")
+	maxRows, _ := cmd.Flags().GetInt("max-rows")
+	width, _ := cmd.Flags().GetInt("width")
+	height, _ := cmd.Flags().GetInt("height")
+
+	if err := waterfall.Colormap(colormap).Validate(); err != nil {
+		return err
+	}
+	if maxRows < 1 {
+		return fmt.Errorf("max rows must be positive, got %d", maxRows)
+	}
+	if hop == 0 {
+		hop = max(1, fftSize/2)
+	}
+
+	opts := getInputOptions(cmd, sampleRate)
+	opts.iq = iq
+	stream, err := openInput(input, opts)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	defer stream.Close()
+
+	// Average enough frames per row to keep within max rows
+	config := spectrum.SpectrogramConfig{
+		FFTSize:      fftSize,
+		Hop:          hop,
+		Window:       window.Type(windowType),
+		Beta:         beta,
+		FramesPerRow: 1,
+	}
+	if frames := (stream.length-int64(fftSize))/int64(max(hop, 1)) + 1; frames > int64(maxRows) {
+		config.FramesPerRow = int((frames + int64(maxRows) - 1) / int64(maxRows))
+	}
+	stft, err := spectrum.NewSpectrogram(config, stream.sampleRate, stream.iq)
+	if err != nil {
+		return err
+	}
+
+	realBlock := make([]float64, streamBlockSize)
+	iqBlock := make([]complex128, streamBlockSize)
+	for {
+		var n int
+		if stream.iq {
+			if n, err = stream.readIQ(iqBlock); err == nil {
+				stft.AddIQ(iqBlock[:n])
+			}
+		} else if n, err = stream.readReal(realBlock); err == nil {
+			stft.AddReal(realBlock[:n])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+	}
+
+	rows := stft.Rows()
+	if len(rows) == 0 {
+		return fmt.Errorf("signal is shorter than one FFT frame of %d samples", fftSize)
+	}
+
+	// Absolute RF frequencies for I/Q input with a known center frequency
+	frequencies := stft.Frequencies()
+	if centerFreq == 0 {
+		centerFreq = stream.centerFreq
+	}
+	if stream.iq {
+		for i := range frequencies {
+			frequencies[i] += centerFreq
+		}
+	}
+
+	autoMin, autoMax := waterfall.AutoRange(rows)
+	if !cmd.Flags().Changed("db-min") {
+		minDB = autoMin
+	}
+	if !cmd.Flags().Changed("db-max") {
+		maxDB = autoMax
+	}
+
+	img, err := waterfall.Render(rows, frequencies, stft.RowDuration(), waterfall.Options{
+		Colormap: waterfall.Colormap(colormap),
+		MinDB:    minDB,
+		MaxDB:    maxDB,
+		Width:    width,
+		Height:   height,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render spectrogram: %w", err)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+
+	fmt.Printf("Rendered %d frames in %d rows (%.1f to %.1f dB) to %s\n",
+		stft.Frames(), len(rows), minDB, maxDB, output)
+	return nil
+}
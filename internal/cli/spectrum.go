@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Vivirinter/sdr-parser/pkg/spectrum"
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the spectrum command
+const (
+	outputCSV  = "csv"
+	outputJSON = "json"
+)
+
+func getSpectrumCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spectrum",
+		Short: "Estimate the power spectral density of a signal",
+		Long: `Estimate the power spectral density with Welch averaging of windowed,
+overlapping segments. I/Q input gives a two-sided spectrum, in absolute Hz
+when the center frequency is known; real input gives a one-sided spectrum.`,
+		RunE: estimateSpectrum,
+	}
+
+	cmd.Flags().StringP("input", "i", "", "input WAV, SigMF or raw I/Q file")
+	cmd.Flags().StringP("output", "o", "", "output CSV or JSON file (default stdout)")
+	cmd.Flags().String("output-format", "", "output format (csv, json); default from the output extension, else csv")
+	cmd.Flags().Float64P("rate", "r", 0, "sample rate (Hz), required for raw I/Q input")
+	cmd.Flags().Bool("iq", false, "treat stereo WAV input as baseband I/Q (I left, Q right)")
+	cmd.Flags().Float64("center-freq", 0, "center frequency (Hz) of I/Q input; default from the file metadata")
+	cmd.Flags().IntP("segment", "n", 1024, "segment length in samples (frequency resolution is rate/segment)")
+	cmd.Flags().Float64("overlap", 0.5, "fraction of each segment overlapping the next, in [0, 1)")
+	cmd.Flags().StringP("window", "w", string(window.Hann), "window (hann, hamming, blackman-harris, flattop, kaiser, rectangular)")
+	cmd.Flags().Float64("beta", window.DefaultKaiserBeta, "Kaiser window shape parameter")
+	addInputFlags(cmd)
+
+	cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+func estimateSpectrum(cmd *cobra.Command, args []string) error {
+	input, _ := cmd.Flags().GetString("input")
+	output, _ := cmd.Flags().GetString("output")
+	format, _ := cmd.Flags().GetString("output-format")
+	sampleRate, _ := cmd.Flags().GetFloat64("rate")
+	iq, _ := cmd.Flags().GetBool("iq")
+	centerFreq, _ := cmd.Flags().GetFloat64("center-freq")
+	segment, _ := cmd.Flags().GetInt("segment")
+	overlap, _ := cmd.Flags().GetFloat64("overlap")
+	windowType, _ := cmd.Flags().GetString("window")
+	beta, _ := cmd.Flags().GetFloat64("beta")
+
+	if format == "" {
+		format = outputCSV
+		if strings.EqualFold(filepath.Ext(output), ".json") {
+			format = outputJSON
+		}
+	}
+	if format != outputCSV && format != outputJSON {
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	opts := getInputOptions(cmd, sampleRate)
+	opts.iq = iq
+	stream, err := openInput(input, opts)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	defer stream.Close()
+
+	config := spectrum.WelchConfig{
+		SegmentLength: segment,
+		Overlap:       overlap,
+		Window:        window.Type(windowType),
+		Beta:          beta,
+	}
+	welch, err := spectrum.NewWelch(config, stream.sampleRate, stream.iq)
+	if err != nil {
+		return err
+	}
+
+	// Accumulate segments block by block
+	realBlock := make([]float64, streamBlockSize)
+	iqBlock := make([]complex128, streamBlockSize)
+	for {
+		var n int
+		if stream.iq {
+			if n, err = stream.readIQ(iqBlock); err == nil {
+				welch.AddIQ(iqBlock[:n])
+			}
+		} else if n, err = stream.readReal(realBlock); err == nil {
+			welch.AddReal(realBlock[:n])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+	}
+
+	psd, err := welch.Result()
+	if err != nil {
+		return err
+	}
+
+	// Absolute RF frequencies for I/Q input with a known center frequency
+	if centerFreq == 0 {
+		centerFreq = stream.centerFreq
+	}
+	if stream.iq && centerFreq != 0 {
+		psd.Shift(centerFreq)
+	} else {
+		centerFreq = 0
+	}
+
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if format == outputJSON {
+		err = writeSpectrumJSON(w, psd, config, centerFreq)
+	} else {
+		err = writeSpectrumCSV(w, psd)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write spectrum: %w", err)
+	}
+
+	if output != "" {
+		peakFreq, peakPower := psd.Peak()
+		fmt.Printf("Averaged %d segments; peak %.1f dB/Hz at %.0f Hz; written to %s\n",
+			psd.Segments, 10*math.Log10(peakPower), peakFreq, output)
+	}
+	return nil
+}
+
+// writeSpectrumCSV writes one row per frequency bin
+func writeSpectrumCSV(w io.Writer, psd *spectrum.PSD) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "frequency_hz,psd,psd_db")
+	for i, db := range psd.PowerDB() {
+		fmt.Fprintf(out, "%s,%s,%s\n",
+			strconv.FormatFloat(psd.Frequencies[i], 'f', -1, 64),
+			strconv.FormatFloat(psd.Power[i], 'g', -1, 64),
+			strconv.FormatFloat(db, 'f', 3, 64))
+	}
+	return out.Flush()
+}
+
+// spectrumJSON is the JSON layout of a spectrum estimate
+type spectrumJSON struct {
+	SampleRate  float64              `json:"sample_rate"`
+	CenterFreq  float64              `json:"center_freq,omitempty"`
+	Welch       spectrum.WelchConfig `json:"welch"`
+	Segments    int                  `json:"segments"`
+	OneSided    bool                 `json:"one_sided"`
+	Frequencies []float64            `json:"frequencies_hz"`
+	PSD         []float64            `json:"psd"`
+	PSDdB       []float64            `json:"psd_db"`
+}
+
+// writeSpectrumJSON writes the estimate with its parameters
+func writeSpectrumJSON(w io.Writer, psd *spectrum.PSD, config spectrum.WelchConfig, centerFreq float64) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(spectrumJSON{
+		SampleRate:  psd.SampleRate,
+		CenterFreq:  centerFreq,
+		Welch:       config,
+		Segments:    psd.Segments,
+		OneSided:    psd.OneSided,
+		Frequencies: psd.Frequencies,
+		PSD:         psd.Power,
+		PSDdB:       psd.PowerDB(),
+	})
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Vivirinter/sdr-parser/pkg/rawiq"
+	"github.com/Vivirinter/sdr-parser/pkg/reader"
+)
+
+// streamBlockSize is the number of samples processed per block when streaming
+const streamBlockSize = 65536
+
+// inputStream reads a capture block by block. WAV and raw I/Q files are
+// streamed from disk, so long recordings are processed in constant memory.
+type inputStream struct {
+	wav *reader.WAVReader
+	raw *rawiq.Reader
+	mem *inputSignal // Input read whole, for formats without block reads
+	pos int          // Read position in mem
+
+	iq         bool         // Blocks are read with readIQ rather than readReal
+	rawConfig  rawiq.Config // Encoding of raw I/Q input, used to write matching output
+	sampleRate float64
+	length     int64     // Samples per channel, 0 if unknown
+	centerFreq float64   // From the auxi chunk of baseband WAV files (0 if unknown)
+	timestamp  time.Time // From the auxi chunk of baseband WAV files
+}
+
+// openInputStream opens the input capture for block reads. It returns nil
+// without an error for formats that are only read whole, such as SigMF.
+func openInputStream(filename string, opts inputOptions) (*inputStream, error) {
+	if opts.format == formatAuto {
+		opts.format = detectFormat(filename)
+	}
+
+	switch opts.format {
+	case formatSigMF:
+		return nil, nil
+
+	case formatWAV:
+		wav, err := reader.NewWAVReader(filename)
+		if err != nil {
+			return nil, err
+		}
+		if opts.iq && wav.Header.NumChannels != 2 {
+			wav.Close()
+			return nil, fmt.Errorf("I/Q WAV files must have 2 channels, got %d", wav.Header.NumChannels)
+		}
+
+		stream := &inputStream{
+			wav:        wav,
+			iq:         opts.iq,
+			sampleRate: float64(wav.Header.SampleRate),
+		}
+		if blockAlign := uint64(wav.Header.BlockAlign); blockAlign > 0 {
+			stream.length = int64(wav.DataSize / blockAlign)
+		}
+		if opts.sampleRate > 0 {
+			stream.sampleRate = opts.sampleRate
+		}
+		if auxi := wav.Metadata.Auxi; opts.iq && auxi != nil {
+			stream.centerFreq = float64(auxi.CenterFreq)
+			stream.timestamp = auxi.StartTime
+		}
+		return stream, nil
+	}
+
+	config, err := opts.rawConfig()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rawiq.Open(filename, config)
+	if err != nil {
+		return nil, err
+	}
+	stream := &inputStream{
+		raw:        raw,
+		iq:         true,
+		rawConfig:  config,
+		sampleRate: config.SampleRate,
+	}
+	if info, err := os.Stat(filename); err == nil {
+		stream.length = info.Size() / int64(config.Format.SampleSize())
+	}
+	return stream, nil
+}
+
+// openInput opens the input capture for block reads like openInputStream,
+// reading formats without block reads whole
+func openInput(filename string, opts inputOptions) (*inputStream, error) {
+	stream, err := openInputStream(filename, opts)
+	if err != nil || stream != nil {
+		return stream, err
+	}
+
+	input, err := readInput(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream = &inputStream{
+		mem:        input,
+		iq:         input.iq != nil,
+		sampleRate: input.sampleRate(),
+	}
+	if input.real != nil {
+		stream.length = int64(len(input.real.Samples))
+	}
+	if input.iq != nil {
+		stream.length = int64(len(input.iq.Samples))
+		stream.centerFreq = input.iq.CenterFreq
+		stream.timestamp = input.iq.Timestamp
+	}
+	return stream, nil
+}
+
+// readReal reads the next block of a real capture, returning io.EOF at the end
+func (s *inputStream) readReal(buf []float64) (int, error) {
+	if s.mem != nil {
+		n := copy(buf, s.mem.real.Samples[s.pos:])
+		return s.advance(n)
+	}
+	return s.wav.ReadBlock(buf)
+}
+
+// readIQ reads the next block of an I/Q capture, returning io.EOF at the end
+func (s *inputStream) readIQ(buf []complex128) (int, error) {
+	switch {
+	case s.mem != nil:
+		n := copy(buf, s.mem.iq.Samples[s.pos:])
+		return s.advance(n)
+	case s.raw != nil:
+		return s.raw.ReadBlock(buf)
+	}
+	return s.wav.ReadIQBlock(buf)
+}
+
+// advance moves the in-memory read position by n samples
+func (s *inputStream) advance(n int) (int, error) {
+	if n == 0 {
+		return 0, io.EOF
+	}
+	s.pos += n
+	return n, nil
+}
+
+// Close closes the underlying file
+func (s *inputStream) Close() error {
+	switch {
+	case s.mem != nil:
+		return nil
+	case s.raw != nil:
+		return s.raw.Close()
+	}
+	return s.wav.Close()
+}
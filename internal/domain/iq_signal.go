@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"math/cmplx"
+	"time"
+)
+
+// IQSignal represents a complex baseband (in-phase/quadrature) signal
+// in the time domain, as produced by an SDR receiver.
+type IQSignal struct {
+	Samples    []complex128
+	SampleRate float64
+	CenterFreq float64   // RF frequency at DC in Hz (0 if unknown)
+	Timestamp  time.Time // Capture time of the first sample (zero if unknown)
+}
+
+// NewIQSignal creates a new IQSignal instance
+func NewIQSignal(samples []complex128, sampleRate float64) *IQSignal {
+	return &IQSignal{
+		Samples:    samples,
+		SampleRate: sampleRate,
+	}
+}
+
+// NewIQSignalFromChannels combines separate in-phase and quadrature signals
+// into a single complex signal
+func NewIQSignalFromChannels(i, q *Signal) (*IQSignal, error) {
+	if i.SampleRate != q.SampleRate {
+		return nil, fmt.Errorf("sample rate mismatch: I=%g Hz, Q=%g Hz", i.SampleRate, q.SampleRate)
+	}
+	if len(i.Samples) != len(q.Samples) {
+		return nil, fmt.Errorf("length mismatch: I=%d, Q=%d samples", len(i.Samples), len(q.Samples))
+	}
+
+	samples := make([]complex128, len(i.Samples))
+	for n := range samples {
+		samples[n] = complex(i.Samples[n], q.Samples[n])
+	}
+	return NewIQSignal(samples, i.SampleRate), nil
+}
+
+// BasebandOffset converts an absolute RF frequency to an offset from DC,
+// which requires the center frequency to be known
+func (s *IQSignal) BasebandOffset(freq float64) (float64, error) {
+	if s.CenterFreq == 0 {
+		return 0, fmt.Errorf("center frequency unknown, cannot convert %g Hz to a baseband offset", freq)
+	}
+	offset := freq - s.CenterFreq
+	if offset < -s.SampleRate/2 || offset > s.SampleRate/2 {
+		return 0, fmt.Errorf("%g Hz is outside the captured band %g-%g Hz",
+			freq, s.CenterFreq-s.SampleRate/2, s.CenterFreq+s.SampleRate/2)
+	}
+	return offset, nil
+}
+
+// Duration returns the duration of the signal in seconds
+func (s *IQSignal) Duration() float64 {
+	return float64(len(s.Samples)) / s.SampleRate
+}
+
+// Clone creates a deep copy of the signal
+func (s *IQSignal) Clone() *IQSignal {
+	samples := make([]complex128, len(s.Samples))
+	copy(samples, s.Samples)
+	return &IQSignal{
+		Samples:    samples,
+		SampleRate: s.SampleRate,
+		CenterFreq: s.CenterFreq,
+		Timestamp:  s.Timestamp,
+	}
+}
+
+// InPhase returns the in-phase (real) component as a real signal
+func (s *IQSignal) InPhase() *Signal {
+	samples := make([]float64, len(s.Samples))
+	for n, v := range s.Samples {
+		samples[n] = real(v)
+	}
+	return NewSignal(samples, s.SampleRate)
+}
+
+// Quadrature returns the quadrature (imaginary) component as a real signal
+func (s *IQSignal) Quadrature() *Signal {
+	samples := make([]float64, len(s.Samples))
+	for n, v := range s.Samples {
+		samples[n] = imag(v)
+	}
+	return NewSignal(samples, s.SampleRate)
+}
+
+// Magnitude returns the instantaneous envelope |I + jQ| as a real signal
+func (s *IQSignal) Magnitude() *Signal {
+	samples := make([]float64, len(s.Samples))
+	for n, v := range s.Samples {
+		samples[n] = cmplx.Abs(v)
+	}
+	return NewSignal(samples, s.SampleRate)
+}
+
+// Phase returns the instantaneous phase arg(I + jQ) in radians as a real signal
+func (s *IQSignal) Phase() *Signal {
+	samples := make([]float64, len(s.Samples))
+	for n, v := range s.Samples {
+		samples[n] = cmplx.Phase(v)
+	}
+	return NewSignal(samples, s.SampleRate)
+}
+
+// ToIQ converts a real signal to a complex one with a zero quadrature component
+func (s *Signal) ToIQ() *IQSignal {
+	samples := make([]complex128, len(s.Samples))
+	for n, v := range s.Samples {
+		samples[n] = complex(v, 0)
+	}
+	return NewIQSignal(samples, s.SampleRate)
+}
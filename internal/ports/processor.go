@@ -35,3 +35,32 @@ type SignalFilter interface {
 	Filter(signal *domain.Signal) (*domain.Signal, error)
 	Configure(params map[string]interface{}) error
 }
+
+// IQSignalDemodulator defines the interface for demodulation of complex baseband signals
+type IQSignalDemodulator interface {
+	DemodulateIQ(signal *domain.IQSignal) (*domain.Signal, error)
+}
+
+// IQSignalFilter defines the interface for filtering of complex baseband signals
+type IQSignalFilter interface {
+	FilterIQ(signal *domain.IQSignal) (*domain.IQSignal, error)
+	Configure(params map[string]interface{}) error
+}
+
+// StreamingProcessor is implemented by filters and demodulators that accept a
+// signal in consecutive blocks, producing the same output as a single call
+type StreamingProcessor interface {
+	// Reset clears the processing state so the next block starts a new signal
+	Reset()
+	// Latency returns the delay of the output relative to the input in samples
+	Latency() float64
+}
+
+// AlignedFilter is implemented by filters whose output can be lined up
+// with the input: drop the first Alignment() output samples and, at the
+// end of the signal, append the samples Flush returns
+type AlignedFilter interface {
+	Alignment() int
+	Flush(sampleRate float64) *domain.Signal
+	FlushIQ(sampleRate float64) (*domain.IQSignal, error)
+}
@@ -1,6 +1,12 @@
 package demod
 
-import "math"
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vivirinter/sdr-parser/pkg/hilbert"
+	"github.com/Vivirinter/sdr-parser/pkg/mixer"
+)
 
 type DemodulationType int
 
@@ -11,6 +17,38 @@ const (
 	LSB
 )
 
+func (t DemodulationType) String() string {
+	switch t {
+	case AM:
+		return "am"
+	case FM:
+		return "fm"
+	case USB:
+		return "usb"
+	case LSB:
+		return "lsb"
+	default:
+		return fmt.Sprintf("DemodulationType(%d)", int(t))
+	}
+}
+
+// ParseDemodulationType converts a name such as "am" or "fm" to a
+// DemodulationType. The FM presets "nbfm" and "wbfm" parse as FM.
+func ParseDemodulationType(name string) (DemodulationType, error) {
+	switch name {
+	case "am":
+		return AM, nil
+	case "fm", "nbfm", "wbfm":
+		return FM, nil
+	case "usb":
+		return USB, nil
+	case "lsb":
+		return LSB, nil
+	default:
+		return 0, fmt.Errorf("unknown demodulation type: %s", name)
+	}
+}
+
 type GainMode int
 
 const (
@@ -18,6 +56,9 @@ const (
 	AGC
 )
 
+// AGCSettings configure the AGC gain mode. The attack and release times
+// in seconds smooth the output envelope while it rises and falls; the gain
+// brings the envelope to Target within [MinGain, MaxGain].
 type AGCSettings struct {
 	AttackTime  float64
 	ReleaseTime float64
@@ -27,10 +68,27 @@ type AGCSettings struct {
 }
 
 type DemodulatorConfig struct {
-	Type       DemodulationType
+	Type DemodulationType
+	// GainMode selects the gain of AM and SSB output: ManualGain (0 for
+	// unity) or AGC with AGCConfig, which needs SampleRate
 	GainMode   GainMode
 	ManualGain float64
 	AGCConfig  AGCSettings
+
+	// SampleRate is the input sample rate in Hz, which FM needs to scale
+	// Deviation and to de-emphasize
+	SampleRate float64
+	// Deviation is the FM peak deviation in Hz, which gives output ±1.
+	// Without it or SampleRate, ±1 is half the sample rate.
+	Deviation float64
+	// Deemphasis is the FM de-emphasis time constant in seconds
+	// (Deemphasis50us, Deemphasis75us), 0 for none
+	Deemphasis float64
+
+	// Carrier is the suppressed carrier of real SSB input in cycles per
+	// sample (Hz divided by the sample rate). At 0 the input is audio
+	// whose upper sideband is the whole signal.
+	Carrier float64
 }
 
 type GainMetrics struct {
@@ -39,150 +97,106 @@ type GainMetrics struct {
 	GainReduction float64
 }
 
+// Demodulator demodulates real-valued samples.
+//
+// Demodulators are streaming: Demodulate may be called on consecutive blocks
+// of one signal, and the concatenated output equals the output of
+// demodulating the whole signal at once.
 type Demodulator interface {
 	Demodulate(samples []float64) ([]float64, GainMetrics)
+
+	// Reset clears the demodulator state so the next block starts a new signal
+	Reset()
+	// Latency returns the delay of the output relative to the input in samples
+	Latency() float64
+}
+
+type AMDemod struct {
+	gain gainStage
 }
 
-type AMDemod struct{}
-type FMDemod struct{}
-type USBDemod struct{}
-type LSBDemod struct{}
+// USBDemod and LSBDemod turn real input into its analytic signal, shift
+// the carrier to DC and select a sideband like I/Q input, so real output
+// lags the input by two Hilbert transformers
+type USBDemod struct {
+	gain     gainStage
+	passband passband
+	sideband sidebandDemod
+}
+
+type LSBDemod struct {
+	gain     gainStage
+	passband passband
+	sideband sidebandDemod
+}
 
 func (d *AMDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
 	output := make([]float64, len(samples))
-	maxAmp := 0.0
-
 	for i, sample := range samples {
-		if sample < 0 {
-			sample = -sample
-		}
-		if sample > maxAmp {
-			maxAmp = sample
-		}
-		output[i] = sample
-	}
-
-	gain := 1.0
-	if maxAmp > 0 {
-		gain = 0.7 / maxAmp
-	}
-
-	for i := range output {
-		output[i] *= gain
-	}
-
-	metrics := GainMetrics{
-		CurrentGain:   gain,
-		CompressionDB: 20 * log10(gain),
-		GainReduction: 1 / gain,
+		output[i] = math.Abs(sample)
 	}
-
-	return output, metrics
+	return output, d.gain.apply(output)
 }
 
-func (d *FMDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
-	output := make([]float64, len(samples)-1)
-	maxAmp := 0.0
-
-	for i := 0; i < len(samples)-1; i++ {
-		diff := samples[i+1] - samples[i]
-		if diff < 0 {
-			diff = -diff
-		}
-		if diff > maxAmp {
-			maxAmp = diff
-		}
-		output[i] = diff
-	}
-
-	gain := 1.0
-	if maxAmp > 0 {
-		gain = 0.7 / maxAmp
-	}
-
-	for i := range output {
-		output[i] *= gain
-	}
-
-	metrics := GainMetrics{
-		CurrentGain:   gain,
-		CompressionDB: 20 * log10(gain),
-		GainReduction: 1 / gain,
-	}
+func (d *AMDemod) Reset() {
+	d.gain.reset()
+}
 
-	return output, metrics
+func (d *AMDemod) Latency() float64 {
+	return 0
 }
 
 func (d *USBDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
-	output := make([]float64, len(samples))
-	maxAmp := 0.0
-
-	for i := 0; i < len(samples); i++ {
-		val := samples[i]
-		if i > 0 {
-			val += samples[i-1]
-		}
-		if val < 0 {
-			val = -val
-		}
-		if val > maxAmp {
-			maxAmp = val
-		}
-		output[i] = val
-	}
+	output := d.sideband.process(d.passband.process(samples), -1)
+	return output, d.gain.apply(output)
+}
 
-	gain := 1.0
-	if maxAmp > 0 {
-		gain = 0.7 / maxAmp
-	}
+func (d *USBDemod) Reset() {
+	d.gain.reset()
+	d.passband.reset()
+	d.sideband.reset()
+}
 
-	for i := range output {
-		output[i] *= gain
-	}
+func (d *USBDemod) Latency() float64 {
+	return 2 * (hilbertTaps / 2)
+}
 
-	metrics := GainMetrics{
-		CurrentGain:   gain,
-		CompressionDB: 20 * log10(gain),
-		GainReduction: 1 / gain,
-	}
+func (d *LSBDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
+	output := d.sideband.process(d.passband.process(samples), 1)
+	return output, d.gain.apply(output)
+}
 
-	return output, metrics
+func (d *LSBDemod) Reset() {
+	d.gain.reset()
+	d.passband.reset()
+	d.sideband.reset()
 }
 
-func (d *LSBDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
-	output := make([]float64, len(samples))
-	maxAmp := 0.0
-
-	for i := 0; i < len(samples); i++ {
-		val := samples[i]
-		if i < len(samples)-1 {
-			val -= samples[i+1]
-		}
-		if val < 0 {
-			val = -val
-		}
-		if val > maxAmp {
-			maxAmp = val
-		}
-		output[i] = val
-	}
+func (d *LSBDemod) Latency() float64 {
+	return 2 * (hilbertTaps / 2)
+}
 
-	gain := 1.0
-	if maxAmp > 0 {
-		gain = 0.7 / maxAmp
-	}
+// passband converts real input to complex baseband: the analytic signal
+// shifted down by the carrier
+type passband struct {
+	carrier  float64
+	analytic *hilbert.Transformer
+	mixer    *mixer.Mixer
+}
 
-	for i := range output {
-		output[i] *= gain
+func (p *passband) process(samples []float64) []complex128 {
+	if p.analytic == nil {
+		p.analytic, _ = hilbert.NewTransformerTaps(hilbertTaps)
+		p.mixer, _ = mixer.NewMixer(-p.carrier, 1)
 	}
+	return p.mixer.Process(p.analytic.Process(samples))
+}
 
-	metrics := GainMetrics{
-		CurrentGain:   gain,
-		CompressionDB: 20 * log10(gain),
-		GainReduction: 1 / gain,
+func (p *passband) reset() {
+	if p.analytic != nil {
+		p.analytic.Reset()
+		p.mixer.Reset()
 	}
-
-	return output, metrics
 }
 
 func log10(x float64) float64 {
@@ -204,13 +218,13 @@ func log10(x float64) float64 {
 func NewDemodulator(config DemodulatorConfig) Demodulator {
 	switch config.Type {
 	case AM:
-		return &AMDemod{}
+		return &AMDemod{gain: newGainStage(config)}
 	case FM:
-		return &FMDemod{}
+		return newFMDemod(config)
 	case USB:
-		return &USBDemod{}
+		return &USBDemod{gain: newGainStage(config), passband: passband{carrier: config.Carrier}}
 	case LSB:
-		return &LSBDemod{}
+		return &LSBDemod{gain: newGainStage(config), passband: passband{carrier: config.Carrier}}
 	default:
 		return &AMDemod{}
 	}
@@ -0,0 +1,169 @@
+package demod
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/Vivirinter/sdr-parser/pkg/hilbert"
+)
+
+// De-emphasis time constants of broadcast FM: 50 µs in Europe and most of
+// the world, 75 µs in the Americas and South Korea
+const (
+	Deemphasis50us = 50e-6
+	Deemphasis75us = 75e-6
+)
+
+// FMPreset is the deviation and de-emphasis of a class of FM transmission
+type FMPreset struct {
+	Deviation  float64 // Peak deviation in Hz
+	Deemphasis float64 // De-emphasis time constant in seconds, 0 for none
+}
+
+var (
+	// NBFM is narrowband voice FM (land mobile, amateur, PMR)
+	NBFM = FMPreset{Deviation: 5000}
+	// WBFM is wideband broadcast FM
+	WBFM = FMPreset{Deviation: 75000, Deemphasis: Deemphasis50us}
+)
+
+// ParseFMPreset returns the preset named "nbfm" or "wbfm"; plain "fm" is
+// broadcast FM
+func ParseFMPreset(name string) (FMPreset, error) {
+	switch name {
+	case "nbfm":
+		return NBFM, nil
+	case "wbfm", "fm":
+		return WBFM, nil
+	default:
+		return FMPreset{}, fmt.Errorf("unknown FM preset: %s", name)
+	}
+}
+
+// Apply sets the FM deviation and de-emphasis of config
+func (p FMPreset) Apply(config *DemodulatorConfig) {
+	config.Deviation = p.Deviation
+	config.Deemphasis = p.Deemphasis
+}
+
+// Validate checks the FM, SSB carrier and gain settings of the configuration
+func (c DemodulatorConfig) Validate() error {
+	if c.SampleRate < 0 {
+		return fmt.Errorf("sample rate must not be negative")
+	}
+	if c.Deviation < 0 {
+		return fmt.Errorf("deviation must not be negative")
+	}
+	if c.Deemphasis < 0 {
+		return fmt.Errorf("de-emphasis time constant must not be negative")
+	}
+	if c.Carrier < 0 || c.Carrier >= 0.5 {
+		return fmt.Errorf("carrier must be from 0 to half the sample rate, got %g cycles per sample", c.Carrier)
+	}
+	if c.GainMode == AGC {
+		return c.AGCConfig.validate(c.SampleRate)
+	}
+	return nil
+}
+
+// FMDemod is a polar discriminator: the instantaneous frequency is the
+// phase step between consecutive I/Q samples, atan2 of x[n]·conj(x[n-1]).
+// The output is 1 at the configured deviation and has one sample per input
+// sample; the first sample of a signal has no predecessor and is 0.
+type FMDemod struct {
+	scale    float64 // Output per radian of phase step, 0 for 1/π
+	deemph   deemphasis
+	analytic *hilbert.Transformer // Converts real input to I/Q
+	prevIQ   complex128           // Last I/Q input sample
+}
+
+func newFMDemod(config DemodulatorConfig) *FMDemod {
+	d := &FMDemod{}
+	if config.SampleRate > 0 && config.Deviation > 0 {
+		d.scale = config.SampleRate / (2 * math.Pi * config.Deviation)
+	}
+	if config.SampleRate > 0 && config.Deemphasis > 0 {
+		d.deemph.alpha = 1 - math.Exp(-1/(config.Deemphasis*config.SampleRate))
+	}
+	return d
+}
+
+// Demodulate converts real input to its analytic signal and demodulates
+// it as I/Q
+func (d *FMDemod) Demodulate(samples []float64) ([]float64, GainMetrics) {
+	if d.analytic == nil {
+		d.analytic, _ = hilbert.NewTransformerTaps(hilbertTaps)
+	}
+	return d.DemodulateIQ(d.analytic.Process(samples))
+}
+
+// DemodulateIQ recovers the instantaneous frequency of an FM signal
+func (d *FMDemod) DemodulateIQ(samples []complex128) ([]float64, GainMetrics) {
+	scale := d.scale
+	if scale == 0 {
+		scale = 1 / math.Pi
+	}
+
+	output := make([]float64, len(samples))
+	for i, sample := range samples {
+		output[i] = scale * cmplx.Phase(sample*cmplx.Conj(d.prevIQ))
+		d.prevIQ = sample
+	}
+	d.deemph.process(output)
+	return output, GainMetrics{
+		CurrentGain:   scale,
+		CompressionDB: 20 * log10(scale),
+		GainReduction: 1 / scale,
+	}
+}
+
+func (d *FMDemod) Reset() {
+	d.prevIQ = 0
+	d.deemph.reset()
+	if d.analytic != nil {
+		d.analytic.Reset()
+	}
+}
+
+// Latency returns the delay of real input: the Hilbert transformer's, plus
+// the discriminator's half sample and the de-emphasis delay
+func (d *FMDemod) Latency() float64 {
+	return hilbertTaps/2 + d.IQLatency()
+}
+
+// IQLatency returns half a sample, as each output is aligned with the later
+// sample of the pair it is computed from, plus the de-emphasis delay
+func (d *FMDemod) IQLatency() float64 {
+	return 0.5 + d.deemph.latency()
+}
+
+// deemphasis is the single-pole low-pass filter 1/(1 + sτ) that undoes
+// the transmitter's pre-emphasis of treble
+type deemphasis struct {
+	alpha float64 // Smoothing factor 1 - exp(-1/(τ·fs)), 0 when disabled
+	y     float64 // Last output
+}
+
+// process filters a block in place
+func (f *deemphasis) process(samples []float64) {
+	if f.alpha == 0 {
+		return
+	}
+	for i, v := range samples {
+		f.y += f.alpha * (v - f.y)
+		samples[i] = f.y
+	}
+}
+
+func (f *deemphasis) reset() {
+	f.y = 0
+}
+
+// latency returns the group delay at DC, (1 - α)/α samples
+func (f *deemphasis) latency() float64 {
+	if f.alpha == 0 {
+		return 0
+	}
+	return (1 - f.alpha) / f.alpha
+}
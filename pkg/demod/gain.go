@@ -0,0 +1,129 @@
+package demod
+
+import (
+	"fmt"
+	"math"
+)
+
+// normalizedPeak is the peak magnitude Normalize scales a signal to
+const normalizedPeak = 0.7
+
+// DefaultAGC follows the audio envelope within milliseconds of a rise and
+// lets it fall over half a second
+var DefaultAGC = AGCSettings{
+	AttackTime:  0.005,
+	ReleaseTime: 0.5,
+	Target:      normalizedPeak,
+	MaxGain:     1000,
+	MinGain:     0.001,
+}
+
+// validate checks the AGC settings for a signal sampled at sampleRate
+func (s AGCSettings) validate(sampleRate float64) error {
+	if sampleRate <= 0 {
+		return fmt.Errorf("AGC needs the sample rate")
+	}
+	if s.AttackTime <= 0 || s.ReleaseTime <= 0 {
+		return fmt.Errorf("AGC attack and release times must be positive")
+	}
+	if s.Target <= 0 {
+		return fmt.Errorf("AGC target must be positive")
+	}
+	if s.MinGain <= 0 || s.MaxGain < s.MinGain {
+		return fmt.Errorf("AGC gain limits must satisfy 0 < min <= max")
+	}
+	return nil
+}
+
+// gainStage scales demodulator output by a fixed gain or, in AGC mode, by
+// the gain that brings the output envelope to the target. Both depend only
+// on past samples, so the output does not depend on the block boundaries.
+// The zero value has unity gain.
+type gainStage struct {
+	agc      bool
+	gain     float64 // Fixed gain, or the current AGC gain
+	settings AGCSettings
+	attack   float64 // Envelope smoothing per sample while it rises
+	release  float64 // Envelope smoothing per sample while it falls
+	envelope float64
+}
+
+func newGainStage(config DemodulatorConfig) gainStage {
+	if config.GainMode != AGC {
+		return gainStage{gain: config.ManualGain}
+	}
+	s := config.AGCConfig
+	return gainStage{
+		agc:      true,
+		settings: s,
+		attack:   1 - math.Exp(-1/(config.SampleRate*s.AttackTime)),
+		release:  1 - math.Exp(-1/(config.SampleRate*s.ReleaseTime)),
+	}
+}
+
+func (g *gainStage) apply(output []float64) GainMetrics {
+	if !g.agc {
+		gain := g.gain
+		if gain == 0 {
+			gain = 1
+		}
+		for i := range output {
+			output[i] *= gain
+		}
+		return gainMetrics(gain)
+	}
+
+	for i, v := range output {
+		magnitude := math.Abs(v)
+		if magnitude > g.envelope {
+			g.envelope += g.attack * (magnitude - g.envelope)
+		} else {
+			g.envelope += g.release * (magnitude - g.envelope)
+		}
+		g.gain = g.settings.MaxGain
+		if g.envelope > 0 {
+			g.gain = math.Max(g.settings.MinGain, math.Min(g.settings.MaxGain, g.settings.Target/g.envelope))
+		}
+		output[i] = v * g.gain
+	}
+	return gainMetrics(g.gain)
+}
+
+func (g *gainStage) reset() {
+	if g.agc {
+		g.gain = 0
+		g.envelope = 0
+	}
+}
+
+// Normalize scales a whole demodulated signal in place so its peak
+// magnitude is 0.7. It needs the complete output, so streams use a fixed
+// gain or AGC instead.
+func Normalize(output []float64) GainMetrics {
+	peak := 0.0
+	for _, v := range output {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	gain := NormalizeGain(peak)
+	for i := range output {
+		output[i] *= gain
+	}
+	return gainMetrics(gain)
+}
+
+// NormalizeGain returns the gain Normalize applies to output whose peak
+// magnitude is peak, for a fixed gain that normalizes a stream
+func NormalizeGain(peak float64) float64 {
+	if peak > 0 {
+		return normalizedPeak / peak
+	}
+	return 1
+}
+
+func gainMetrics(gain float64) GainMetrics {
+	return GainMetrics{
+		CurrentGain:   gain,
+		CompressionDB: 20 * log10(gain),
+		GainReduction: 1 / gain,
+	}
+}
@@ -0,0 +1,104 @@
+package demod
+
+import (
+	"math/cmplx"
+
+	"github.com/Vivirinter/sdr-parser/pkg/hilbert"
+)
+
+// hilbertTaps is the length of the FIR Hilbert transformer used for
+// sideband selection (must be odd)
+const hilbertTaps = 65
+
+// IQDemodulator demodulates complex baseband (I/Q) samples. Like
+// Demodulator it is streaming; a demodulator instance carries the state of
+// one stream, so a signal is fed either as real or as I/Q blocks.
+type IQDemodulator interface {
+	DemodulateIQ(samples []complex128) ([]float64, GainMetrics)
+
+	// IQLatency returns the delay of DemodulateIQ output in samples
+	IQLatency() float64
+}
+
+// DemodulateIQ recovers the envelope |I + jQ| of an AM signal
+func (d *AMDemod) DemodulateIQ(samples []complex128) ([]float64, GainMetrics) {
+	output := make([]float64, len(samples))
+	for i, sample := range samples {
+		output[i] = cmplx.Abs(sample)
+	}
+	return output, d.gain.apply(output)
+}
+
+func (d *AMDemod) IQLatency() float64 {
+	return 0
+}
+
+// DemodulateIQ recovers the upper sideband using the phasing method: I - H{Q}
+func (d *USBDemod) DemodulateIQ(samples []complex128) ([]float64, GainMetrics) {
+	output := d.sideband.process(samples, -1)
+	return output, d.gain.apply(output)
+}
+
+func (d *USBDemod) IQLatency() float64 {
+	return hilbertTaps / 2
+}
+
+// DemodulateIQ recovers the lower sideband using the phasing method: I + H{Q}
+func (d *LSBDemod) DemodulateIQ(samples []complex128) ([]float64, GainMetrics) {
+	output := d.sideband.process(samples, 1)
+	return output, d.gain.apply(output)
+}
+
+func (d *LSBDemod) IQLatency() float64 {
+	return hilbertTaps / 2
+}
+
+// sidebandDemod combines the in-phase component with the Hilbert transform
+// of the quadrature component. The causal FIR transformer delays its output
+// by half its length, so the in-phase component is delayed to match.
+type sidebandDemod struct {
+	taps []float64
+	q    []float64 // Last hilbertTaps-1 quadrature samples
+	i    []float64 // Last hilbertTaps/2 in-phase samples
+}
+
+// process demodulates one block; sign selects the sideband
+func (s *sidebandDemod) process(samples []complex128, sign float64) []float64 {
+	if s.taps == nil {
+		s.taps, _ = hilbert.Taps(hilbertTaps)
+		s.reset()
+	}
+
+	// Prepend the tails of the previous block so the FIR spans block boundaries
+	q := s.q[:len(s.q):len(s.q)]
+	in := s.i[:len(s.i):len(s.i)]
+	for _, sample := range samples {
+		q = append(q, imag(sample))
+		in = append(in, real(sample))
+	}
+
+	output := make([]float64, len(samples))
+	last := len(s.taps) - 1
+	for k := range output {
+		var hq float64
+		for n, tap := range s.taps {
+			hq += tap * q[k+last-n]
+		}
+		output[k] = in[k] + sign*hq
+	}
+
+	copy(s.q, q[len(q)-len(s.q):])
+	copy(s.i, in[len(in)-len(s.i):])
+	return output
+}
+
+// reset clears the sample history
+func (s *sidebandDemod) reset() {
+	s.q = make([]float64, hilbertTaps-1)
+	s.i = make([]float64, hilbertTaps/2)
+}
+
+// NewIQDemodulator creates an I/Q demodulator for the given configuration
+func NewIQDemodulator(config DemodulatorConfig) IQDemodulator {
+	return NewDemodulator(config).(IQDemodulator)
+}
@@ -0,0 +1,217 @@
+package demod
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+// Broadcast FM stereo multiplex (MPX): L+R audio up to 15 kHz, a 19 kHz
+// pilot and L-R on a suppressed 38 kHz subcarrier locked to twice the pilot
+const (
+	PilotFreq      = 19000.0
+	SubcarrierFreq = 2 * PilotFreq
+	stereoAudio    = 15000.0 // Bandwidth of L+R and L-R
+	stereoStop     = 18500.0 // Start of the audio low-pass stopband, below the pilot
+
+	// MinStereoRate is the lowest MPX sample rate holding the L-R band
+	MinStereoRate = 2 * (SubcarrierFreq + stereoAudio)
+)
+
+// Pilot detection: the pilot amplitude relative to the MPX RMS level turns
+// stereo on above PilotOnLevel and back to mono below PilotOffLevel
+const (
+	PilotOnLevel  = 0.06
+	PilotOffLevel = 0.04
+)
+
+// Pilot loop settings in Hz: the PLL bandwidth, the bandwidth of its phase
+// detector, the averaging bandwidth of the pilot level and the furthest the
+// loop may pull from 19 kHz
+const (
+	pilotLoopBandwidth = 10.0
+	pilotDetector      = 500.0
+	pilotLevelSmooth   = 2.0
+	pilotPullRange     = 50.0
+)
+
+// StereoDecoder splits the MPX output of an FM discriminator (without
+// de-emphasis) into left and right audio. A PLL locks to the pilot and
+// regenerates the 38 kHz subcarrier that demodulates L-R; L and R are the
+// sum and difference of L+R and L-R. While the pilot is weak or missing
+// both channels carry L+R. StereoDecoder is streaming.
+type StereoDecoder struct {
+	pll    pilotPLL
+	sum    *filter.FIR // L+R low-pass
+	diff   *filter.FIR // L-R low-pass after the subcarrier mixer
+	left   deemphasis
+	right  deemphasis
+	stereo bool
+	// Pilot state of the last samples still inside the audio filters, so
+	// the mono/stereo switch lands on the audio it was detected in
+	pending []bool
+}
+
+// NewStereoDecoder creates a decoder for MPX sampled at sampleRate, which
+// must be at least MinStereoRate, de-emphasizing both channels with the
+// time constant deemphasis in seconds (0 for none)
+func NewStereoDecoder(sampleRate, deemphasis float64) (*StereoDecoder, error) {
+	if sampleRate < MinStereoRate {
+		return nil, fmt.Errorf("stereo decoding needs an MPX sample rate of at least %g Hz, got %g Hz", MinStereoRate, sampleRate)
+	}
+	if deemphasis < 0 {
+		return nil, fmt.Errorf("de-emphasis time constant must not be negative")
+	}
+
+	bands := []filter.Band{
+		{Low: 0, High: stereoAudio, Gain: 1},
+		{Low: stereoStop, High: sampleRate / 2},
+	}
+	taps, err := filter.DesignKaiser(0, bands, sampleRate, 60)
+	if err != nil {
+		return nil, fmt.Errorf("failed to design audio filter: %w", err)
+	}
+	d := &StereoDecoder{pll: newPilotPLL(sampleRate)}
+	if d.sum, err = filter.NewFIR(taps); err != nil {
+		return nil, err
+	}
+	if d.diff, err = filter.NewFIR(taps); err != nil {
+		return nil, err
+	}
+	d.pending = make([]bool, int(math.Round(d.sum.Latency())))
+	if deemphasis > 0 {
+		d.left.alpha = 1 - math.Exp(-1/(deemphasis*sampleRate))
+		d.right.alpha = d.left.alpha
+	}
+	return d, nil
+}
+
+// Process decodes a block of MPX samples into left and right audio
+func (d *StereoDecoder) Process(mpx []float64) (left, right []float64) {
+	// Mix L-R down with the subcarrier, 2·sin(2θ) for the pilot sin(θ),
+	// and note per sample whether the pilot is strong enough. The notes
+	// are delayed like the filtered audio.
+	mixed := make([]float64, len(mpx))
+	delay := len(d.pending)
+	stereo := make([]bool, delay+len(mpx))
+	copy(stereo, d.pending)
+	for i, x := range mpx {
+		theta := d.pll.next(x)
+		d.stereo = d.pll.detect(d.stereo)
+		mixed[i] = 2 * x * math.Sin(2*theta)
+		stereo[delay+i] = d.stereo
+	}
+	copy(d.pending, stereo[len(mpx):])
+
+	sum := d.sum.Process(mpx)
+	diff := d.diff.Process(mixed)
+	left = make([]float64, len(mpx))
+	right = make([]float64, len(mpx))
+	for i := range sum {
+		if !stereo[i] {
+			diff[i] = 0
+		}
+		left[i] = sum[i] + diff[i]
+		right[i] = sum[i] - diff[i]
+	}
+	d.left.process(left)
+	d.right.process(right)
+	return left, right
+}
+
+// Stereo reports whether the pilot was detected at the last sample
+func (d *StereoDecoder) Stereo() bool {
+	return d.stereo
+}
+
+// PilotLevel returns the pilot amplitude relative to the MPX RMS level
+func (d *StereoDecoder) PilotLevel() float64 {
+	return d.pll.relativeLevel()
+}
+
+// Reset clears the filters and restarts pilot acquisition
+func (d *StereoDecoder) Reset() {
+	d.pll.reset()
+	d.sum.Reset()
+	d.diff.Reset()
+	d.left.reset()
+	d.right.reset()
+	d.stereo = false
+	clear(d.pending)
+}
+
+// Latency returns the delay of the audio low-pass filters and the
+// de-emphasis in samples
+func (d *StereoDecoder) Latency() float64 {
+	return d.sum.Latency() + d.left.latency()
+}
+
+// pilotPLL is a second-order phase-locked loop tracking the 19 kHz pilot.
+// The phase detector low-passes the MPX mixed with the loop's sine and
+// cosine and takes the angle between them, so the loop gain does not
+// depend on the pilot level.
+type pilotPLL struct {
+	nominal  float64 // Pilot frequency in radians per sample
+	pull     float64 // Largest frequency correction in radians per sample
+	kp, ki   float64 // Proportional and integral loop gains
+	detector float64 // Smoothing factor of the phase detector
+	smooth   float64 // Smoothing factor of the level estimates
+
+	phase, freq float64 // Oscillator phase and frequency correction
+	i, q        float64 // Phase detector outputs
+	level       float64 // In-phase pilot amplitude
+	power       float64 // Mean MPX power
+}
+
+func newPilotPLL(sampleRate float64) pilotPLL {
+	// Natural frequency for a damping factor of 1/√2, from the loop noise
+	// bandwidth Bn = ωn(ζ + 1/4ζ)/2
+	const zeta = math.Sqrt2 / 2
+	wn := 2 * pilotLoopBandwidth / (zeta + 1/(4*zeta)) / sampleRate
+	return pilotPLL{
+		nominal:  2 * math.Pi * PilotFreq / sampleRate,
+		pull:     2 * math.Pi * pilotPullRange / sampleRate,
+		kp:       2 * zeta * wn,
+		ki:       wn * wn,
+		detector: 1 - math.Exp(-2*math.Pi*pilotDetector/sampleRate),
+		smooth:   1 - math.Exp(-2*math.Pi*pilotLevelSmooth/sampleRate),
+	}
+}
+
+// next returns the pilot phase θ at sample x, with the pilot sin(θ), and
+// advances the loop
+func (p *pilotPLL) next(x float64) float64 {
+	theta := p.phase
+	sin, cos := math.Sincos(theta)
+	p.i += p.detector * (x*sin - p.i)
+	p.q += p.detector * (x*cos - p.q)
+	p.level += p.smooth * (2*x*sin - p.level)
+	p.power += p.smooth * (x*x - p.power)
+
+	err := math.Atan2(p.q, p.i)
+	p.freq = math.Max(-p.pull, math.Min(p.pull, p.freq+p.ki*err))
+	p.phase = math.Mod(p.phase+p.nominal+p.freq+p.kp*err, 2*math.Pi)
+	return theta
+}
+
+// detect applies the pilot thresholds with hysteresis to the current state
+func (p *pilotPLL) detect(stereo bool) bool {
+	level := p.relativeLevel()
+	if stereo {
+		return level >= PilotOffLevel
+	}
+	return level >= PilotOnLevel
+}
+
+func (p *pilotPLL) relativeLevel() float64 {
+	if p.power <= 0 {
+		return 0
+	}
+	return p.level / math.Sqrt(p.power)
+}
+
+func (p *pilotPLL) reset() {
+	p.phase, p.freq = 0, 0
+	p.i, p.q, p.level, p.power = 0, 0, 0, 0
+}
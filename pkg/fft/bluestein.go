@@ -0,0 +1,64 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// bluestein computes a DFT of any length as a circular convolution with a
+// chirp, evaluated with power-of-two transforms
+type bluestein struct {
+	n      int
+	chirp  []complex128 // exp(-πik²/n)
+	filter []complex128 // DFT of the conjugate chirp, zero-padded to the plan length
+	plan   *Plan        // Power-of-two plan for the convolution
+}
+
+func newBluestein(n int) (*bluestein, error) {
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	plan, err := NewPlan(m)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bluestein{
+		n:      n,
+		chirp:  make([]complex128, n),
+		filter: make([]complex128, m),
+		plan:   plan,
+	}
+	for k := range b.chirp {
+		// k² mod 2n keeps the phase argument small for large k
+		phase := math.Pi * float64((k*k)%(2*n)) / float64(n)
+		b.chirp[k] = cmplx.Rect(1, -phase)
+	}
+
+	b.filter[0] = cmplx.Conj(b.chirp[0])
+	for k := 1; k < n; k++ {
+		b.filter[k] = cmplx.Conj(b.chirp[k])
+		b.filter[m-k] = b.filter[k]
+	}
+	plan.forward(b.filter, b.filter)
+	return b, nil
+}
+
+// transform computes the DFT of src into dst, which may alias
+func (b *bluestein) transform(dst, src []complex128) {
+	work := make([]complex128, b.plan.n)
+	for k, v := range src {
+		work[k] = v * b.chirp[k]
+	}
+
+	b.plan.forward(work, work)
+	for k := range work {
+		work[k] *= b.filter[k]
+	}
+	b.plan.Inverse(work, work)
+
+	for k := range dst {
+		dst[k] = work[k] * b.chirp[k]
+	}
+}
@@ -0,0 +1,282 @@
+// Package fft implements fast Fourier transforms of complex and real
+// signals of any length, without cgo.
+//
+// Lengths are factored into radices 4, 2, 3 and 5 plus any remaining small
+// primes, which are transformed with mixed-radix Cooley-Tukey decimation in
+// time. Lengths with a prime factor above maxRadix use Bluestein's chirp-z
+// algorithm, so every length runs in O(n log n).
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// maxRadix is the largest prime factor transformed directly; lengths with
+// larger prime factors are computed with Bluestein's algorithm
+const maxRadix = 64
+
+// Plan holds the factorization and twiddle factors for transforms of one
+// length. A plan is safe for concurrent use.
+type Plan struct {
+	n         int
+	factors   []int        // Radices, applied from the outermost stage inwards
+	twiddles  []complex128 // exp(-2πik/n) for k in [0, n)
+	bluestein *bluestein   // Set when n has a prime factor above maxRadix
+}
+
+// NewPlan creates a plan for complex transforms of length n
+func NewPlan(n int) (*Plan, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("transform length must be positive, got %d", n)
+	}
+
+	p := &Plan{n: n, factors: factorize(n)}
+	if p.factors[len(p.factors)-1] > maxRadix {
+		b, err := newBluestein(n)
+		if err != nil {
+			return nil, err
+		}
+		p.bluestein = b
+		return p, nil
+	}
+
+	p.twiddles = make([]complex128, n)
+	for k := range p.twiddles {
+		p.twiddles[k] = cmplx.Rect(1, -2*math.Pi*float64(k)/float64(n))
+	}
+	return p, nil
+}
+
+// Len returns the transform length
+func (p *Plan) Len() int {
+	return p.n
+}
+
+// Forward computes the DFT X[k] = sum x[j] exp(-2πijk/n) of src into dst.
+// Both slices must have the plan's length; dst may alias src.
+func (p *Plan) Forward(dst, src []complex128) error {
+	if err := p.checkLen(dst, src); err != nil {
+		return err
+	}
+	p.forward(dst, src)
+	return nil
+}
+
+// Inverse computes the inverse DFT, scaled by 1/n so that
+// Inverse(Forward(x)) == x. Both slices must have the plan's length;
+// dst may alias src.
+func (p *Plan) Inverse(dst, src []complex128) error {
+	if err := p.checkLen(dst, src); err != nil {
+		return err
+	}
+
+	// IDFT(x) = conj(DFT(conj(x))) / n
+	work := make([]complex128, p.n)
+	for i, v := range src {
+		work[i] = cmplx.Conj(v)
+	}
+	p.forward(work, work)
+
+	scale := 1 / float64(p.n)
+	for i, v := range work {
+		dst[i] = complex(real(v)*scale, -imag(v)*scale)
+	}
+	return nil
+}
+
+func (p *Plan) checkLen(dst, src []complex128) error {
+	if len(src) != p.n || len(dst) != p.n {
+		return fmt.Errorf("plan length is %d, got src %d and dst %d", p.n, len(src), len(dst))
+	}
+	return nil
+}
+
+// forward computes the DFT of src into dst, which may alias
+func (p *Plan) forward(dst, src []complex128) {
+	if p.bluestein != nil {
+		p.bluestein.transform(dst, src)
+		return
+	}
+
+	// The recursion reads src with strides, so it needs a separate output
+	out := dst
+	if &dst[0] == &src[0] {
+		out = make([]complex128, p.n)
+	}
+	p.transform(out, src, p.n, 1, p.factors)
+	if &out[0] != &dst[0] {
+		copy(dst, out)
+	}
+}
+
+// transform computes the n-point DFT of src[0], src[stride], ... into dst
+// by splitting it into factors[0] interleaved sub-transforms
+func (p *Plan) transform(dst, src []complex128, n, stride int, factors []int) {
+	if n == 1 {
+		dst[0] = src[0]
+		return
+	}
+
+	radix := factors[0]
+	m := n / radix
+	for q := 0; q < radix; q++ {
+		p.transform(dst[q*m:(q+1)*m], src[q*stride:], m, stride*radix, factors[1:])
+	}
+
+	// Twiddle index step of this stage: W_n^k = W_N^(k*N/n)
+	step := p.n / n
+	switch radix {
+	case 2:
+		p.butterfly2(dst, m, step)
+	case 4:
+		p.butterfly4(dst, m, step)
+	default:
+		p.butterfly(dst, m, step, radix)
+	}
+}
+
+// butterfly2 combines two sub-transforms of length m
+func (p *Plan) butterfly2(dst []complex128, m, step int) {
+	for k := 0; k < m; k++ {
+		a := dst[k]
+		b := dst[k+m] * p.twiddles[k*step]
+		dst[k] = a + b
+		dst[k+m] = a - b
+	}
+}
+
+// butterfly4 combines four sub-transforms of length m
+func (p *Plan) butterfly4(dst []complex128, m, step int) {
+	for k := 0; k < m; k++ {
+		a0 := dst[k]
+		a1 := dst[k+m] * p.twiddles[k*step]
+		a2 := dst[k+2*m] * p.twiddles[2*k*step]
+		a3 := dst[k+3*m] * p.twiddles[3*k*step]
+
+		t0, t1 := a0+a2, a0-a2
+		t2, t3 := a1+a3, a1-a3
+		// Multiply by -i
+		t3 = complex(imag(t3), -real(t3))
+
+		dst[k] = t0 + t2
+		dst[k+m] = t1 + t3
+		dst[k+2*m] = t0 - t2
+		dst[k+3*m] = t1 - t3
+	}
+}
+
+// butterfly combines radix sub-transforms of length m with a direct
+// radix-point DFT, used for radix 3, 5 and other small primes
+func (p *Plan) butterfly(dst []complex128, m, step, radix int) {
+	n := m * radix
+	work := make([]complex128, radix)
+	for k := 0; k < m; k++ {
+		for q := range work {
+			work[q] = dst[k+q*m] * p.twiddles[(q*k*step)%p.n]
+		}
+		for s := 0; s < radix; s++ {
+			var sum complex128
+			for q, v := range work {
+				// W_radix^(q*s) = W_N^(q*s*N/radix)
+				sum += v * p.twiddles[(q*s%radix)*(n/radix)*step]
+			}
+			dst[k+s*m] = sum
+		}
+	}
+}
+
+// factorize splits n into radices: 4s first, then 2, 3, 5 and the remaining
+// primes in increasing order
+func factorize(n int) []int {
+	if n == 1 {
+		return []int{1}
+	}
+
+	var factors []int
+	for n%4 == 0 {
+		factors = append(factors, 4)
+		n /= 4
+	}
+	for _, radix := range []int{2, 3, 5} {
+		for n%radix == 0 {
+			factors = append(factors, radix)
+			n /= radix
+		}
+	}
+	for radix := 7; radix*radix <= n; radix += 2 {
+		for n%radix == 0 {
+			factors = append(factors, radix)
+			n /= radix
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// plans caches plans for the convenience functions
+var plans sync.Map
+
+// planFor returns a cached plan for length n
+func planFor(n int) (*Plan, error) {
+	if plan, ok := plans.Load(n); ok {
+		return plan.(*Plan), nil
+	}
+	plan, err := NewPlan(n)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := plans.LoadOrStore(n, plan)
+	return actual.(*Plan), nil
+}
+
+// FFT returns the DFT of x, using a cached plan for its length
+func FFT(x []complex128) []complex128 {
+	if len(x) == 0 {
+		return []complex128{}
+	}
+	plan, _ := planFor(len(x))
+	out := make([]complex128, len(x))
+	plan.forward(out, x)
+	return out
+}
+
+// IFFT returns the inverse DFT of x scaled by 1/len(x), using a cached plan
+func IFFT(x []complex128) []complex128 {
+	if len(x) == 0 {
+		return []complex128{}
+	}
+	plan, _ := planFor(len(x))
+	out := make([]complex128, len(x))
+	plan.Inverse(out, x)
+	return out
+}
+
+// Frequencies returns the frequency in Hz of each of the n bins of a DFT,
+// in FFT order: 0, positive frequencies, then negative frequencies
+func Frequencies(n int, sampleRate float64) []float64 {
+	freqs := make([]float64, n)
+	for k := range freqs {
+		bin := k
+		if k > (n-1)/2 {
+			bin = k - n
+		}
+		freqs[k] = float64(bin) * sampleRate / float64(n)
+	}
+	return freqs
+}
+
+// Shift reorders a spectrum in FFT order so the zero-frequency bin is in
+// the middle, from the most negative to the most positive frequency
+func Shift[T any](x []T) []T {
+	n := len(x)
+	out := make([]T, n)
+	half := (n + 1) / 2
+	copy(out, x[half:])
+	copy(out[n-half:], x[:half])
+	return out
+}
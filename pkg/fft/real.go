@@ -0,0 +1,170 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// RealPlan computes transforms of real signals of one length, returning
+// the n/2+1 non-negative frequency bins. Even lengths are transformed as a
+// complex signal of half the length. A plan is safe for concurrent use.
+type RealPlan struct {
+	n        int
+	plan     *Plan        // Half-length plan for even n, full-length otherwise
+	twiddles []complex128 // exp(-2πik/n) for k in [0, n/2], even n only
+}
+
+// NewRealPlan creates a plan for real transforms of length n
+func NewRealPlan(n int) (*RealPlan, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("transform length must be positive, got %d", n)
+	}
+
+	p := &RealPlan{n: n}
+	size := n
+	if n%2 == 0 {
+		size = n / 2
+		p.twiddles = make([]complex128, n/2+1)
+		for k := range p.twiddles {
+			p.twiddles[k] = cmplx.Rect(1, -2*math.Pi*float64(k)/float64(n))
+		}
+	}
+
+	plan, err := NewPlan(size)
+	if err != nil {
+		return nil, err
+	}
+	p.plan = plan
+	return p, nil
+}
+
+// Len returns the length of the real signal
+func (p *RealPlan) Len() int {
+	return p.n
+}
+
+// Bins returns the number of frequency bins, n/2+1
+func (p *RealPlan) Bins() int {
+	return p.n/2 + 1
+}
+
+// Forward computes the non-negative frequency bins of the DFT of src, which
+// must have the plan's length, into dst, which must have Bins() elements
+func (p *RealPlan) Forward(dst []complex128, src []float64) error {
+	if len(src) != p.n || len(dst) != p.Bins() {
+		return fmt.Errorf("real plan length is %d with %d bins, got src %d and dst %d",
+			p.n, p.Bins(), len(src), len(dst))
+	}
+
+	if p.n%2 == 1 {
+		work := make([]complex128, p.n)
+		for i, v := range src {
+			work[i] = complex(v, 0)
+		}
+		p.plan.forward(work, work)
+		copy(dst, work)
+		return nil
+	}
+
+	// Pack even and odd samples as z[k] = x[2k] + i*x[2k+1]
+	h := p.n / 2
+	z := make([]complex128, h)
+	for k := range z {
+		z[k] = complex(src[2*k], src[2*k+1])
+	}
+	p.plan.forward(z, z)
+
+	// Split Z into the transforms of the even and odd samples and combine:
+	// X[k] = E[k] + W^k O[k]
+	for k := 0; k <= h; k++ {
+		zk, zr := z[k%h], cmplx.Conj(z[(h-k)%h])
+		even := (zk + zr) / 2
+		odd := (zk - zr) / 2i
+		dst[k] = even + p.twiddles[k]*odd
+	}
+	return nil
+}
+
+// Inverse reconstructs the real signal of the plan's length from its
+// Bins() non-negative frequency bins, scaled by 1/n
+func (p *RealPlan) Inverse(dst []float64, src []complex128) error {
+	if len(dst) != p.n || len(src) != p.Bins() {
+		return fmt.Errorf("real plan length is %d with %d bins, got src %d and dst %d",
+			p.n, p.Bins(), len(src), len(dst))
+	}
+
+	if p.n%2 == 1 {
+		// Rebuild the negative frequencies from conjugate symmetry
+		work := make([]complex128, p.n)
+		copy(work, src)
+		for k := 1; k < p.Bins(); k++ {
+			work[p.n-k] = cmplx.Conj(src[k])
+		}
+		p.plan.Inverse(work, work)
+		for i, v := range work {
+			dst[i] = real(v)
+		}
+		return nil
+	}
+
+	// Invert X[k] = E[k] + W^k O[k] and pack Z[k] = E[k] + i*O[k]
+	h := p.n / 2
+	z := make([]complex128, h)
+	for k := range z {
+		xk, xr := src[k], cmplx.Conj(src[h-k])
+		even := (xk + xr) / 2
+		odd := (xk - xr) / (2 * p.twiddles[k])
+		z[k] = even + 1i*odd
+	}
+	p.plan.Inverse(z, z)
+
+	for k, v := range z {
+		dst[2*k] = real(v)
+		dst[2*k+1] = imag(v)
+	}
+	return nil
+}
+
+// realPlans caches real plans for the convenience functions
+var realPlans sync.Map
+
+// realPlanFor returns a cached real plan for length n
+func realPlanFor(n int) (*RealPlan, error) {
+	if plan, ok := realPlans.Load(n); ok {
+		return plan.(*RealPlan), nil
+	}
+	plan, err := NewRealPlan(n)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := realPlans.LoadOrStore(n, plan)
+	return actual.(*RealPlan), nil
+}
+
+// RFFT returns the len(x)/2+1 non-negative frequency bins of the DFT of a
+// real signal, using a cached plan for its length
+func RFFT(x []float64) []complex128 {
+	if len(x) == 0 {
+		return []complex128{}
+	}
+	plan, _ := realPlanFor(len(x))
+	out := make([]complex128, plan.Bins())
+	plan.Forward(out, x)
+	return out
+}
+
+// IRFFT reconstructs a real signal of length n from its n/2+1 non-negative
+// frequency bins, using a cached plan for the length
+func IRFFT(x []complex128, n int) ([]float64, error) {
+	plan, err := realPlanFor(n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, n)
+	if err := plan.Inverse(out, x); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChebyshevIFilter implements a Chebyshev type I filter. It trades an
+// equiripple passband, with ripple set by FilterConfig.Ripple, for a
+// steeper roll-off than a Butterworth filter of the same order. The cutoff
+// and band edges are where the response leaves the ripple band.
+type ChebyshevIFilter struct {
+	iirFilter
+}
+
+// NewChebyshevIFilter creates a new Chebyshev type I filter instance
+func NewChebyshevIFilter() *ChebyshevIFilter {
+	return &ChebyshevIFilter{}
+}
+
+// Configure sets up the filter with the provided configuration
+func (f *ChebyshevIFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	proto, gain := chebyshevIPrototype(config.Order, config.Ripple)
+	return f.design(config, proto, gain)
+}
+
+// chebyshevIPrototype returns the analog low-pass prototype with the given
+// passband ripple in dB and its gain at DC: the poles of a Butterworth
+// prototype squeezed onto an ellipse
+func chebyshevIPrototype(order int, ripple float64) (zpk, float64) {
+	eps := math.Sqrt(math.Pow(10, ripple/10) - 1)
+	mu := math.Asinh(1/eps) / float64(order)
+
+	var proto zpk
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+1) / float64(2*order)
+		proto.poles = append(proto.poles, complex(-math.Sinh(mu)*math.Sin(theta), math.Cosh(mu)*math.Cos(theta)))
+	}
+
+	// Even orders start at the bottom of the ripple band
+	gain := 1.0
+	if order%2 == 0 {
+		gain = 1 / math.Sqrt(1+eps*eps)
+	}
+	return proto, gain
+}
+
+// ChebyshevIIFilter implements a Chebyshev type II (inverse Chebyshev)
+// filter. Its passband is maximally flat and its stopband equiripple, at
+// least FilterConfig.Attenuation below the passband. The cutoff and band
+// edges are where the stopband begins.
+type ChebyshevIIFilter struct {
+	iirFilter
+}
+
+// NewChebyshevIIFilter creates a new Chebyshev type II filter instance
+func NewChebyshevIIFilter() *ChebyshevIIFilter {
+	return &ChebyshevIIFilter{}
+}
+
+// Configure sets up the filter with the provided configuration
+func (f *ChebyshevIIFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return f.design(config, chebyshevIIPrototype(config.Order, config.Attenuation), 1)
+}
+
+// chebyshevIIPrototype returns the analog low-pass prototype whose
+// stopband, from 1 rad/s, is attenuated by the given amount in dB. Its
+// poles are the inverted poles of a Chebyshev I prototype and its zeros lie
+// on the imaginary axis.
+func chebyshevIIPrototype(order int, attenuation float64) zpk {
+	eps := 1 / math.Sqrt(math.Pow(10, attenuation/10)-1)
+	mu := math.Asinh(1/eps) / float64(order)
+
+	var proto zpk
+	for k := 0; k < order; k++ {
+		theta := math.Pi * float64(2*k+1) / float64(2*order)
+		proto.poles = append(proto.poles, 1/complex(-math.Sinh(mu)*math.Sin(theta), math.Cosh(mu)*math.Cos(theta)))
+
+		// Odd orders have one zero at infinity instead of at ±j∞
+		if c := math.Cos(theta); math.Abs(c) > 1e-12 {
+			proto.zeros = append(proto.zeros, complex(0, 1/c))
+		}
+	}
+	return proto
+}
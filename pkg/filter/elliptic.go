@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// EllipticFilter implements an elliptic (Cauer) filter, with an
+// equiripple passband (FilterConfig.Ripple) and an equiripple stopband at
+// least FilterConfig.Attenuation below it. It has the steepest transition
+// of the IIR designs for a given order. The cutoff and band edges are where
+// the response leaves the ripple band.
+type EllipticFilter struct {
+	iirFilter
+}
+
+// NewEllipticFilter creates a new elliptic filter instance
+func NewEllipticFilter() *EllipticFilter {
+	return &EllipticFilter{}
+}
+
+// Configure sets up the filter with the provided configuration
+func (f *EllipticFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	proto, gain, err := ellipticPrototype(config.Order, config.Ripple, config.Attenuation)
+	if err != nil {
+		return fmt.Errorf("failed to calculate coefficients: %w", err)
+	}
+	return f.design(config, proto, gain)
+}
+
+// ellipticPrototype returns the analog low-pass prototype with the given
+// passband ripple and stopband attenuation in dB, and its gain at DC. The
+// passband ends at 1 rad/s; the stopband starts where the order allows.
+func ellipticPrototype(order int, ripple, attenuation float64) (zpk, float64, error) {
+	eps := math.Sqrt(math.Pow(10, ripple/10) - 1)
+	if order == 1 {
+		return zpk{poles: []complex128{complex(-1/eps, 0)}}, 1, nil
+	}
+
+	// Discrimination factor k1 and the selectivity m = k² it allows
+	k1 := eps / math.Sqrt(math.Pow(10, attenuation/10)-1)
+	k1c := math.Sqrt(1 - k1*k1)
+	if k1c == 1 {
+		return zpk{}, 0, fmt.Errorf("ripple and attenuation are too close to design an elliptic filter")
+	}
+	kk1, kk1c := ellipticK(k1*k1), ellipticK(1-k1*k1)
+	m, mc := selectivity(float64(order) * kk1 / kk1c)
+	capK := ellipticK(m)
+
+	var proto zpk
+	var sn, cn, dn []float64
+	for j := 1 - order%2; j < order; j += 2 {
+		s, c, d := jacobi(float64(j)*capK/float64(order), m)
+		sn, cn, dn = append(sn, s), append(cn, c), append(dn, d)
+		if math.Abs(s) > 1e-15 {
+			z := complex(0, 1/(math.Sqrt(m)*s))
+			proto.zeros = append(proto.zeros, z, cmplx.Conj(z))
+		}
+	}
+
+	v0 := capK * arcJacobiSC(1/eps, k1*k1) / (float64(order) * kk1)
+	sv, cv, dv := jacobi(v0, mc)
+	for i := range sn {
+		den := 1 - (dn[i]*sv)*(dn[i]*sv)
+		p := complex(-cn[i]*dn[i]*sv*cv/den, -sn[i]*dv/den)
+		if math.Abs(imag(p)) < 1e-12 {
+			// The real pole of odd orders
+			proto.poles = append(proto.poles, complex(real(p), 0))
+		} else {
+			proto.poles = append(proto.poles, p, cmplx.Conj(p))
+		}
+	}
+
+	gain := 1.0
+	if order%2 == 0 {
+		gain = 1 / math.Sqrt(1+eps*eps)
+	}
+	return proto, gain, nil
+}
+
+// ellipticK returns the complete elliptic integral of the first kind K(m)
+// with parameter m = k², from the arithmetic-geometric mean
+func ellipticK(m float64) float64 {
+	a, b := 1.0, math.Sqrt(1-m)
+	for math.Abs(a-b) > 1e-15*a {
+		a, b = (a+b)/2, math.Sqrt(a*b)
+	}
+	return math.Pi / (2 * a)
+}
+
+// selectivity returns the parameter m and its complement 1 - m for which
+// K(m)/K(1-m) equals ratio, from the theta functions of the nome
+// q = exp(-π/ratio)
+func selectivity(ratio float64) (float64, float64) {
+	q := math.Exp(-math.Pi / ratio)
+	theta2, theta3, theta4 := 0.0, 1.0, 1.0
+	for n := 0; ; n++ {
+		t2 := math.Pow(q, float64(n*(n+1)))
+		theta2 += t2
+		if n > 0 {
+			t := math.Pow(q, float64(n*n))
+			theta3 += 2 * t
+			theta4 += 2 * t * float64(1-2*(n%2))
+		}
+		if t2 < 1e-17 {
+			break
+		}
+	}
+	theta2 *= 2 * math.Pow(q, 0.25)
+	m := math.Pow(theta2/theta3, 4)
+	mc := math.Pow(theta4/theta3, 4)
+	return m, mc
+}
+
+// jacobi returns the Jacobi elliptic functions sn, cn and dn of u with
+// parameter m in [0, 1), by descending Landen transformations
+func jacobi(u, m float64) (sn, cn, dn float64) {
+	if m < 1e-15 {
+		return math.Sin(u), math.Cos(u), 1
+	}
+
+	var a, c [16]float64
+	a[0], c[0] = 1, math.Sqrt(m)
+	b := math.Sqrt(1 - m)
+	n := 0
+	for n < len(a)-1 && math.Abs(c[n]) > 1e-16 {
+		a[n+1] = (a[n] + b) / 2
+		c[n+1] = (a[n] - b) / 2
+		b = math.Sqrt(a[n] * b)
+		n++
+	}
+
+	phi := math.Exp2(float64(n)) * a[n] * u
+	var prev float64
+	for ; n > 0; n-- {
+		prev = phi
+		phi = (phi + math.Asin(c[n]/a[n]*math.Sin(phi))) / 2
+	}
+	return math.Sin(phi), math.Cos(phi), math.Cos(phi) / math.Cos(prev-phi)
+}
+
+// arcJacobiSC returns the real u with sc(u, m) = w, where sc = sn/cn
+func arcJacobiSC(w, m float64) float64 {
+	return imag(arcJacobiSN(complex(0, w), m))
+}
+
+// arcJacobiSN returns the complex u with sn(u, m) = w, by ascending Landen
+// transformations of the modulus down to zero, where sn becomes sin
+func arcJacobiSN(w complex128, m float64) complex128 {
+	complement := func(x complex128) complex128 {
+		return cmplx.Sqrt((1 - x) * (1 + x))
+	}
+
+	ks := []float64{math.Sqrt(m)}
+	for i := 0; ks[len(ks)-1] != 0 && i < 64; i++ {
+		k := ks[len(ks)-1]
+		kc := math.Sqrt((1 - k) * (1 + k))
+		ks = append(ks, (1-kc)/(1+kc))
+	}
+
+	capK := math.Pi / 2
+	for _, k := range ks[1:] {
+		capK *= 1 + k
+	}
+	for i := 0; i+1 < len(ks); i++ {
+		kn, next := ks[i], ks[i+1]
+		w = 2 * w / (complex(1+next, 0) * (1 + complement(complex(kn, 0)*w)))
+	}
+	return complex(capK, 0) * 2 / math.Pi * cmplx.Asin(w)
+}
@@ -6,13 +6,11 @@ type ConfigValidator interface {
     Validate(config FilterConfig) error
 }
 
+// DefaultConfigValidator checks the parameters each filter type requires
 type DefaultConfigValidator struct{}
 
 func (v *DefaultConfigValidator) Validate(config FilterConfig) error {
-    if config.WindowSize <= 0 {
-        return fmt.Errorf("window size must be positive")
-    }
-    return nil
+    return config.Validate()
 }
 
 // FilterFactory creates and configures filters based on type and configuration
@@ -28,6 +26,13 @@ func NewFilterFactory() *FilterFactory {
             MovingAverage: func() Filter { return NewMovingAverageFilter() },
             Median:        func() Filter { return NewMedianFilter() },
             Butterworth:   func() Filter { return NewButterworthFilter() },
+            ChebyshevI:    func() Filter { return NewChebyshevIFilter() },
+            ChebyshevII:   func() Filter { return NewChebyshevIIFilter() },
+            Elliptic:      func() Filter { return NewEllipticFilter() },
+            FIRKaiser:     func() Filter { return NewKaiserFIRFilter() },
+            FIRRemez:      func() Filter { return NewRemezFIRFilter() },
+            RootRaisedCosine: func() Filter { return NewRootRaisedCosineFilter() },
+            Gaussian:      func() Filter { return NewGaussianFilter() },
         },
     }
 }
@@ -0,0 +1,270 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+// FIR runs a finite impulse response filter. FIR is streaming: the last
+// len(taps)-1 inputs carry over between calls to Process.
+type FIR struct {
+	taps    []float64
+	history []float64    // The previous len(taps)-1 inputs, oldest first
+	fast    *OverlapSave // FFT convolution for long filters, nil for direct
+}
+
+// NewFIR creates a filter with the given taps. Filters of
+// FastConvolutionTaps or more taps convolve by FFT (overlap-save), shorter
+// ones directly.
+func NewFIR(taps []float64) (*FIR, error) {
+	f, err := NewDirectFIR(taps)
+	if err != nil {
+		return nil, err
+	}
+	if len(taps) >= FastConvolutionTaps {
+		if f.fast, err = NewOverlapSave(f.taps); err != nil {
+			return nil, err
+		}
+		f.history = nil
+	}
+	return f, nil
+}
+
+// NewDirectFIR creates a filter with the given taps that always convolves
+// directly
+func NewDirectFIR(taps []float64) (*FIR, error) {
+	if len(taps) == 0 {
+		return nil, fmt.Errorf("filter has no taps")
+	}
+	for i, v := range taps {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("tap %d is not finite", i)
+		}
+	}
+	return &FIR{
+		taps:    slices.Clone(taps),
+		history: make([]float64, len(taps)-1),
+	}, nil
+}
+
+// Taps returns the filter taps
+func (f *FIR) Taps() []float64 {
+	return f.taps
+}
+
+// Process filters a block of samples into a new slice
+func (f *FIR) Process(samples []float64) []float64 {
+	if f.fast != nil {
+		return f.fast.Process(samples)
+	}
+
+	n := len(f.taps)
+	buf := append(f.history, samples...)
+	result := make([]float64, len(samples))
+	for i := range result {
+		window := buf[i : i+n]
+		var sum float64
+		for k, t := range f.taps {
+			sum += t * window[n-1-k]
+		}
+		result[i] = sum
+	}
+	f.history = append(f.history[:0:0], buf[len(buf)-(n-1):]...)
+	return result
+}
+
+// Reset clears the input history
+func (f *FIR) Reset() {
+	if f.fast != nil {
+		f.fast.Reset()
+	}
+	clear(f.history)
+}
+
+// Latency returns the delay of the centre tap, (N-1)/2 samples, which is
+// the group delay of linear-phase (symmetric) taps
+func (f *FIR) Latency() float64 {
+	return float64(len(f.taps)-1) / 2
+}
+
+// Response returns the complex response at freq Hz
+func (f *FIR) Response(freq, sampleRate float64) complex128 {
+	return evaluate(f.taps, freq, sampleRate)
+}
+
+// firFilter runs a designed FIR filter. The FIR filter types embed it and
+// supply their design method.
+type firFilter struct {
+	config FilterConfig
+	fir    *FIR
+	stats  FilterStats
+}
+
+// Process applies the filter to the input samples
+func (f *firFilter) Process(samples []float64) ([]float64, error) {
+	if f.fir == nil {
+		return nil, fmt.Errorf("filter not configured")
+	}
+	if len(samples) == 0 {
+		return []float64{}, nil
+	}
+
+	result := f.fir.Process(samples)
+	f.stats = FilterStats{
+		InputSamples:  len(samples),
+		OutputSamples: len(result),
+		InputMean:     calculateMean(samples),
+		InputStdDev:   calculateStdDev(samples),
+		OutputMean:    calculateMean(result),
+		OutputStdDev:  calculateStdDev(result),
+	}
+	if f.stats.InputStdDev > 0 {
+		f.stats.NoiseReduction = 1 - f.stats.OutputStdDev/f.stats.InputStdDev
+	}
+	return result, nil
+}
+
+// GetFrequencyResponse returns the filter's magnitude response at the specified frequencies
+func (f *firFilter) GetFrequencyResponse(frequencies []float64) ([]float64, error) {
+	if f.fir == nil {
+		return nil, fmt.Errorf("filter not configured")
+	}
+	response := make([]float64, len(frequencies))
+	for i, freq := range frequencies {
+		response[i] = cmplx.Abs(f.fir.Response(freq, f.config.SampleRate))
+	}
+	return response, nil
+}
+
+// Taps returns the designed filter taps
+func (f *firFilter) Taps() []float64 {
+	if f.fir == nil {
+		return nil
+	}
+	return f.fir.Taps()
+}
+
+// Coefficients returns the taps as the feedforward coefficients, for use
+// with FilterResponse
+func (f *firFilter) Coefficients() (b, a []float64) {
+	if f.fir == nil {
+		return nil, nil
+	}
+	return f.fir.Taps(), []float64{1}
+}
+
+// Reset clears the input history
+func (f *firFilter) Reset() {
+	if f.fir != nil {
+		f.fir.Reset()
+	}
+}
+
+// Latency returns the group delay of the linear-phase taps, (N-1)/2 samples
+func (f *firFilter) Latency() float64 {
+	if f.fir == nil {
+		return 0
+	}
+	return f.fir.Latency()
+}
+
+// Flush runs the taps over zeros past the end of the signal and returns the
+// Latency() outputs that centre on its last samples. Even-length filters
+// have a half-sample delay and cannot be aligned, so they return nil.
+func (f *firFilter) Flush() []float64 {
+	if f.fir == nil || len(f.fir.Taps())%2 == 0 {
+		return nil
+	}
+	return f.fir.Process(make([]float64, int(f.fir.Latency())))
+}
+
+// GetStats returns the filter's statistics
+func (f *firFilter) GetStats() FilterStats {
+	return f.stats
+}
+
+// GetConfig returns the filter's configuration
+func (f *firFilter) GetConfig() FilterConfig {
+	return f.config
+}
+
+// use installs designed taps
+func (f *firFilter) use(config FilterConfig, taps []float64) error {
+	fir, err := NewFIR(taps)
+	if err != nil {
+		return fmt.Errorf("failed to design filter: %w", err)
+	}
+	config.Taps = len(taps)
+	f.config = config
+	f.fir = fir
+	f.stats = FilterStats{}
+	return nil
+}
+
+// KaiserFIRFilter is a linear-phase FIR filter designed by windowing the
+// ideal (sinc) response with a Kaiser window. The window is sized from the
+// narrowest transition band and FilterConfig.Attenuation unless
+// FilterConfig.Taps is set.
+type KaiserFIRFilter struct {
+	firFilter
+}
+
+// NewKaiserFIRFilter creates a new windowed-sinc FIR filter instance
+func NewKaiserFIRFilter() *KaiserFIRFilter {
+	return &KaiserFIRFilter{}
+}
+
+// Configure designs the filter for the provided configuration
+func (f *KaiserFIRFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	bands, err := config.firBands()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	taps, err := DesignKaiser(config.Taps, bands, config.SampleRate, config.Attenuation)
+	if err != nil {
+		return fmt.Errorf("failed to design filter: %w", err)
+	}
+	return f.use(config, taps)
+}
+
+// RemezFIRFilter is a linear-phase equiripple FIR filter designed with the
+// Parks-McClellan (Remez exchange) algorithm. Unless FilterConfig.Taps is
+// set, the length is estimated from the narrowest transition band,
+// FilterConfig.Ripple and FilterConfig.Attenuation.
+type RemezFIRFilter struct {
+	firFilter
+}
+
+// NewRemezFIRFilter creates a new equiripple FIR filter instance
+func NewRemezFIRFilter() *RemezFIRFilter {
+	return &RemezFIRFilter{}
+}
+
+// Configure designs the filter for the provided configuration
+func (f *RemezFIRFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	bands, err := config.firBands()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	numTaps := config.Taps
+	if numTaps == 0 {
+		numTaps = RemezLength(minTransition(bands), config.SampleRate, config.Ripple, config.Attenuation)
+		if nyquistGain(bands, config.SampleRate) != 0 {
+			numTaps |= 1
+		}
+	}
+	taps, err := DesignRemez(numTaps, bands, config.SampleRate)
+	if err != nil {
+		return fmt.Errorf("failed to design filter: %w", err)
+	}
+	return f.use(config, taps)
+}
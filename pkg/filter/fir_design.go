@@ -0,0 +1,412 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+)
+
+// Band is one band of an FIR specification: the desired gain from Low to
+// High Hz. Gaps between bands are transition bands, where the response is
+// unconstrained.
+type Band struct {
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	Gain   float64 `json:"gain"`
+	Weight float64 `json:"weight,omitempty"` // Relative error weight for Remez design (default 1)
+}
+
+// validateBands checks that bands are ordered, do not overlap and lie
+// between DC and Nyquist
+func validateBands(bands []Band, sampleRate float64) error {
+	if len(bands) == 0 {
+		return fmt.Errorf("no bands specified")
+	}
+	nyquist := sampleRate / 2
+	for i, b := range bands {
+		if b.Low < 0 || b.High > nyquist || b.Low >= b.High {
+			return fmt.Errorf("band %d (%g-%g Hz) must satisfy 0 <= low < high <= Nyquist (%g Hz)", i, b.Low, b.High, nyquist)
+		}
+		if i > 0 && b.Low < bands[i-1].High {
+			return fmt.Errorf("band %d overlaps band %d", i, i-1)
+		}
+		if b.Weight < 0 {
+			return fmt.Errorf("band %d has a negative weight", i)
+		}
+	}
+	return nil
+}
+
+// firBands returns the bands of an FIR configuration: Bands when given,
+// otherwise the pass- and stopbands of the response type with a transition
+// of TransitionWidth centred on each edge. When ripple and attenuation are
+// both set, stopbands are weighted by their ratio for Remez design.
+func (c FilterConfig) firBands() ([]Band, error) {
+	if len(c.Bands) > 0 {
+		return c.Bands, validateBands(c.Bands, c.SampleRate)
+	}
+
+	nyquist := c.SampleRate / 2
+	half := c.TransitionWidth / 2
+	stopWeight := 1.0
+	if c.Ripple > 0 && c.Attenuation > 0 {
+		passDev, stopDev := deviations(c.Ripple, c.Attenuation)
+		stopWeight = passDev / stopDev
+	}
+	pass := func(lo, hi float64) Band { return Band{Low: lo, High: hi, Gain: 1, Weight: 1} }
+	stop := func(lo, hi float64) Band { return Band{Low: lo, High: hi, Gain: 0, Weight: stopWeight} }
+
+	var bands []Band
+	switch c.ResponseType.orDefault() {
+	case LowPass:
+		bands = []Band{pass(0, c.CutoffFreq-half), stop(c.CutoffFreq+half, nyquist)}
+	case HighPass:
+		bands = []Band{stop(0, c.CutoffFreq-half), pass(c.CutoffFreq+half, nyquist)}
+	case BandPass:
+		bands = []Band{stop(0, c.LowFreq-half), pass(c.LowFreq+half, c.HighFreq-half), stop(c.HighFreq+half, nyquist)}
+	case BandStop:
+		bands = []Band{pass(0, c.LowFreq-half), stop(c.LowFreq+half, c.HighFreq-half), pass(c.HighFreq+half, nyquist)}
+	}
+	if err := validateBands(bands, c.SampleRate); err != nil {
+		return nil, fmt.Errorf("transition width %g Hz does not fit the band edges: %w", c.TransitionWidth, err)
+	}
+	return bands, nil
+}
+
+// deviations converts a passband ripple and a stopband attenuation in dB to
+// amplitude deviations
+func deviations(ripple, attenuation float64) (float64, float64) {
+	r := math.Pow(10, ripple/20)
+	return (r - 1) / (r + 1), math.Pow(10, -attenuation/20)
+}
+
+// minTransition returns the narrowest gap between bands in Hz, or 0 when
+// the bands touch
+func minTransition(bands []Band) float64 {
+	width := math.Inf(1)
+	for i := 1; i < len(bands); i++ {
+		width = math.Min(width, bands[i].Low-bands[i-1].High)
+	}
+	if math.IsInf(width, 1) {
+		return 0
+	}
+	return width
+}
+
+// nyquistGain returns the desired gain at Nyquist, or 0 when no band
+// reaches it
+func nyquistGain(bands []Band, sampleRate float64) float64 {
+	if last := bands[len(bands)-1]; last.High >= sampleRate/2 {
+		return last.Gain
+	}
+	return 0
+}
+
+// KaiserLength estimates the number of taps of a Kaiser-window design with
+// the given transition width in Hz and stopband attenuation in dB
+func KaiserLength(transition, sampleRate, attenuation float64) int {
+	return int(math.Ceil((attenuation-7.95)/(2.285*2*math.Pi*transition/sampleRate))) + 1
+}
+
+// RemezLength estimates the number of taps of an equiripple design with
+// the given transition width in Hz, passband ripple and stopband
+// attenuation in dB (Kaiser's formula)
+func RemezLength(transition, sampleRate, ripple, attenuation float64) int {
+	passDev, stopDev := deviations(ripple, attenuation)
+	return int(math.Ceil((-20*math.Log10(math.Sqrt(passDev*stopDev))-13)/(14.6*transition/sampleRate))) + 1
+}
+
+// DesignKaiser returns the taps of a linear-phase FIR filter approximating
+// the piecewise-constant response of bands, with the ideal response
+// stepping in the middle of each transition band and truncated by a Kaiser
+// window for the given stopband attenuation in dB. A numTaps of 0 sizes the
+// filter from the narrowest transition band. Computed lengths are odd so
+// that any gain at Nyquist is possible.
+func DesignKaiser(numTaps int, bands []Band, sampleRate, attenuation float64) ([]float64, error) {
+	if err := validateBands(bands, sampleRate); err != nil {
+		return nil, err
+	}
+	if attenuation <= 0 {
+		return nil, fmt.Errorf("stopband attenuation must be positive")
+	}
+	if numTaps == 0 {
+		transition := minTransition(bands)
+		if transition <= 0 {
+			return nil, fmt.Errorf("bands without transition gaps need an explicit number of taps")
+		}
+		numTaps = KaiserLength(transition, sampleRate, attenuation) | 1
+	}
+	if numTaps < 1 {
+		return nil, fmt.Errorf("number of taps must be positive, got %d", numTaps)
+	}
+	if numTaps%2 == 0 && nyquistGain(bands, sampleRate) != 0 {
+		return nil, fmt.Errorf("an even number of taps cannot pass Nyquist; use an odd number")
+	}
+
+	// Step frequencies of the ideal response in cycles per sample
+	edges := []float64{0}
+	for i := 1; i < len(bands); i++ {
+		edges = append(edges, (bands[i-1].High+bands[i].Low)/2/sampleRate)
+	}
+	edges = append(edges, 0.5)
+
+	w, err := window.Symmetric(window.Kaiser, numTaps, window.KaiserBeta(attenuation))
+	if err != nil {
+		return nil, err
+	}
+
+	// The ideal response is a sum of band-limited sincs, one per band
+	lowpass := func(cutoff, m float64) float64 {
+		if m == 0 {
+			return 2 * cutoff
+		}
+		return math.Sin(2*math.Pi*cutoff*m) / (math.Pi * m)
+	}
+	taps := make([]float64, numTaps)
+	centre := float64(numTaps-1) / 2
+	for n := range taps {
+		m := float64(n) - centre
+		var h float64
+		for i, b := range bands {
+			h += b.Gain * (lowpass(edges[i+1], m) - lowpass(edges[i], m))
+		}
+		taps[n] = h * w[n]
+	}
+	return taps, nil
+}
+
+// DesignRemez returns the taps of the linear-phase FIR filter of numTaps
+// taps whose weighted error from the gains of bands has the smallest peak
+// (the Parks-McClellan equiripple design). Even lengths have a zero at
+// Nyquist.
+func DesignRemez(numTaps int, bands []Band, sampleRate float64) ([]float64, error) {
+	if err := validateBands(bands, sampleRate); err != nil {
+		return nil, err
+	}
+	if numTaps < 3 {
+		return nil, fmt.Errorf("number of taps must be at least 3, got %d", numTaps)
+	}
+	odd := numTaps%2 == 1
+	if !odd && nyquistGain(bands, sampleRate) != 0 {
+		return nil, fmt.Errorf("an even number of taps cannot pass Nyquist; use an odd number")
+	}
+
+	// Cosine terms of the amplitude response; even lengths are written as
+	// cos(πf) times a cosine series, which moves the factor into the
+	// desired response and weight
+	r := numTaps / 2
+	if odd {
+		r = (numTaps + 1) / 2
+	}
+
+	// Dense grid over the bands in cycles per sample
+	const density = 16
+	step := 0.5 / float64(density*r)
+	var grid, desired, weight []float64
+	var band []int
+	for i, b := range bands {
+		lo, hi := b.Low/sampleRate, b.High/sampleRate
+		if !odd {
+			hi = math.Min(hi, 0.5-step)
+		}
+		if lo > hi {
+			continue
+		}
+		wt := b.Weight
+		if wt == 0 {
+			wt = 1
+		}
+		n := max(1, int(math.Ceil((hi-lo)/step)))
+		for j := 0; j <= n; j++ {
+			f := lo + (hi-lo)*float64(j)/float64(n)
+			d, w := b.Gain, wt
+			if !odd {
+				c := math.Cos(math.Pi * f)
+				d, w = d/c, w*c
+			}
+			grid = append(grid, f)
+			desired = append(desired, d)
+			weight = append(weight, w)
+			band = append(band, i)
+		}
+	}
+	if len(grid) < r+1 {
+		return nil, fmt.Errorf("bands are too narrow for %d taps", numTaps)
+	}
+
+	x := make([]float64, len(grid))
+	for i, f := range grid {
+		x[i] = math.Cos(2 * math.Pi * f)
+	}
+
+	// Initial extremal frequencies evenly spread over the grid
+	extremal := make([]int, r+1)
+	for k := range extremal {
+		extremal[k] = k * (len(grid) - 1) / r
+	}
+
+	var interp *barycentric
+	for iteration := 0; iteration < 100; iteration++ {
+		// Deviation that makes the weighted error alternate on the extremals
+		xs := make([]float64, r+1)
+		for k, i := range extremal {
+			xs[k] = x[i]
+		}
+		bw := barycentricWeights(xs)
+		var num, den float64
+		sign := 1.0
+		for k, i := range extremal {
+			num += bw[k] * desired[i]
+			den += sign * bw[k] / weight[i]
+			sign = -sign
+		}
+		delta := num / den
+
+		// Interpolate the amplitude through r of the extremals
+		values := make([]float64, r)
+		sign = 1
+		for k := range values {
+			i := extremal[k]
+			values[k] = desired[i] - sign*delta/weight[i]
+			sign = -sign
+		}
+		interp = newBarycentric(xs[:r], values)
+
+		errs := make([]float64, len(grid))
+		peak := 0.0
+		for i := range grid {
+			errs[i] = weight[i] * (desired[i] - interp.at(x[i]))
+			peak = math.Max(peak, math.Abs(errs[i]))
+		}
+
+		next := selectExtremals(errs, band, r+1)
+		if len(next) < r+1 || slices.Equal(next, extremal) || peak-math.Abs(delta) <= 1e-9*peak {
+			break
+		}
+		extremal = next
+	}
+
+	// Sample the amplitude response at numTaps frequencies and invert the
+	// linear-phase DFT
+	amplitude := make([]float64, numTaps)
+	for k := range amplitude {
+		f := float64(k) / float64(numTaps)
+		amplitude[k] = interp.at(math.Cos(2 * math.Pi * f))
+		if !odd {
+			amplitude[k] *= math.Cos(math.Pi * f)
+		}
+	}
+	taps := make([]float64, numTaps)
+	centre := float64(numTaps-1) / 2
+	for n := range taps {
+		m := float64(n) - centre
+		var sum float64
+		for k, a := range amplitude {
+			sum += a * math.Cos(2*math.Pi*float64(k)*m/float64(numTaps))
+		}
+		taps[n] = sum / float64(numTaps)
+	}
+	return taps, nil
+}
+
+// selectExtremals returns the indices of count alternating extrema of the
+// error, keeping the largest
+func selectExtremals(errs []float64, band []int, count int) []int {
+	// Local extrema within each band, including the band edges
+	var candidates []int
+	for i, e := range errs {
+		prevOK := i == 0 || band[i-1] != band[i] || (e >= 0 && e >= errs[i-1]) || (e < 0 && e <= errs[i-1])
+		nextOK := i == len(errs)-1 || band[i+1] != band[i] || (e >= 0 && e >= errs[i+1]) || (e < 0 && e <= errs[i+1])
+		if prevOK && nextOK {
+			candidates = append(candidates, i)
+		}
+	}
+
+	// Of neighbours with the same sign keep the larger
+	alternate := func(indices []int) []int {
+		var out []int
+		for _, i := range indices {
+			if n := len(out); n > 0 && (errs[i] >= 0) == (errs[out[n-1]] >= 0) {
+				if math.Abs(errs[i]) > math.Abs(errs[out[n-1]]) {
+					out[n-1] = i
+				}
+				continue
+			}
+			out = append(out, i)
+		}
+		return out
+	}
+	extremal := alternate(candidates)
+
+	// Drop the smallest until count remain, keeping the alternation
+	for len(extremal) > count {
+		if len(extremal) == count+1 {
+			if math.Abs(errs[extremal[0]]) < math.Abs(errs[extremal[len(extremal)-1]]) {
+				extremal = extremal[1:]
+			} else {
+				extremal = extremal[:len(extremal)-1]
+			}
+			continue
+		}
+		smallest := 0
+		for k, i := range extremal {
+			if math.Abs(errs[i]) < math.Abs(errs[extremal[smallest]]) {
+				smallest = k
+			}
+		}
+		extremal = alternate(slices.Delete(extremal, smallest, smallest+1))
+	}
+	return extremal
+}
+
+// barycentricWeights returns the weights 1/Π(x[k]-x[j]) of barycentric
+// Lagrange interpolation, scaled by a common factor to avoid overflow
+func barycentricWeights(x []float64) []float64 {
+	logs := make([]float64, len(x))
+	signs := make([]float64, len(x))
+	minLog := math.Inf(1)
+	for k := range x {
+		signs[k] = 1
+		for j := range x {
+			if j == k {
+				continue
+			}
+			d := x[k] - x[j]
+			if d < 0 {
+				signs[k] = -signs[k]
+			}
+			logs[k] += math.Log(math.Abs(d))
+		}
+		minLog = math.Min(minLog, logs[k])
+	}
+	w := make([]float64, len(x))
+	for k := range w {
+		w[k] = signs[k] * math.Exp(minLog-logs[k])
+	}
+	return w
+}
+
+// barycentric interpolates a polynomial through points in barycentric form
+type barycentric struct {
+	x, y, w []float64
+}
+
+func newBarycentric(x, y []float64) *barycentric {
+	return &barycentric{x: x, y: y, w: barycentricWeights(x)}
+}
+
+func (b *barycentric) at(x float64) float64 {
+	var num, den float64
+	for k, xk := range b.x {
+		d := x - xk
+		if d == 0 {
+			return b.y[k]
+		}
+		t := b.w[k] / d
+		num += t * b.y[k]
+		den += t
+	}
+	return num / den
+}
@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"fmt"
+	"math/cmplx"
+)
+
+// iirFilter runs an IIR design as a cascade of second-order sections. The
+// IIR filter types embed it and supply their analog low-pass prototype.
+type iirFilter struct {
+	config    FilterConfig
+	reference float64     // Passband frequency in Hz where the gain is set
+	sos       *SOS        // Second-order sections
+	stats     FilterStats // Filter statistics
+}
+
+// design transforms the prototype to the response of config, maps it to
+// the z-plane with the bilinear transform and factors it into second-order
+// sections. gain is the prototype's gain at DC, which becomes the gain at
+// the passband reference frequency.
+func (f *iirFilter) design(config FilterConfig, prototype zpk, gain float64) error {
+	digital, reference, err := transform(prototype, config)
+	if err != nil {
+		return fmt.Errorf("failed to calculate coefficients: %w", err)
+	}
+
+	sections := digital.sections()
+	if err := normalizeSections(sections, reference, config.SampleRate, gain); err != nil {
+		return fmt.Errorf("failed to calculate coefficients: %w", err)
+	}
+	sos, err := NewSOS(sections)
+	if err != nil {
+		return fmt.Errorf("failed to calculate coefficients: %w", err)
+	}
+
+	config.ResponseType = config.ResponseType.orDefault()
+	f.config = config
+	f.reference = reference
+	f.sos = sos
+	f.stats = FilterStats{}
+	return nil
+}
+
+// Process applies the filter to the input samples
+func (f *iirFilter) Process(samples []float64) ([]float64, error) {
+	if f.sos == nil {
+		return nil, fmt.Errorf("filter not configured")
+	}
+	if len(samples) == 0 {
+		return []float64{}, nil
+	}
+
+	result := f.sos.Process(samples)
+
+	// Calculate statistics
+	f.stats = FilterStats{
+		InputMean:    calculateMean(samples),
+		InputStdDev:  calculateStdDev(samples),
+		OutputMean:   calculateMean(result),
+		OutputStdDev: calculateStdDev(result),
+	}
+	f.stats.NoiseReduction = 1 - (f.stats.OutputStdDev / f.stats.InputStdDev)
+
+	return result, nil
+}
+
+// GetFrequencyResponse returns the filter's magnitude response at the specified frequencies
+func (f *iirFilter) GetFrequencyResponse(frequencies []float64) ([]float64, error) {
+	if f.sos == nil {
+		return nil, fmt.Errorf("filter not configured")
+	}
+
+	response := make([]float64, len(frequencies))
+	for i, freq := range frequencies {
+		response[i] = cmplx.Abs(f.sos.Response(freq, f.config.SampleRate))
+	}
+	return response, nil
+}
+
+// Reset clears the section state
+func (f *iirFilter) Reset() {
+	if f.sos != nil {
+		f.sos.Reset()
+	}
+}
+
+// Latency returns the group delay in samples at the passband reference
+// frequency: DC for low-pass and band-stop, Nyquist for high-pass and the
+// band centre for band-pass
+func (f *iirFilter) Latency() float64 {
+	if f.sos == nil {
+		return 0
+	}
+	return f.sos.GroupDelay(f.reference, f.config.SampleRate)
+}
+
+// Sections returns the second-order sections the filter runs
+func (f *iirFilter) Sections() []Biquad {
+	if f.sos == nil {
+		return nil
+	}
+	return f.sos.Sections()
+}
+
+// Coefficients returns the feedforward (b) and feedback (a) coefficients
+// of the expanded transfer function, for use with FilterResponse
+func (f *iirFilter) Coefficients() (b, a []float64) {
+	if f.sos == nil {
+		return nil, nil
+	}
+	return f.sos.Coefficients()
+}
+
+// GetStats returns the filter's statistics
+func (f *iirFilter) GetStats() FilterStats {
+	return f.stats
+}
+
+// GetConfig returns the filter's configuration
+func (f *iirFilter) GetConfig() FilterConfig {
+	return f.config
+}
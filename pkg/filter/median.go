@@ -8,9 +8,15 @@ import (
 // MedianFilter implements a median filter for impulse noise reduction.
 // The median filter is particularly effective at removing impulse noise
 // (salt and pepper noise) while preserving edges in the signal.
+// The window ends at the current sample, like the moving average, so the
+// output is delayed by Latency(), half a window, relative to a window
+// centred on the sample; the first outputs use the shorter window
+// available. Dropping the first Latency() outputs and appending Flush()
+// gives the centred median, whose windows are cut short at both ends.
 type MedianFilter struct {
 	windowSize int       // Size of the sliding window (must be odd)
 	window     []float64 // Buffer for window values
+	history    []float64 // Last windowSize-1 input samples of previous blocks
 	stats      FilterStats
 }
 
@@ -36,15 +42,16 @@ func (f *MedianFilter) Configure(config FilterConfig) error {
 		f.windowSize++
 	}
 
-	// Allocate buffer
+	// Allocate buffers
 	f.window = make([]float64, f.windowSize)
+	f.history = make([]float64, 0, f.windowSize-1)
 	f.stats = FilterStats{}
 
 	return nil
 }
 
 // Process applies the median filter to the input samples.
-// For each sample, it takes a window of neighboring samples,
+// For each sample, it takes a window of the preceding samples,
 // sorts them, and selects the median value as the output.
 func (f *MedianFilter) Process(samples []float64) ([]float64, error) {
 	if f.windowSize == 0 {
@@ -55,18 +62,21 @@ func (f *MedianFilter) Process(samples []float64) ([]float64, error) {
 	}
 
 	result := make([]float64, len(samples))
-	halfWindow := f.windowSize / 2
+
+	// Prepend the tail of the previous block so windows span block boundaries
+	input := append(f.history[:len(f.history):len(f.history)], samples...)
+	offset := len(f.history)
 
 	// Process each sample
-	for i := 0; i < len(samples); i++ {
+	for i := range samples {
 		// Determine window boundaries
-		windowStart := max(0, i-halfWindow)
-		windowEnd := min(len(samples), i+halfWindow+1)
+		windowEnd := offset + i + 1
+		windowStart := max(0, windowEnd-f.windowSize)
 		windowSize := windowEnd - windowStart
 
 		// Copy window values and sort
 		window := f.window[:windowSize]
-		copy(window, samples[windowStart:windowEnd])
+		copy(window, input[windowStart:windowEnd])
 		sort.Float64s(window)
 
 		// Select median value
@@ -78,6 +88,10 @@ func (f *MedianFilter) Process(samples []float64) ([]float64, error) {
 		}
 	}
 
+	// Keep the last windowSize-1 samples for the next block
+	tail := input[max(0, len(input)-(f.windowSize-1)):]
+	f.history = append(f.history[:0], tail...)
+
 	// Calculate statistics
 	f.stats = FilterStats{
 		InputMean:      calculateMean(samples),
@@ -93,6 +107,39 @@ func (f *MedianFilter) Process(samples []float64) ([]float64, error) {
 	return result, nil
 }
 
+// Flush returns the centred medians of the last Latency() samples of the
+// signal (fewer for a shorter signal), over windows cut short by its end
+func (f *MedianFilter) Flush() []float64 {
+	half := f.windowSize / 2
+	count := min(half, len(f.history))
+	result := make([]float64, count)
+	for j := range result {
+		// History index of the sample and the start of its window
+		i := len(f.history) - count + j
+		window := f.window[:len(f.history)-max(0, i-half)]
+		copy(window, f.history[max(0, i-half):])
+		sort.Float64s(window)
+
+		medianIdx := len(window) / 2
+		if len(window)%2 == 0 {
+			result[j] = (window[medianIdx-1] + window[medianIdx]) / 2
+		} else {
+			result[j] = window[medianIdx]
+		}
+	}
+	return result
+}
+
+// Reset clears the samples kept from previous blocks
+func (f *MedianFilter) Reset() {
+	f.history = f.history[:0]
+}
+
+// Latency returns the delay of the window centre, half a window
+func (f *MedianFilter) Latency() float64 {
+	return float64(f.windowSize / 2)
+}
+
 // GetStats returns the filter's statistics
 func (f *MedianFilter) GetStats() FilterStats {
 	return f.stats
@@ -56,6 +56,20 @@ func (f *MovingAverageFilter) Process(input []float64) ([]float64, error) {
 	return output, nil
 }
 
+// Reset clears the averaging window
+func (f *MovingAverageFilter) Reset() {
+	for i := range f.buffer {
+		f.buffer[i] = 0
+	}
+	f.position = 0
+	f.sum = 0
+}
+
+// Latency returns the delay of the window centre, (N-1)/2 samples
+func (f *MovingAverageFilter) Latency() float64 {
+	return float64(f.config.WindowSize-1) / 2
+}
+
 // GetStats returns the filter's statistics
 func (f *MovingAverageFilter) GetStats() FilterStats {
 	return f.BaseFilter.stats
@@ -0,0 +1,88 @@
+package filter
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/Vivirinter/sdr-parser/pkg/fft"
+)
+
+// FastConvolutionTaps is the length from which NewFIR convolves with
+// overlap-save instead of directly, about where the FFT becomes faster
+const FastConvolutionTaps = 96
+
+// OverlapSave convolves a signal with FIR taps by FFT, block by block. Each
+// block is the last len(taps)-1 inputs followed by new ones; of its circular
+// convolution with the taps only the outputs for the new inputs are kept,
+// which equal the linear convolution. Blocks end with each call to Process,
+// so the output is exact for any block sizes.
+type OverlapSave struct {
+	taps     []float64
+	plan     *fft.RealPlan
+	response []complex128 // Transform of the zero-padded taps
+	history  []float64    // The previous len(taps)-1 inputs, oldest first
+
+	block    []float64 // Work buffers of the transform length
+	spectrum []complex128
+}
+
+// NewOverlapSave creates a convolver for the given taps. The transform
+// length is the power of two at least four times the number of taps.
+func NewOverlapSave(taps []float64) (*OverlapSave, error) {
+	if len(taps) == 0 {
+		return nil, fmt.Errorf("filter has no taps")
+	}
+	size := 1 << bits.Len(uint(4*len(taps)-1))
+	plan, err := fft.NewRealPlan(size)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &OverlapSave{
+		taps:     taps,
+		plan:     plan,
+		response: make([]complex128, plan.Bins()),
+		history:  make([]float64, len(taps)-1),
+		block:    make([]float64, size),
+		spectrum: make([]complex128, plan.Bins()),
+	}
+	copy(o.block, taps)
+	if err := plan.Forward(o.response, o.block); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Process filters a block of samples into a new slice
+func (o *OverlapSave) Process(samples []float64) []float64 {
+	result := make([]float64, len(samples))
+	overlap := len(o.history)
+	step := len(o.block) - overlap
+	for start := 0; start < len(samples); start += step {
+		chunk := samples[start:min(start+step, len(samples))]
+
+		copy(o.block, o.history)
+		copy(o.block[overlap:], chunk)
+		clear(o.block[overlap+len(chunk):])
+		o.plan.Forward(o.spectrum, o.block)
+		for k := range o.spectrum {
+			o.spectrum[k] *= o.response[k]
+		}
+		o.plan.Inverse(o.block, o.spectrum)
+		copy(result[start:], o.block[overlap:overlap+len(chunk)])
+
+		// The history is the tail of the previous history and the chunk
+		if len(chunk) >= overlap {
+			copy(o.history, chunk[len(chunk)-overlap:])
+		} else {
+			copy(o.history, o.history[len(chunk):])
+			copy(o.history[overlap-len(chunk):], chunk)
+		}
+	}
+	return result
+}
+
+// Reset clears the input history
+func (o *OverlapSave) Reset() {
+	clear(o.history)
+}
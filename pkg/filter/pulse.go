@@ -0,0 +1,133 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+)
+
+// RootRaisedCosineFilter is a root-raised-cosine pulse-shaping or matched
+// filter with roll-off FilterConfig.RollOff, spanning
+// FilterConfig.SymbolSpan symbols of FilterConfig.SamplesPerSymbol samples.
+// Its taps have unit energy, so a transmit and receive pair is a raised
+// cosine with unit gain at the symbol instants and zero gain at the
+// neighbouring ones (no inter-symbol interference).
+type RootRaisedCosineFilter struct {
+	firFilter
+}
+
+// NewRootRaisedCosineFilter creates a new root-raised-cosine filter instance
+func NewRootRaisedCosineFilter() *RootRaisedCosineFilter {
+	return &RootRaisedCosineFilter{}
+}
+
+// Configure designs the filter for the provided configuration
+func (f *RootRaisedCosineFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	taps, err := RootRaisedCosineTaps(config.RollOff, config.SymbolSpan, config.SamplesPerSymbol)
+	if err != nil {
+		return fmt.Errorf("failed to design filter: %w", err)
+	}
+	return f.use(config, taps)
+}
+
+// GaussianFilter is a Gaussian pulse-shaping filter with bandwidth-time
+// product FilterConfig.BT, as used by GMSK and GFSK, spanning
+// FilterConfig.SymbolSpan symbols of FilterConfig.SamplesPerSymbol samples.
+// Its taps sum to one, so held (NRZ) symbols keep their level.
+type GaussianFilter struct {
+	firFilter
+}
+
+// NewGaussianFilter creates a new Gaussian filter instance
+func NewGaussianFilter() *GaussianFilter {
+	return &GaussianFilter{}
+}
+
+// Configure designs the filter for the provided configuration
+func (f *GaussianFilter) Configure(config FilterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	taps, err := GaussianTaps(config.BT, config.SymbolSpan, config.SamplesPerSymbol)
+	if err != nil {
+		return fmt.Errorf("failed to design filter: %w", err)
+	}
+	return f.use(config, taps)
+}
+
+// validatePulse checks the span and oversampling of a pulse-shaping filter
+func validatePulse(span, samplesPerSymbol int) error {
+	if span <= 0 {
+		return fmt.Errorf("symbol span must be positive")
+	}
+	if samplesPerSymbol <= 0 {
+		return fmt.Errorf("samples per symbol must be positive")
+	}
+	return nil
+}
+
+// RootRaisedCosineTaps returns the span*samplesPerSymbol+1 taps of a
+// root-raised-cosine filter with the given roll-off, scaled to unit energy
+func RootRaisedCosineTaps(rollOff float64, span, samplesPerSymbol int) ([]float64, error) {
+	if err := validatePulse(span, samplesPerSymbol); err != nil {
+		return nil, err
+	}
+	if rollOff < 0 || rollOff > 1 {
+		return nil, fmt.Errorf("roll-off must be between 0 and 1, got %g", rollOff)
+	}
+
+	taps := make([]float64, span*samplesPerSymbol+1)
+	centre := float64(len(taps)-1) / 2 // Half-way between two taps when the length is even
+	var energy float64
+	for n := range taps {
+		t := (float64(n) - centre) / float64(samplesPerSymbol) // In symbols
+		var h float64
+		switch {
+		case t == 0:
+			h = 1 - rollOff + 4*rollOff/math.Pi
+		case rollOff > 0 && math.Abs(math.Abs(4*rollOff*t)-1) < 1e-9:
+			// The singularity at t = ±1/(4β)
+			a := math.Pi / (4 * rollOff)
+			h = rollOff / math.Sqrt2 * ((1+2/math.Pi)*math.Sin(a) + (1-2/math.Pi)*math.Cos(a))
+		default:
+			num := math.Sin(math.Pi*t*(1-rollOff)) + 4*rollOff*t*math.Cos(math.Pi*t*(1+rollOff))
+			h = num / (math.Pi * t * (1 - (4*rollOff*t)*(4*rollOff*t)))
+		}
+		taps[n] = h
+		energy += h * h
+	}
+
+	scale := 1 / math.Sqrt(energy)
+	for n := range taps {
+		taps[n] *= scale
+	}
+	return taps, nil
+}
+
+// GaussianTaps returns the span*samplesPerSymbol+1 taps of a Gaussian
+// filter with bandwidth-time product bt (the 3 dB bandwidth in units of the
+// symbol rate), scaled to unit DC gain
+func GaussianTaps(bt float64, span, samplesPerSymbol int) ([]float64, error) {
+	if err := validatePulse(span, samplesPerSymbol); err != nil {
+		return nil, err
+	}
+	if bt <= 0 {
+		return nil, fmt.Errorf("bandwidth-time product must be positive, got %g", bt)
+	}
+
+	taps := make([]float64, span*samplesPerSymbol+1)
+	centre := float64(len(taps)-1) / 2
+	alpha := 2 * math.Pi * math.Pi * bt * bt / math.Ln2
+	var sum float64
+	for n := range taps {
+		t := (float64(n) - centre) / float64(samplesPerSymbol)
+		taps[n] = math.Exp(-alpha * t * t)
+		sum += taps[n]
+	}
+	for n := range taps {
+		taps[n] /= sum
+	}
+	return taps, nil
+}
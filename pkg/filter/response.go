@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"fmt"
+
+	"github.com/Vivirinter/sdr-parser/pkg/fft"
+)
+
+// TransferFunction is implemented by filters described by the rational
+// transfer function H(z) = B(z)/A(z)
+type TransferFunction interface {
+	Coefficients() (b, a []float64)
+}
+
+// FrequencyResponse evaluates H(z) = B(z)/A(z) at nfft/2+1 frequencies evenly
+// spaced from DC to Nyquist, using real FFTs of the zero-padded coefficients.
+// It returns the frequencies in Hz and the complex response.
+func FrequencyResponse(b, a []float64, nfft int, sampleRate float64) ([]float64, []complex128, error) {
+	if len(b) == 0 || len(a) == 0 {
+		return nil, nil, fmt.Errorf("filter coefficients are empty")
+	}
+	if nfft < len(b) || nfft < len(a) {
+		return nil, nil, fmt.Errorf("FFT size %d is shorter than the %d/%d filter coefficients", nfft, len(b), len(a))
+	}
+
+	plan, err := fft.NewRealPlan(nfft)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transform := func(c []float64) []complex128 {
+		padded := make([]float64, nfft)
+		copy(padded, c)
+		out := make([]complex128, plan.Bins())
+		plan.Forward(out, padded)
+		return out
+	}
+	num, den := transform(b), transform(a)
+
+	freqs := make([]float64, plan.Bins())
+	response := make([]complex128, plan.Bins())
+	for k := range response {
+		freqs[k] = float64(k) * sampleRate / float64(nfft)
+		if den[k] == 0 {
+			return nil, nil, fmt.Errorf("division by zero in frequency response at %.1f Hz", freqs[k])
+		}
+		response[k] = num[k] / den[k]
+	}
+	return freqs, response, nil
+}
+
+// SectionFilter is implemented by IIR filters that run as a cascade of
+// second-order sections
+type SectionFilter interface {
+	Sections() []Biquad
+}
+
+// SectionResponse evaluates the frequency response of a cascade of
+// second-order sections like FrequencyResponse, as the product of the
+// section responses
+func SectionResponse(sections []Biquad, nfft int, sampleRate float64) ([]float64, []complex128, error) {
+	if len(sections) == 0 {
+		return nil, nil, fmt.Errorf("cascade has no sections")
+	}
+
+	var freqs []float64
+	var response []complex128
+	for _, q := range sections {
+		f, h, err := FrequencyResponse([]float64{q.B0, q.B1, q.B2}, []float64{1, q.A1, q.A2}, nfft, sampleRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		if response == nil {
+			freqs, response = f, h
+			continue
+		}
+		for k := range response {
+			response[k] *= h[k]
+		}
+	}
+	return freqs, response, nil
+}
+
+// FilterResponse evaluates the frequency response of a filter with a
+// rational transfer function, like FrequencyResponse. Filters running as
+// second-order sections are evaluated section by section, which stays
+// accurate at high orders.
+func FilterResponse(f TransferFunction, nfft int, sampleRate float64) ([]float64, []complex128, error) {
+	if sf, ok := f.(SectionFilter); ok {
+		if sections := sf.Sections(); sections != nil {
+			return SectionResponse(sections, nfft, sampleRate)
+		}
+	}
+	b, a := f.Coefficients()
+	if b == nil || a == nil {
+		return nil, nil, fmt.Errorf("filter not configured")
+	}
+	return FrequencyResponse(b, a, nfft, sampleRate)
+}
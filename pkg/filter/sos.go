@@ -0,0 +1,247 @@
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+// Biquad is one second-order section
+//
+//	H(z) = (B0 + B1·z⁻¹ + B2·z⁻²) / (1 + A1·z⁻¹ + A2·z⁻²)
+//
+// First-order sections have B2 and A2 zero.
+type Biquad struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// Response returns the section's complex response at freq Hz
+func (q Biquad) Response(freq, sampleRate float64) complex128 {
+	return evaluate([]float64{q.B0, q.B1, q.B2}, freq, sampleRate) /
+		evaluate([]float64{1, q.A1, q.A2}, freq, sampleRate)
+}
+
+// SOS runs a cascade of second-order sections in transposed direct form
+// II. Running an IIR filter as a cascade keeps high orders and poles close
+// to the unit circle numerically stable, where the expanded polynomial is
+// not. SOS is streaming: state carries over between calls to Process.
+type SOS struct {
+	sections []Biquad
+	state    [][2]float64 // Delay elements of each section
+}
+
+// NewSOS creates a cascade of the given sections
+func NewSOS(sections []Biquad) (*SOS, error) {
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("cascade has no sections")
+	}
+	for i, q := range sections {
+		for _, v := range []float64{q.B0, q.B1, q.B2, q.A1, q.A2} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("section %d has a non-finite coefficient", i)
+			}
+		}
+	}
+	return &SOS{
+		sections: slices.Clone(sections),
+		state:    make([][2]float64, len(sections)),
+	}, nil
+}
+
+// Sections returns the sections of the cascade
+func (s *SOS) Sections() []Biquad {
+	return s.sections
+}
+
+// ProcessSample filters one sample
+func (s *SOS) ProcessSample(x float64) float64 {
+	for i, q := range s.sections {
+		st := &s.state[i]
+		y := q.B0*x + st[0]
+		st[0] = q.B1*x - q.A1*y + st[1]
+		st[1] = q.B2*x - q.A2*y
+		x = y
+	}
+	return x
+}
+
+// Process filters a block of samples into a new slice
+func (s *SOS) Process(samples []float64) []float64 {
+	result := make([]float64, len(samples))
+	for i, x := range samples {
+		result[i] = s.ProcessSample(x)
+	}
+	return result
+}
+
+// Reset clears the delay elements
+func (s *SOS) Reset() {
+	for i := range s.state {
+		s.state[i] = [2]float64{}
+	}
+}
+
+// Response returns the cascade's complex response at freq Hz
+func (s *SOS) Response(freq, sampleRate float64) complex128 {
+	h := complex(1, 0)
+	for _, q := range s.sections {
+		h *= q.Response(freq, sampleRate)
+	}
+	return h
+}
+
+// GroupDelay returns the cascade's group delay in samples at freq Hz
+func (s *SOS) GroupDelay(freq, sampleRate float64) float64 {
+	var delay float64
+	for _, q := range s.sections {
+		delay += groupDelay([]float64{q.B0, q.B1, q.B2}, freq, sampleRate) -
+			groupDelay([]float64{1, q.A1, q.A2}, freq, sampleRate)
+	}
+	return delay
+}
+
+// Coefficients expands the cascade into the feedforward (b) and feedback
+// (a) coefficients of a single transfer function. The expansion loses
+// precision at high orders; filter with the sections instead.
+func (s *SOS) Coefficients() (b, a []float64) {
+	b, a = []float64{1}, []float64{1}
+	for _, q := range s.sections {
+		b = convolve(b, []float64{q.B0, q.B1, q.B2})
+		a = convolve(a, []float64{1, q.A1, q.A2})
+	}
+	return trimTrailingZeros(b), trimTrailingZeros(a)
+}
+
+func convolve(x, y []float64) []float64 {
+	out := make([]float64, len(x)+len(y)-1)
+	for i, u := range x {
+		for j, v := range y {
+			out[i+j] += u * v
+		}
+	}
+	return out
+}
+
+// trimTrailingZeros drops the zero high-order terms left by first-order sections
+func trimTrailingZeros(c []float64) []float64 {
+	n := len(c)
+	for n > 1 && c[n-1] == 0 {
+		n--
+	}
+	return c[:n]
+}
+
+// rootGroup is one real root, a pair of real roots or a conjugate pair,
+// which together give real section coefficients
+type rootGroup []complex128
+
+// groupRoots splits roots into conjugate pairs and pairs of real roots,
+// leaving at most one real root on its own
+func groupRoots(roots []complex128) []rootGroup {
+	const tolerance = 1e-9
+	var groups []rootGroup
+	var reals []float64
+	for _, r := range roots {
+		switch {
+		case math.Abs(imag(r)) <= tolerance*math.Max(1, cmplx.Abs(r)):
+			reals = append(reals, real(r))
+		case imag(r) > 0:
+			// The conjugate is implied, so it is taken exactly
+			groups = append(groups, rootGroup{r, cmplx.Conj(r)})
+		}
+	}
+	slices.Sort(reals)
+	for i := 0; i < len(reals); i += 2 {
+		if i+1 < len(reals) {
+			groups = append(groups, rootGroup{complex(reals[i], 0), complex(reals[i+1], 0)})
+		} else {
+			groups = append(groups, rootGroup{complex(reals[i], 0)})
+		}
+	}
+	return groups
+}
+
+// quadratic returns the coefficients c1, c2 of (1 - r1·z⁻¹)(1 - r2·z⁻¹)
+func (g rootGroup) quadratic() (float64, float64) {
+	switch len(g) {
+	case 0:
+		return 0, 0
+	case 1:
+		return -real(g[0]), 0
+	}
+	return -real(g[0] + g[1]), real(g[0] * g[1])
+}
+
+// sections converts a digital zpk into a cascade of second-order sections.
+// Each pole group is paired with its nearest zero group so that the peaks
+// and notches of a section partly cancel, and sections are ordered with
+// the poles closest to the unit circle last. Every section has a leading
+// numerator coefficient of 1 until it is normalized.
+func (s zpk) sections() []Biquad {
+	poles := groupRoots(s.poles)
+	zeros := groupRoots(s.zeros)
+
+	// Pair the poles closest to the unit circle first
+	slices.SortStableFunc(poles, func(x, y rootGroup) int {
+		return cmp.Compare(cmplx.Abs(y[0]), cmplx.Abs(x[0]))
+	})
+
+	var sections []Biquad
+	for _, p := range poles {
+		var z rootGroup
+		if len(zeros) > 0 {
+			// Prefer a zero group of the same size, so a lone real pole
+			// takes a lone real zero
+			sameSize := slices.ContainsFunc(zeros, func(g rootGroup) bool { return len(g) == len(p) })
+			best := -1
+			for i, candidate := range zeros {
+				if sameSize && len(candidate) != len(p) {
+					continue
+				}
+				if best < 0 || cmplx.Abs(candidate[0]-p[0]) < cmplx.Abs(zeros[best][0]-p[0]) {
+					best = i
+				}
+			}
+			z = zeros[best]
+			zeros = slices.Delete(zeros, best, best+1)
+		}
+
+		b1, b2 := z.quadratic()
+		a1, a2 := p.quadratic()
+		sections = append(sections, Biquad{B0: 1, B1: b1, B2: b2, A1: a1, A2: a2})
+	}
+	// Any zeros left over get sections without poles
+	for _, z := range zeros {
+		b1, b2 := z.quadratic()
+		sections = append(sections, Biquad{B0: 1, B1: b1, B2: b2})
+	}
+	if len(sections) == 0 {
+		sections = append(sections, Biquad{B0: 1})
+	}
+
+	slices.Reverse(sections)
+	return sections
+}
+
+// normalizeSections scales every section to unity gain at freq Hz, and
+// then the first section to the overall gain, so that the signal level
+// stays similar through the cascade
+func normalizeSections(sections []Biquad, freq, sampleRate, gain float64) error {
+	for i := range sections {
+		g := cmplx.Abs(sections[i].Response(freq, sampleRate))
+		if g < 1e-12 || math.IsInf(g, 0) || math.IsNaN(g) {
+			return fmt.Errorf("section %d has no usable gain at %.1f Hz", i, freq)
+		}
+		scale := gain / g
+		if i > 0 {
+			scale = 1 / g
+		}
+		sections[i].B0 *= scale
+		sections[i].B1 *= scale
+		sections[i].B2 *= scale
+	}
+	return nil
+}
@@ -6,40 +6,142 @@ const (
 	MovingAverage FilterType = "moving_average"
 	Median       FilterType = "median"
 	Butterworth  FilterType = "butterworth"
+	ChebyshevI   FilterType = "chebyshev1"
+	ChebyshevII  FilterType = "chebyshev2"
+	Elliptic     FilterType = "elliptic"
+	FIRKaiser    FilterType = "fir_kaiser"
+	FIRRemez     FilterType = "fir_remez"
+	RootRaisedCosine FilterType = "rrc"
+	Gaussian     FilterType = "gaussian"
 
 	MinOrder           = 1
 	MinFrequency       = 0.0
-	MaxPoleRadius      = 0.99
+	MaxPoleRadius      = 0.9999999 // Poles this close to the unit circle are stable as second-order sections
 	MinMedianWindowSize = 3
 )
 
 type FilterType string
 
+// ResponseType selects the passband of a frequency-selective filter
+type ResponseType string
+
+const (
+	LowPass  ResponseType = "lowpass"
+	HighPass ResponseType = "highpass"
+	BandPass ResponseType = "bandpass"
+	BandStop ResponseType = "bandstop"
+)
+
+func (rt ResponseType) String() string {
+	return string(rt)
+}
+
+// Validate checks the response type; empty selects a low-pass
+func (rt ResponseType) Validate() error {
+	switch rt {
+	case "", LowPass, HighPass, BandPass, BandStop:
+		return nil
+	default:
+		return fmt.Errorf("unsupported response type: %s", rt)
+	}
+}
+
+// orDefault returns the response type, defaulting to a low-pass
+func (rt ResponseType) orDefault() ResponseType {
+	if rt == "" {
+		return LowPass
+	}
+	return rt
+}
+
+// validateEdges checks the edge frequencies a response type needs: the
+// cutoff for low- and high-pass, and the low and high edges otherwise
+func (c FilterConfig) validateEdges() error {
+	if err := c.ResponseType.Validate(); err != nil {
+		return err
+	}
+	switch c.ResponseType.orDefault() {
+	case BandPass, BandStop:
+		if c.LowFreq <= 0 {
+			return fmt.Errorf("low edge frequency must be positive")
+		}
+		if c.HighFreq <= c.LowFreq {
+			return fmt.Errorf("high edge frequency must be above the low edge frequency")
+		}
+	default:
+		if c.CutoffFreq <= 0 {
+			return fmt.Errorf("cutoff frequency must be positive")
+		}
+	}
+	return nil
+}
+
 func (ft FilterType) String() string {
 	return string(ft)
 }
 
 func (ft FilterType) Validate() error {
 	switch ft {
-	case MovingAverage, Median, Butterworth:
+	case MovingAverage, Median, Butterworth, ChebyshevI, ChebyshevII, Elliptic, FIRKaiser, FIRRemez, RootRaisedCosine, Gaussian:
 		return nil
 	default:
 		return fmt.Errorf("unsupported filter type: %s", ft)
 	}
 }
 
-// Filter interface defines the common operations for all filters in the system
+// Filter interface defines the common operations for all filters in the system.
+//
+// Filters are streaming: Process may be called on consecutive blocks of one
+// signal, and the concatenated output equals the output of processing the
+// whole signal at once. Every call returns one output sample per input sample.
 type Filter interface {
 	Configure(config FilterConfig) error
 	Process(samples []float64) ([]float64, error)
 	GetStats() FilterStats
 	GetConfig() FilterConfig
+
+	// Reset clears the filter state so the next block starts a new signal
+	Reset()
+	// Latency returns the delay of the output relative to the input in
+	// samples (the group delay in the passband for IIR filters)
+	Latency() float64
+}
+
+// Flusher is implemented by filters whose output lines up with the input
+// once the first Latency() output samples are dropped: linear-phase FIR
+// filters of odd length and the median filter. At the end of a signal,
+// Flush returns the outputs still owed for its last Latency() samples.
+type Flusher interface {
+	Flush() []float64
 }
 
 type FilterConfig struct {
 	Type       FilterType `json:"type"`
 	WindowSize int       `json:"window_size,omitempty"`
 	CutoffFreq float64   `json:"cutoff_freq,omitempty"`
+	// ResponseType selects low-, high-, band-pass or band-stop. Low- and
+	// high-pass use CutoffFreq; band-pass and band-stop use LowFreq and HighFreq.
+	ResponseType ResponseType `json:"response_type,omitempty"`
+	LowFreq      float64      `json:"low_freq,omitempty"`
+	HighFreq     float64      `json:"high_freq,omitempty"`
+	// Ripple is the passband ripple in dB (Chebyshev I, elliptic) and
+	// Attenuation the minimum stopband attenuation in dB (Chebyshev II,
+	// elliptic)
+	Ripple      float64 `json:"ripple,omitempty"`
+	Attenuation float64 `json:"attenuation,omitempty"`
+	// FIR designs use either Bands, or the response type's edges with a
+	// transition band of TransitionWidth Hz centred on each edge. Taps
+	// fixes the filter length; zero estimates it from the specification.
+	Taps            int     `json:"taps,omitempty"`
+	TransitionWidth float64 `json:"transition_width,omitempty"`
+	Bands           []Band  `json:"bands,omitempty"`
+	// Pulse-shaping filters span SymbolSpan symbols of SamplesPerSymbol
+	// samples, with roll-off RollOff (root-raised-cosine) or
+	// bandwidth-time product BT (Gaussian)
+	SymbolSpan       int     `json:"symbol_span,omitempty"`
+	SamplesPerSymbol int     `json:"samples_per_symbol,omitempty"`
+	RollOff          float64 `json:"roll_off,omitempty"`
+	BT               float64 `json:"bt,omitempty"`
 	Order      int       `json:"order,omitempty"`
 	SampleRate float64   `json:"sample_rate,omitempty"`
 	Amplitude  float64   `json:"amplitude,omitempty"`
@@ -70,16 +172,63 @@ func (c FilterConfig) Validate() error {
 		if c.WindowSize <= 0 {
 			return fmt.Errorf("window size must be positive")
 		}
-	case Butterworth:
+	case Butterworth, ChebyshevI, ChebyshevII, Elliptic:
 		if c.Order <= 0 {
 			return fmt.Errorf("filter order must be positive")
 		}
-		if c.CutoffFreq <= 0 {
-			return fmt.Errorf("cutoff frequency must be positive")
+		if err := c.validateEdges(); err != nil {
+			return err
 		}
 		if c.SampleRate <= 0 {
 			return fmt.Errorf("sample rate must be positive")
 		}
+		if (c.Type == ChebyshevI || c.Type == Elliptic) && c.Ripple <= 0 {
+			return fmt.Errorf("passband ripple must be positive")
+		}
+		if (c.Type == ChebyshevII || c.Type == Elliptic) && c.Attenuation <= 0 {
+			return fmt.Errorf("stopband attenuation must be positive")
+		}
+		if c.Type == Elliptic && c.Attenuation <= c.Ripple {
+			return fmt.Errorf("stopband attenuation must exceed the passband ripple")
+		}
+	case FIRKaiser, FIRRemez:
+		if c.SampleRate <= 0 {
+			return fmt.Errorf("sample rate must be positive")
+		}
+		if c.Taps < 0 {
+			return fmt.Errorf("number of taps must not be negative")
+		}
+		if len(c.Bands) > 0 {
+			if err := validateBands(c.Bands, c.SampleRate); err != nil {
+				return err
+			}
+		} else {
+			if err := c.validateEdges(); err != nil {
+				return err
+			}
+			if c.TransitionWidth <= 0 {
+				return fmt.Errorf("transition width must be positive")
+			}
+		}
+		if c.Type == FIRKaiser && c.Attenuation <= 0 {
+			return fmt.Errorf("stopband attenuation must be positive")
+		}
+		if c.Type == FIRRemez && c.Taps == 0 && (c.Ripple <= 0 || c.Attenuation <= 0) {
+			return fmt.Errorf("passband ripple and stopband attenuation are needed to estimate the number of taps")
+		}
+	case RootRaisedCosine, Gaussian:
+		if c.SampleRate <= 0 {
+			return fmt.Errorf("sample rate must be positive")
+		}
+		if err := validatePulse(c.SymbolSpan, c.SamplesPerSymbol); err != nil {
+			return err
+		}
+		if c.Type == RootRaisedCosine && (c.RollOff < 0 || c.RollOff > 1) {
+			return fmt.Errorf("roll-off must be between 0 and 1")
+		}
+		if c.Type == Gaussian && c.BT <= 0 {
+			return fmt.Errorf("bandwidth-time product must be positive")
+		}
 	}
 	return nil
 }
@@ -0,0 +1,187 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// zpk holds the zeros and poles of a transfer function. IIR designs start
+// from a normalized analog low-pass prototype (cutoff 1 rad/s), are
+// transformed to the requested response in the s-plane, and are mapped to
+// the z-plane with the bilinear transform. The gain is not tracked: it is
+// set by normalizing the final sections in the passband.
+type zpk struct {
+	zeros []complex128
+	poles []complex128
+}
+
+// degree returns the number of poles in excess of zeros
+func (s zpk) degree() int {
+	return len(s.poles) - len(s.zeros)
+}
+
+// lowpass moves the cutoff of a prototype to w rad/s
+func (s zpk) lowpass(w float64) zpk {
+	var out zpk
+	for _, z := range s.zeros {
+		out.zeros = append(out.zeros, z*complex(w, 0))
+	}
+	for _, p := range s.poles {
+		out.poles = append(out.poles, p*complex(w, 0))
+	}
+	return out
+}
+
+// highpass maps a prototype to a high-pass with cutoff w rad/s (s -> w/s).
+// The excess poles become zeros at the origin.
+func (s zpk) highpass(w float64) zpk {
+	var out zpk
+	for _, z := range s.zeros {
+		out.zeros = append(out.zeros, complex(w, 0)/z)
+	}
+	for _, p := range s.poles {
+		out.poles = append(out.poles, complex(w, 0)/p)
+	}
+	for range s.degree() {
+		out.zeros = append(out.zeros, 0)
+	}
+	return out
+}
+
+// bandpass maps a prototype to a band-pass centred on w0 rad/s with
+// bandwidth bw rad/s (s -> (s² + w0²)/(bw·s)). Each root splits in two.
+func (s zpk) bandpass(w0, bw float64) zpk {
+	var out zpk
+	out.zeros = splitRoots(s.zeros, bw/2, w0)
+	out.poles = splitRoots(s.poles, bw/2, w0)
+	for range s.degree() {
+		out.zeros = append(out.zeros, 0)
+	}
+	return out
+}
+
+// bandstop maps a prototype to a band-stop centred on w0 rad/s with
+// bandwidth bw rad/s (s -> bw·s/(s² + w0²)). The excess poles become zeros
+// at ±j·w0.
+func (s zpk) bandstop(w0, bw float64) zpk {
+	var out zpk
+	inverse := func(roots []complex128) []complex128 {
+		inv := make([]complex128, len(roots))
+		for i, r := range roots {
+			inv[i] = 1 / r
+		}
+		return inv
+	}
+	out.zeros = splitRoots(inverse(s.zeros), bw/2, w0)
+	out.poles = splitRoots(inverse(s.poles), bw/2, w0)
+	for range s.degree() {
+		out.zeros = append(out.zeros, complex(0, w0), complex(0, -w0))
+	}
+	return out
+}
+
+// splitRoots returns the two roots r·scale ± sqrt((r·scale)² - w0²) of each root r
+func splitRoots(roots []complex128, scale, w0 float64) []complex128 {
+	var out []complex128
+	for _, r := range roots {
+		r *= complex(scale, 0)
+		d := cmplx.Sqrt(r*r - complex(w0*w0, 0))
+		out = append(out, r+d, r-d)
+	}
+	return out
+}
+
+// bilinear maps an analog transfer function to the z-plane at the given
+// sample rate. Zeros at infinity map to Nyquist (z = -1).
+func (s zpk) bilinear(sampleRate float64) zpk {
+	fs2 := complex(2*sampleRate, 0)
+	var out zpk
+	for _, z := range s.zeros {
+		out.zeros = append(out.zeros, (fs2+z)/(fs2-z))
+	}
+	for _, p := range s.poles {
+		out.poles = append(out.poles, (fs2+p)/(fs2-p))
+	}
+	for range s.degree() {
+		out.zeros = append(out.zeros, -1)
+	}
+	return out
+}
+
+// prewarp returns the analog frequency in rad/s that the bilinear transform
+// maps to freq Hz
+func prewarp(freq, sampleRate float64) float64 {
+	return 2 * sampleRate * math.Tan(math.Pi*freq/sampleRate)
+}
+
+// transform maps a normalized analog low-pass prototype to the response and
+// band edges of config and returns the digital design together with the
+// passband frequency in Hz at which its gain is normalized to one
+func transform(prototype zpk, config FilterConfig) (zpk, float64, error) {
+	fs := config.SampleRate
+	nyquist := fs / 2
+
+	var analog zpk
+	var reference float64
+	switch config.ResponseType.orDefault() {
+	case LowPass, HighPass:
+		if config.CutoffFreq >= nyquist {
+			return zpk{}, 0, fmt.Errorf("cutoff frequency (%f Hz) must be less than Nyquist frequency (%f Hz)",
+				config.CutoffFreq, nyquist)
+		}
+		w := prewarp(config.CutoffFreq, fs)
+		if config.ResponseType == HighPass {
+			analog, reference = prototype.highpass(w), nyquist
+		} else {
+			analog, reference = prototype.lowpass(w), 0
+		}
+
+	case BandPass, BandStop:
+		if config.HighFreq >= nyquist {
+			return zpk{}, 0, fmt.Errorf("high edge frequency (%f Hz) must be less than Nyquist frequency (%f Hz)",
+				config.HighFreq, nyquist)
+		}
+		w1, w2 := prewarp(config.LowFreq, fs), prewarp(config.HighFreq, fs)
+		w0, bw := math.Sqrt(w1*w2), w2-w1
+		if config.ResponseType == BandPass {
+			// The geometric centre of the analog band
+			analog, reference = prototype.bandpass(w0, bw), fs/math.Pi*math.Atan(w0/(2*fs))
+		} else {
+			analog, reference = prototype.bandstop(w0, bw), 0
+		}
+	}
+
+	digital := analog.bilinear(fs)
+	for _, p := range digital.poles {
+		if cmplx.Abs(p) > MaxPoleRadius {
+			return zpk{}, 0, fmt.Errorf("unstable filter: pole magnitude %.3f exceeds maximum allowed %.3f",
+				cmplx.Abs(p), MaxPoleRadius)
+		}
+	}
+	return digital, reference, nil
+}
+
+// evaluate returns the polynomial in z⁻¹ with coefficients c at freq Hz
+func evaluate(c []float64, freq, sampleRate float64) complex128 {
+	zInv := cmplx.Rect(1, -2*math.Pi*freq/sampleRate)
+	var sum complex128
+	for n := len(c) - 1; n >= 0; n-- {
+		sum = sum*zInv + complex(c[n], 0)
+	}
+	return sum
+}
+
+// groupDelay returns the group delay in samples of the polynomial in z⁻¹
+// with coefficients c at freq Hz, Re(Σ n·c[n]·z⁻ⁿ / Σ c[n]·z⁻ⁿ)
+func groupDelay(c []float64, freq, sampleRate float64) float64 {
+	weighted := make([]float64, len(c))
+	for n, v := range c {
+		weighted[n] = float64(n) * v
+	}
+	den := evaluate(c, freq, sampleRate)
+	if cmplx.Abs(den) == 0 {
+		return 0
+	}
+	return real(evaluate(weighted, freq, sampleRate) / den)
+}
@@ -0,0 +1,114 @@
+// Package hilbert converts real signals to analytic (complex) signals,
+// whose imaginary part is the Hilbert transform of the real part, either
+// block by block with an FIR Hilbert transformer or for a whole signal by
+// FFT
+package hilbert
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vivirinter/sdr-parser/pkg/fft"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+// DefaultTaps is the length of the FIR Hilbert transformer made by
+// NewTransformer
+const DefaultTaps = 65
+
+// Taps returns a Hamming-windowed FIR Hilbert transformer of n taps, which
+// must be odd. The ideal response 2/(πk) for odd k is truncated, so the
+// transformer is accurate away from DC and Nyquist.
+func Taps(n int) ([]float64, error) {
+	if n < 3 || n%2 == 0 {
+		return nil, fmt.Errorf("Hilbert transformer length must be odd and at least 3, got %d", n)
+	}
+	half := n / 2
+	taps := make([]float64, n)
+	for i := range taps {
+		k := i - half
+		if k%2 == 0 {
+			continue
+		}
+		window := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		taps[i] = 2 / (math.Pi * float64(k)) * window
+	}
+	return taps, nil
+}
+
+// Transformer converts a real signal to its analytic signal block by
+// block. The FIR transformer is causal, so the output lags the input by
+// half its length; the real part is delayed to match.
+type Transformer struct {
+	fir   *filter.FIR
+	delay []float64 // The last Latency() inputs, oldest first
+}
+
+// NewTransformer creates a transformer of DefaultTaps taps
+func NewTransformer() *Transformer {
+	t, _ := NewTransformerTaps(DefaultTaps)
+	return t
+}
+
+// NewTransformerTaps creates a transformer of n taps, which must be odd
+func NewTransformerTaps(n int) (*Transformer, error) {
+	taps, err := Taps(n)
+	if err != nil {
+		return nil, err
+	}
+	fir, err := filter.NewFIR(taps)
+	if err != nil {
+		return nil, err
+	}
+	return &Transformer{fir: fir, delay: make([]float64, n/2)}, nil
+}
+
+// Process returns the analytic signal of a block of samples
+func (t *Transformer) Process(samples []float64) []complex128 {
+	quadrature := t.fir.Process(samples)
+	inPhase := append(t.delay, samples...)
+
+	result := make([]complex128, len(samples))
+	for i, q := range quadrature {
+		result[i] = complex(inPhase[i], q)
+	}
+	t.delay = append(t.delay[:0:0], inPhase[len(inPhase)-len(t.delay):]...)
+	return result
+}
+
+// Reset clears the filter state
+func (t *Transformer) Reset() {
+	t.fir.Reset()
+	clear(t.delay)
+}
+
+// Latency returns the delay of the output in samples
+func (t *Transformer) Latency() float64 {
+	return float64(len(t.delay))
+}
+
+// Analytic returns the analytic signal of a whole real signal: its
+// spectrum with the negative frequencies removed and the positive ones
+// doubled. The real part equals the input and there is no delay, but the
+// transform treats the signal as periodic, so its ends may ring.
+func Analytic(samples []float64) []complex128 {
+	n := len(samples)
+	if n == 0 {
+		return []complex128{}
+	}
+
+	x := make([]complex128, n)
+	for i, v := range samples {
+		x[i] = complex(v, 0)
+	}
+	spectrum := fft.FFT(x)
+	for k := 1; k < n; k++ {
+		switch {
+		case 2*k < n:
+			spectrum[k] *= 2
+		case 2*k > n:
+			spectrum[k] = 0
+		}
+	}
+	return fft.IFFT(spectrum)
+}
@@ -0,0 +1,42 @@
+package mixer
+
+// Mixer shifts a complex signal in frequency by multiplying it with an NCO.
+// A positive shift moves the spectrum up; tuning a station at offset Hz to
+// DC takes a shift of -offset. Mixer is streaming: the oscillator phase
+// carries over between calls to Process.
+type Mixer struct {
+	nco *NCO
+}
+
+// NewMixer creates a mixer shifting by shift Hz
+func NewMixer(shift, sampleRate float64) (*Mixer, error) {
+	nco, err := NewNCO(shift, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Mixer{nco: nco}, nil
+}
+
+// SetShift changes the shift without a phase jump
+func (m *Mixer) SetShift(shift float64) {
+	m.nco.SetFrequency(shift)
+}
+
+// Shift returns the frequency shift in Hz
+func (m *Mixer) Shift() float64 {
+	return m.nco.Frequency()
+}
+
+// Process shifts a block of samples into a new slice
+func (m *Mixer) Process(samples []complex128) []complex128 {
+	result := make([]complex128, len(samples))
+	for i, v := range samples {
+		result[i] = v * m.nco.Next()
+	}
+	return result
+}
+
+// Reset returns the oscillator to zero phase
+func (m *Mixer) Reset() {
+	m.nco.Reset()
+}
@@ -0,0 +1,79 @@
+// Package mixer shifts complex baseband signals in frequency with a
+// numerically controlled oscillator
+package mixer
+
+import (
+	"fmt"
+	"math"
+)
+
+// phaseScale converts the phase accumulator to radians: a full turn is 2^64
+const phaseScale = 2 * math.Pi / (1 << 64)
+
+// NCO is a numerically controlled oscillator producing exp(jφ) for a phase
+// φ that advances by 2π·freq/sampleRate per sample. The phase is a 64-bit
+// fixed-point accumulator, so it wraps exactly and never loses precision
+// over long runs, and frequency changes keep it continuous.
+type NCO struct {
+	sampleRate float64
+	freq       float64
+	phase      uint64 // Current phase, 2^64 per turn
+	step       uint64 // Phase increment per sample
+}
+
+// NewNCO creates an oscillator at freq Hz, which may be negative, starting
+// at zero phase
+func NewNCO(freq, sampleRate float64) (*NCO, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate must be positive")
+	}
+	n := &NCO{sampleRate: sampleRate}
+	n.SetFrequency(freq)
+	return n, nil
+}
+
+// SetFrequency changes the frequency from the next sample on without a
+// phase jump. Frequencies beyond ±sampleRate/2 alias.
+func (n *NCO) SetFrequency(freq float64) {
+	n.freq = freq
+	n.step = fixedTurns(freq / n.sampleRate)
+}
+
+// Frequency returns the oscillator frequency in Hz
+func (n *NCO) Frequency() float64 {
+	return n.freq
+}
+
+// Phase returns the phase of the next sample in radians, in [0, 2π)
+func (n *NCO) Phase() float64 {
+	return float64(n.phase) * phaseScale
+}
+
+// SetPhase sets the phase of the next sample in radians
+func (n *NCO) SetPhase(phase float64) {
+	n.phase = fixedTurns(phase / (2 * math.Pi))
+}
+
+// fixedTurns converts a phase in turns to the fixed-point accumulator,
+// wrapping it into [0, 1). Tiny negative phases round up to a full turn,
+// which is zero.
+func fixedTurns(turns float64) uint64 {
+	turns -= math.Floor(turns)
+	fixed := math.Ldexp(turns, 64)
+	if fixed >= math.Ldexp(1, 64) {
+		return 0
+	}
+	return uint64(fixed)
+}
+
+// Next returns exp(jφ) and advances the phase by one sample
+func (n *NCO) Next() complex128 {
+	sin, cos := math.Sincos(float64(n.phase) * phaseScale)
+	n.phase += n.step
+	return complex(cos, sin)
+}
+
+// Reset returns the phase to zero
+func (n *NCO) Reset() {
+	n.phase = 0
+}
@@ -0,0 +1,125 @@
+// Package rawiq reads and writes headerless interleaved I/Q capture files
+// as produced by rtl_sdr, hackrf_transfer, Airspy/SDRplay tools and
+// GNU Radio file sinks.
+package rawiq
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SampleFormat identifies the encoding of one interleaved I/Q sample pair
+type SampleFormat string
+
+const (
+	CU8  SampleFormat = "cu8"  // Unsigned 8-bit, offset binary (rtl_sdr)
+	CS8  SampleFormat = "cs8"  // Signed 8-bit (HackRF)
+	CS16 SampleFormat = "cs16" // Signed 16-bit (Airspy, SDRplay)
+	CF32 SampleFormat = "cf32" // IEEE 754 32-bit float (GNU Radio complex)
+)
+
+func (f SampleFormat) String() string {
+	return string(f)
+}
+
+// Validate checks that the format is one of the supported encodings
+func (f SampleFormat) Validate() error {
+	switch f {
+	case CU8, CS8, CS16, CF32:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sample format: %s", f)
+	}
+}
+
+// ComponentSize returns the size in bytes of a single I or Q component
+func (f SampleFormat) ComponentSize() int {
+	switch f {
+	case CU8, CS8:
+		return 1
+	case CS16:
+		return 2
+	case CF32:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// SampleSize returns the size in bytes of one complex I/Q sample
+func (f SampleFormat) SampleSize() int {
+	return 2 * f.ComponentSize()
+}
+
+// Config describes how a raw capture is encoded
+type Config struct {
+	Format     SampleFormat
+	SampleRate float64
+	ByteOrder  binary.ByteOrder // Defaults to little-endian when nil
+}
+
+// Validate checks the configuration for consistency
+func (c Config) Validate() error {
+	if err := c.Format.Validate(); err != nil {
+		return err
+	}
+	if c.SampleRate <= 0 {
+		return fmt.Errorf("sample rate must be positive")
+	}
+	return nil
+}
+
+func (c Config) byteOrder() binary.ByteOrder {
+	if c.ByteOrder == nil {
+		return binary.LittleEndian
+	}
+	return c.ByteOrder
+}
+
+// ParseByteOrder converts "le"/"little" or "be"/"big" to a binary.ByteOrder
+func ParseByteOrder(name string) (binary.ByteOrder, error) {
+	switch strings.ToLower(name) {
+	case "", "le", "little":
+		return binary.LittleEndian, nil
+	case "be", "big":
+		return binary.BigEndian, nil
+	default:
+		return nil, fmt.Errorf("unknown byte order: %s", name)
+	}
+}
+
+// decodeComponent converts one encoded I or Q component to the range [-1, 1]
+func decodeComponent(format SampleFormat, order binary.ByteOrder, b []byte) float64 {
+	switch format {
+	case CU8:
+		return (float64(b[0]) - 127.5) / 127.5
+	case CS8:
+		return float64(int8(b[0])) / 128.0
+	case CS16:
+		return float64(int16(order.Uint16(b))) / 32768.0
+	case CF32:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	default:
+		return 0
+	}
+}
+
+// encodeComponent writes one I or Q component, clipping integer formats to their range
+func encodeComponent(format SampleFormat, order binary.ByteOrder, b []byte, v float64) {
+	switch format {
+	case CU8:
+		b[0] = uint8(clamp(math.Round(v*127.5+127.5), 0, 255))
+	case CS8:
+		b[0] = uint8(int8(clamp(math.Round(v*128.0), -128, 127)))
+	case CS16:
+		order.PutUint16(b, uint16(int16(clamp(math.Round(v*32768.0), -32768, 32767))))
+	case CF32:
+		order.PutUint32(b, math.Float32bits(float32(v)))
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,115 @@
+package rawiq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader decodes interleaved I/Q samples from a raw capture
+type Reader struct {
+	r      io.Reader
+	closer io.Closer
+	config Config
+	buf    []byte
+}
+
+// NewReader creates a reader decoding samples from r
+func NewReader(r io.Reader, config Config) (*Reader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &Reader{r: r, config: config}, nil
+}
+
+// Open opens a raw capture file for reading
+func Open(filename string, config Config) (*Reader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open I/Q file: %w", err)
+	}
+
+	reader, err := NewReader(file, config)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.closer = file
+	return reader, nil
+}
+
+// Config returns the reader's configuration
+func (r *Reader) Config() Config {
+	return r.config
+}
+
+// ReadBlock decodes up to len(buf) samples into buf and returns the number
+// of samples read. It returns io.EOF once no complete sample remains.
+func (r *Reader) ReadBlock(buf []complex128) (int, error) {
+	sampleSize := r.config.Format.SampleSize()
+	if need := len(buf) * sampleSize; cap(r.buf) < need {
+		r.buf = make([]byte, need)
+	}
+	raw := r.buf[:len(buf)*sampleSize]
+
+	n, err := io.ReadFull(r.r, raw)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// A trailing partial sample is dropped
+		err = nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	count := n / sampleSize
+	if count == 0 {
+		return 0, io.EOF
+	}
+
+	size := r.config.Format.ComponentSize()
+	order := r.config.byteOrder()
+	for i := 0; i < count; i++ {
+		b := raw[i*sampleSize:]
+		buf[i] = complex(
+			decodeComponent(r.config.Format, order, b[:size]),
+			decodeComponent(r.config.Format, order, b[size:2*size]),
+		)
+	}
+	return count, nil
+}
+
+// ReadAll decodes all remaining samples
+func (r *Reader) ReadAll() ([]complex128, error) {
+	var samples []complex128
+	block := make([]complex128, 65536)
+	for {
+		n, err := r.ReadBlock(block)
+		samples = append(samples, block[:n]...)
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close closes the underlying file if the reader was created with Open
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ReadFile is a convenience function to read all samples from a raw capture
+func ReadFile(filename string, config Config) ([]complex128, error) {
+	reader, err := Open(filename, config)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return reader.ReadAll()
+}
@@ -0,0 +1,91 @@
+package rawiq
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Writer encodes complex samples as interleaved I/Q
+type Writer struct {
+	w      *bufio.Writer
+	closer io.Closer
+	config Config
+	buf    []byte
+}
+
+// NewWriter creates a writer encoding samples to w
+func NewWriter(w io.Writer, config Config) (*Writer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &Writer{w: bufio.NewWriter(w), config: config}, nil
+}
+
+// Create creates a raw capture file for writing
+func Create(filename string, config Config) (*Writer, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create I/Q file: %w", err)
+	}
+
+	writer, err := NewWriter(file, config)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	writer.closer = file
+	return writer, nil
+}
+
+// WriteBlock encodes and writes samples
+func (w *Writer) WriteBlock(samples []complex128) error {
+	sampleSize := w.config.Format.SampleSize()
+	if need := len(samples) * sampleSize; cap(w.buf) < need {
+		w.buf = make([]byte, need)
+	}
+	raw := w.buf[:len(samples)*sampleSize]
+
+	size := w.config.Format.ComponentSize()
+	order := w.config.byteOrder()
+	for i, sample := range samples {
+		b := raw[i*sampleSize:]
+		encodeComponent(w.config.Format, order, b[:size], real(sample))
+		encodeComponent(w.config.Format, order, b[size:2*size], imag(sample))
+	}
+
+	if _, err := w.w.Write(raw); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered samples and closes the underlying file if the
+// writer was created with Create
+func (w *Writer) Close() error {
+	if err := w.w.Flush(); err != nil {
+		if w.closer != nil {
+			w.closer.Close()
+		}
+		return fmt.Errorf("failed to flush samples: %w", err)
+	}
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
+
+// WriteFile writes samples to a raw capture file
+func WriteFile(filename string, samples []complex128, config Config) error {
+	writer, err := Create(filename, config)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteBlock(samples); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,280 @@
+package rds
+
+import "math/bits"
+
+// Offset identifies the position of a block in a group by the offset word
+// added to its checkword
+type Offset int
+
+// Block offsets; C' replaces C in version B groups, whose third block
+// repeats the PI code
+const (
+	OffsetA Offset = iota
+	OffsetB
+	OffsetC
+	OffsetCPrime
+	OffsetD
+)
+
+// offsetWords are the 10-bit offset words of EN 50067 Annex A
+var offsetWords = [...]uint16{
+	OffsetA:      0x0FC,
+	OffsetB:      0x198,
+	OffsetC:      0x168,
+	OffsetCPrime: 0x350,
+	OffsetD:      0x1B4,
+}
+
+const (
+	blockBits = 26
+	blockMask = 1<<blockBits - 1
+	// generator is the checkword polynomial x^10+x^8+x^7+x^5+x^4+x^3+1
+	generator = 0x5B9
+	// maxBurst is the longest error burst corrected
+	maxBurst = 5
+	// syncWindow is the number of recent blocks checked for sync loss,
+	// and maxBadBlocks how many of them may be uncorrectable
+	syncWindow   = 50
+	maxBadBlocks = 25
+)
+
+// syndrome returns the remainder of a 26-bit block divided by the
+// generator. For a valid block it is the block's offset word.
+func syndrome(block uint32) uint16 {
+	for i := blockBits - 1; i >= 10; i-- {
+		if block&(1<<i) != 0 {
+			block ^= generator << (i - 10)
+		}
+	}
+	return uint16(block)
+}
+
+// EncodeBlock returns the 26-bit block carrying data with the checkword
+// for the given offset, most significant bit first on air
+func EncodeBlock(data uint16, offset Offset) uint32 {
+	block := uint32(data) << 10
+	return block | uint32(syndrome(block)^offsetWords[offset])
+}
+
+// corrections maps the syndrome of every burst error of up to maxBurst bits
+// to the error pattern. Syndromes shared by two patterns are left out, so
+// they are reported as uncorrectable instead of miscorrected.
+var corrections = func() map[uint16]uint32 {
+	table := make(map[uint16]uint32)
+	ambiguous := make(map[uint16]bool)
+	for length := 1; length <= maxBurst; length++ {
+		// Bursts start and end with an error; the bits between are free
+		for inner := uint32(0); inner < 1<<max(length-2, 0); inner++ {
+			pattern := uint32(1)
+			if length > 1 {
+				pattern = 1<<(length-1) | inner<<1 | 1
+			}
+			for shift := 0; shift+length <= blockBits; shift++ {
+				e := pattern << shift
+				s := syndrome(e)
+				if other, ok := table[s]; ok && other != e {
+					ambiguous[s] = true
+				}
+				table[s] = e
+			}
+		}
+	}
+	for s := range ambiguous {
+		delete(table, s)
+	}
+	return table
+}()
+
+// correct checks a received block against the expected offset and
+// corrects a burst error. It returns the block data and whether the block
+// is valid, and whether it needed correction.
+func correct(block uint32, offset Offset) (data uint16, ok, corrected bool) {
+	s := syndrome(block) ^ offsetWords[offset]
+	if s == 0 {
+		return uint16(block >> 10), true, false
+	}
+	if e, found := corrections[s]; found {
+		return uint16((block ^ e) >> 10), true, true
+	}
+	return 0, false, false
+}
+
+// Group is one RDS group: four blocks A to D of 16 data bits
+type Group struct {
+	Blocks [4]uint16
+	// OK marks blocks received intact or corrected; the data of other
+	// blocks is 0
+	OK [4]bool
+	// CPrime is set when the third block carried offset C' (a version B
+	// group repeating the PI code) rather than C
+	CPrime bool
+	// Corrected counts the blocks that needed error correction
+	Corrected int
+	// Bit is the position in the bit stream of the group's first bit
+	Bit uint64
+}
+
+// Decoder synchronizes a bit stream to RDS blocks and assembles groups.
+// Sync is acquired when two blocks whose offset words are in sequence are
+// found the right number of bits apart, and lost when more than half of
+// the recent blocks are uncorrectable. Decoder is streaming.
+type Decoder struct {
+	register uint32 // Last 26 bits, the newest in the lowest bit
+	count    uint64 // Bits received
+
+	synced   bool
+	next     int    // Index in the group (0-3) of the next block
+	received int    // Bits of the next block received
+	bad      uint64 // Outcome of the last syncWindow blocks, 1 for bad
+	group    Group
+
+	hits []hit // Blocks found in the last two groups while searching
+}
+
+// hit is a block found while searching for sync
+type hit struct {
+	offset Offset
+	bit    uint64 // Bit count at the end of the block
+}
+
+// NewDecoder creates a decoder waiting for sync
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Push appends bits (one per byte, 0 or 1) and returns the groups they
+// complete. Groups are returned once block D is due, even when some of
+// their blocks were not received.
+func (d *Decoder) Push(stream []byte) []Group {
+	var groups []Group
+	for _, bit := range stream {
+		d.register = (d.register<<1 | uint32(bit&1)) & blockMask
+		d.count++
+		if !d.synced {
+			d.search()
+			continue
+		}
+
+		d.received++
+		if d.received < blockBits {
+			continue
+		}
+		d.received = 0
+		d.block(d.next)
+		if d.next == 3 {
+			groups = append(groups, d.group)
+		}
+		d.next = (d.next + 1) % 4
+		if d.next == 0 {
+			d.group = Group{Bit: d.count}
+		}
+		if bits.OnesCount64(d.bad) > maxBadBlocks {
+			d.synced = false
+			d.hits = nil
+		}
+	}
+	return groups
+}
+
+// search looks for a block ending at the newest bit that is in sequence
+// with a block found earlier in the last two groups
+func (d *Decoder) search() {
+	s := syndrome(d.register)
+	for offset, word := range offsetWords {
+		if s != word {
+			continue
+		}
+		found := hit{offset: Offset(offset), bit: d.count}
+		for _, h := range d.hits {
+			if inSequence(h, found) {
+				d.acquire(found.offset)
+				return
+			}
+		}
+
+		// Keep only the hits that later blocks can still pair with
+		kept := d.hits[:0]
+		for _, h := range d.hits {
+			if d.count-h.bit < 8*blockBits {
+				kept = append(kept, h)
+			}
+		}
+		d.hits = append(kept, found)
+		return
+	}
+}
+
+// inSequence reports whether block b is where the offset of block a says
+// it should be, within two groups
+func inSequence(a, b hit) bool {
+	blocks := (index(b.offset) - index(a.offset) + 4) % 4
+	if blocks == 0 {
+		blocks = 4
+	}
+	distance := b.bit - a.bit
+	return distance <= 8*blockBits && distance%(4*blockBits) == uint64(blocks*blockBits)%(4*blockBits)
+}
+
+// acquire starts decoding with the block that has just been found
+func (d *Decoder) acquire(offset Offset) {
+	d.synced = true
+	d.hits = nil
+	d.bad = 0
+	d.received = 0
+	i := index(offset)
+	d.group = Group{Bit: d.count - uint64(blockBits*(i+1))}
+	d.block(i)
+	if i == 3 {
+		// The group is incomplete; start afresh with the next one
+		d.group = Group{Bit: d.count}
+	}
+	d.next = (i + 1) % 4
+}
+
+// block decodes the block in the register as block i of the group
+func (d *Decoder) block(i int) {
+	var data uint16
+	var ok, corrected bool
+	switch i {
+	case 2:
+		if data, ok, corrected = correct(d.register, OffsetC); !ok {
+			data, ok, corrected = correct(d.register, OffsetCPrime)
+			d.group.CPrime = ok
+		}
+	case 3:
+		data, ok, corrected = correct(d.register, OffsetD)
+	default:
+		data, ok, corrected = correct(d.register, Offset(i))
+	}
+
+	d.group.Blocks[i], d.group.OK[i] = data, ok
+	if corrected {
+		d.group.Corrected++
+	}
+	d.bad = (d.bad << 1) & (1<<syncWindow - 1)
+	if !ok {
+		d.bad |= 1
+	}
+}
+
+// Synced reports whether the decoder is synchronized to the blocks
+func (d *Decoder) Synced() bool {
+	return d.synced
+}
+
+// Reset drops sync and any partial group
+func (d *Decoder) Reset() {
+	*d = Decoder{}
+}
+
+// index returns the position in the group of a block with the offset
+func index(offset Offset) int {
+	switch offset {
+	case OffsetCPrime:
+		return 2
+	case OffsetD:
+		return 3
+	default:
+		return int(offset)
+	}
+}
@@ -0,0 +1,172 @@
+// Package rds decodes the Radio Data System (RDS, and RBDS in North
+// America) carried on the 57 kHz subcarrier of broadcast FM: the
+// subcarrier is demodulated to bits, the bits are synchronized to blocks
+// and groups with the offset words and error-corrected, and the groups
+// update the station's programme information.
+package rds
+
+import (
+	"fmt"
+	"math/cmplx"
+
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+	"github.com/Vivirinter/sdr-parser/pkg/mixer"
+	"github.com/Vivirinter/sdr-parser/pkg/resample"
+)
+
+const (
+	// Subcarrier is the RDS subcarrier frequency in Hz, three times the
+	// stereo pilot
+	Subcarrier = 57000.0
+	// BitRate is the RDS data rate in bits per second
+	BitRate = 1187.5
+	// Bandwidth is the one-sided bandwidth of the RDS signal in Hz
+	Bandwidth = 2400.0
+
+	// MinSampleRate is the lowest MPX sample rate holding the RDS band
+	MinSampleRate = 2 * (Subcarrier + Bandwidth)
+
+	// samplesPerBit is the oversampling of the baseband RDS signal
+	samplesPerBit = 16
+	// timingSmooth is the smoothing factor of the per-phase symbol energy
+	// that picks the bit timing, about 64 bits
+	timingSmooth = 1.0 / 64
+)
+
+// Demodulator recovers the RDS bit stream from the MPX output of an FM
+// discriminator (without de-emphasis). The subcarrier is mixed to DC,
+// decimated to 16 samples per bit and low-pass filtered to the RDS band.
+// Each bit is a biphase (Manchester) symbol, so it is detected by
+// correlating with a half-bit step; the bit timing is the correlation
+// phase with the most energy. The data is differentially encoded, which
+// is decoded from the sign of the product of consecutive symbols, so the
+// subcarrier phase need not be recovered. Demodulator is streaming.
+type Demodulator struct {
+	mixer  *mixer.Mixer
+	iConv  resample.Converter
+	qConv  resample.Converter
+	iLP    *filter.FIR
+	qLP    *filter.FIR
+	window [samplesPerBit]complex128 // Last bit of baseband, circular
+	energy [samplesPerBit]float64    // Mean symbol energy per timing phase
+	pos    int                       // Sample index modulo samplesPerBit
+	timing int                       // Timing phase sampled for bits
+	prev   complex128                // Previous symbol
+}
+
+// NewDemodulator creates a demodulator for MPX sampled at sampleRate,
+// which must be at least MinSampleRate
+func NewDemodulator(sampleRate float64) (*Demodulator, error) {
+	if sampleRate < MinSampleRate {
+		return nil, fmt.Errorf("RDS decoding needs an MPX sample rate of at least %g Hz, got %g Hz", MinSampleRate, sampleRate)
+	}
+
+	m, err := mixer.NewMixer(-Subcarrier, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	d := &Demodulator{mixer: m}
+	bitRate := BitRate * samplesPerBit
+	if d.iConv, err = resample.New(sampleRate, bitRate); err != nil {
+		return nil, fmt.Errorf("failed to create decimator: %w", err)
+	}
+	if d.qConv, err = resample.New(sampleRate, bitRate); err != nil {
+		return nil, fmt.Errorf("failed to create decimator: %w", err)
+	}
+
+	bands := []filter.Band{
+		{Low: 0, High: Bandwidth, Gain: 1},
+		{Low: 1.6 * Bandwidth, High: bitRate / 2},
+	}
+	taps, err := filter.DesignKaiser(0, bands, bitRate, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to design RDS filter: %w", err)
+	}
+	if d.iLP, err = filter.NewFIR(taps); err != nil {
+		return nil, err
+	}
+	if d.qLP, err = filter.NewFIR(taps); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Process demodulates a block of MPX samples and returns the bits it
+// completes, one per byte (0 or 1)
+func (d *Demodulator) Process(mpx []float64) []byte {
+	x := make([]complex128, len(mpx))
+	for i, v := range mpx {
+		x[i] = complex(v, 0)
+	}
+	x = d.mixer.Process(x)
+
+	in := make([]float64, len(x))
+	quad := make([]float64, len(x))
+	for i, v := range x {
+		in[i], quad[i] = real(v), imag(v)
+	}
+	in = d.iLP.Process(d.iConv.Process(in))
+	quad = d.qLP.Process(d.qConv.Process(quad))
+
+	var bits []byte
+	for i := range in {
+		d.window[d.pos] = complex(in[i], quad[i])
+
+		// The window holds one bit ending at pos: the first half minus the
+		// second half is the biphase correlation
+		var symbol complex128
+		for k := 1; k <= samplesPerBit; k++ {
+			v := d.window[(d.pos+k)%samplesPerBit]
+			if k <= samplesPerBit/2 {
+				symbol += v
+			} else {
+				symbol -= v
+			}
+		}
+		power := real(symbol)*real(symbol) + imag(symbol)*imag(symbol)
+		d.energy[d.pos] += timingSmooth * (power - d.energy[d.pos])
+
+		if d.pos == d.timing {
+			// A phase reversal between consecutive symbols is a 1
+			if real(symbol*cmplx.Conj(d.prev)) < 0 {
+				bits = append(bits, 1)
+			} else {
+				bits = append(bits, 0)
+			}
+			d.prev = symbol
+			d.timing = d.bestTiming()
+		}
+		d.pos = (d.pos + 1) % samplesPerBit
+	}
+	return bits
+}
+
+// bestTiming returns the timing phase with the most symbol energy
+func (d *Demodulator) bestTiming() int {
+	best := d.timing
+	for p, e := range d.energy {
+		if e > d.energy[best] {
+			best = p
+		}
+	}
+	return best
+}
+
+// Reset clears the demodulator state so the next block starts a new signal
+func (d *Demodulator) Reset() {
+	d.mixer.Reset()
+	d.iConv.Reset()
+	d.qConv.Reset()
+	d.iLP.Reset()
+	d.qLP.Reset()
+	d.window = [samplesPerBit]complex128{}
+	d.energy = [samplesPerBit]float64{}
+	d.pos, d.timing = 0, 0
+	d.prev = 0
+}
+
+// Latency returns the delay of the bits relative to the input in seconds
+func (d *Demodulator) Latency() float64 {
+	bitRate := BitRate * samplesPerBit
+	return (d.iConv.Latency() + d.iLP.Latency() + samplesPerBit) / bitRate
+}
@@ -0,0 +1,218 @@
+package rds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message reports information that changed, as decoded from one group.
+// Only the fields that changed are set besides Time and PI.
+type Message struct {
+	Time      float64 `json:"time"` // Seconds from the start of the bit stream to the group
+	PI        string  `json:"pi"`   // Programme identification code, hexadecimal
+	PTY       *int    `json:"pty,omitempty"`
+	PTYName   string  `json:"pty_name,omitempty"`
+	PS        string  `json:"ps,omitempty"`        // Programme service name
+	RadioText string  `json:"radiotext,omitempty"` // RadioText message
+	ClockTime string  `json:"ct,omitempty"`        // Clock time, RFC 3339 in the station's local offset
+}
+
+// Station accumulates the programme information of one station from its
+// groups: PI, PTY, the 8-character PS name from groups 0A/0B, RadioText
+// from groups 2A/2B and clock time from group 4A. A new PI code starts a
+// new station.
+type Station struct {
+	// RBDS names programme types with the North American table
+	RBDS bool
+
+	pi     uint16
+	havePI bool
+	pty    int
+
+	ps     [8]byte
+	psSeen uint8 // Segments of ps received, one bit each
+	lastPS string
+
+	rt     [64]byte
+	rtSeen uint16 // Segments of rt received, one bit each
+	rtFlag int    // Text A/B flag, -1 before the first RadioText group
+	rtEnd  int    // Length of the text up to its end marker, -1 if not seen
+	lastRT string
+}
+
+// NewStation creates an empty station
+func NewStation(rbds bool) *Station {
+	s := &Station{RBDS: rbds}
+	s.clear()
+	return s
+}
+
+// clear forgets everything decoded for the station
+func (s *Station) clear() {
+	s.havePI = false
+	s.pty = -1
+	s.ps = [8]byte{}
+	s.psSeen = 0
+	s.lastPS = ""
+	s.clearText(-1)
+	s.lastRT = ""
+}
+
+// clearText starts a new RadioText message
+func (s *Station) clearText(flag int) {
+	for i := range s.rt {
+		s.rt[i] = ' '
+	}
+	s.rtSeen = 0
+	s.rtFlag = flag
+	s.rtEnd = -1
+}
+
+// Update applies a group and returns the messages for what it changed
+func (s *Station) Update(g Group) []Message {
+	at := float64(g.Bit) / BitRate
+
+	var pi uint16
+	switch {
+	case g.OK[0]:
+		pi = g.Blocks[0]
+	case g.CPrime && g.OK[2]:
+		pi = g.Blocks[2]
+	default:
+		return nil
+	}
+
+	var messages []Message
+	if !s.havePI || pi != s.pi {
+		s.clear()
+		s.pi, s.havePI = pi, true
+		messages = append(messages, s.message(at))
+	}
+	if !g.OK[1] {
+		return messages
+	}
+
+	b := g.Blocks[1]
+	if pty := int(b>>5) & 0x1F; pty != s.pty {
+		s.pty = pty
+		m := s.message(at)
+		m.PTY = &pty
+		m.PTYName = PTYName(pty, s.RBDS)
+		messages = append(messages, m)
+	}
+
+	groupType := b >> 12
+	versionB := b&0x800 != 0
+	switch {
+	case groupType == 0 && g.OK[3]:
+		if ps, ok := s.updatePS(int(b&3), g.Blocks[3]); ok {
+			m := s.message(at)
+			m.PS = ps
+			messages = append(messages, m)
+		}
+	case groupType == 2:
+		if text, ok := s.updateText(b, g, versionB); ok {
+			m := s.message(at)
+			m.RadioText = text
+			messages = append(messages, m)
+		}
+	case groupType == 4 && !versionB && g.OK[2] && g.OK[3]:
+		if ct, ok := clockTime(b, g.Blocks[2], g.Blocks[3]); ok {
+			m := s.message(at)
+			m.ClockTime = ct.Format(time.RFC3339)
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// message returns a message carrying only the time and PI
+func (s *Station) message(at float64) Message {
+	return Message{Time: at, PI: fmt.Sprintf("%04X", s.pi)}
+}
+
+// updatePS stores two PS characters and returns the name once all four
+// segments have arrived and it differs from the last one reported
+func (s *Station) updatePS(segment int, chars uint16) (string, bool) {
+	s.ps[2*segment] = byte(chars >> 8)
+	s.ps[2*segment+1] = byte(chars)
+	s.psSeen |= 1 << segment
+	if s.psSeen != 0xF {
+		return "", false
+	}
+	s.psSeen = 0
+
+	ps := decodeText(s.ps[:])
+	if ps == s.lastPS {
+		return "", false
+	}
+	s.lastPS = ps
+	return ps, true
+}
+
+// updateText stores the characters of a RadioText group and returns the
+// text once every segment up to its end has arrived and it differs from
+// the last one reported. Version A groups carry four characters of a
+// 64-character text, version B groups two of a 32-character text.
+func (s *Station) updateText(b uint16, g Group, versionB bool) (string, bool) {
+	flag := int(b>>4) & 1
+	if flag != s.rtFlag {
+		s.clearText(flag)
+	}
+
+	segment := int(b & 0xF)
+	var chars []byte
+	switch {
+	case !versionB && g.OK[2] && g.OK[3]:
+		chars = []byte{byte(g.Blocks[2] >> 8), byte(g.Blocks[2]), byte(g.Blocks[3] >> 8), byte(g.Blocks[3])}
+	case versionB && g.OK[3]:
+		chars = []byte{byte(g.Blocks[3] >> 8), byte(g.Blocks[3])}
+	default:
+		return "", false
+	}
+
+	length := 16 * len(chars)
+	start := segment * len(chars)
+	for i, c := range chars {
+		if c == '\r' && s.rtEnd < 0 {
+			s.rtEnd = start + i
+		}
+		s.rt[start+i] = c
+	}
+	s.rtSeen |= 1 << segment
+
+	end := length
+	if s.rtEnd >= 0 {
+		end = s.rtEnd
+	}
+	segments := (end + len(chars) - 1) / len(chars)
+	if end == 0 || s.rtSeen&(1<<segments-1) != 1<<segments-1 {
+		return "", false
+	}
+
+	text := strings.TrimRight(decodeText(s.rt[:end]), " ")
+	if text == s.lastRT {
+		return "", false
+	}
+	s.lastRT = text
+	return text, true
+}
+
+// clockTime decodes group 4A: the Modified Julian Day, UTC hour and minute
+// and the local time offset in half hours
+func clockTime(b, c, d uint16) (time.Time, bool) {
+	mjd := int(b&3)<<15 | int(c>>1)
+	hour := int(c&1)<<4 | int(d>>12)
+	minute := int(d>>6) & 0x3F
+	offset := int(d&0x1F) * 30 * 60
+	if d&0x20 != 0 {
+		offset = -offset
+	}
+	if mjd == 0 || hour > 23 || minute > 59 || offset > 14*3600 || offset < -12*3600 {
+		return time.Time{}, false
+	}
+
+	utc := time.Date(1858, 11, 17, hour, minute, 0, 0, time.UTC).AddDate(0, 0, mjd)
+	return utc.In(time.FixedZone("", offset)), true
+}
@@ -0,0 +1,71 @@
+package rds
+
+import "strings"
+
+// ptyNames are the programme type names of RDS (EN 50067)
+var ptyNames = [32]string{
+	"None", "News", "Current Affairs", "Information", "Sport", "Education",
+	"Drama", "Culture", "Science", "Varied", "Pop Music", "Rock Music",
+	"Easy Listening", "Light Classical", "Serious Classical", "Other Music",
+	"Weather", "Finance", "Children's Programmes", "Social Affairs",
+	"Religion", "Phone-in", "Travel", "Leisure", "Jazz Music",
+	"Country Music", "National Music", "Oldies Music", "Folk Music",
+	"Documentary", "Alarm Test", "Alarm",
+}
+
+// rbdsPTYNames are the programme type names of RBDS (NRSC-4-B)
+var rbdsPTYNames = [32]string{
+	"None", "News", "Information", "Sports", "Talk", "Rock", "Classic Rock",
+	"Adult Hits", "Soft Rock", "Top 40", "Country", "Oldies", "Soft",
+	"Nostalgia", "Jazz", "Classical", "Rhythm and Blues",
+	"Soft Rhythm and Blues", "Language", "Religious Music", "Religious Talk",
+	"Personality", "Public", "College", "Spanish Talk", "Spanish Music",
+	"Hip Hop", "Unassigned", "Unassigned", "Weather", "Emergency Test",
+	"Emergency",
+}
+
+// PTYName returns the name of a programme type code, from the RBDS table
+// when rbds is set
+func PTYName(pty int, rbds bool) string {
+	if pty < 0 || pty >= len(ptyNames) {
+		return ""
+	}
+	if rbds {
+		return rbdsPTYNames[pty]
+	}
+	return ptyNames[pty]
+}
+
+// latin holds the characters of the RDS basic character set (EN 50067
+// Annex E) from 0x80 to 0xDF that are letters; the symbols of rows 0xA0
+// and 0xB0 are decoded as '?'
+var latin = map[byte]rune{
+	0x80: 'á', 0x81: 'à', 0x82: 'é', 0x83: 'è', 0x84: 'í', 0x85: 'ì', 0x86: 'ó', 0x87: 'ò',
+	0x88: 'ú', 0x89: 'ù', 0x8A: 'Ñ', 0x8B: 'Ç', 0x8C: 'Ş', 0x8D: 'ß', 0x8E: '¡', 0x8F: 'Ĳ',
+	0x90: 'â', 0x91: 'ä', 0x92: 'ê', 0x93: 'ë', 0x94: 'î', 0x95: 'ï', 0x96: 'ô', 0x97: 'ö',
+	0x98: 'û', 0x99: 'ü', 0x9A: 'ñ', 0x9B: 'ç', 0x9C: 'ş', 0x9D: 'ğ', 0x9E: 'ı', 0x9F: 'ĳ',
+	0xC0: 'Á', 0xC1: 'À', 0xC2: 'É', 0xC3: 'È', 0xC4: 'Í', 0xC5: 'Ì', 0xC6: 'Ó', 0xC7: 'Ò',
+	0xC8: 'Ú', 0xC9: 'Ù', 0xCA: 'Ř', 0xCB: 'Č', 0xCC: 'Š', 0xCD: 'Ž', 0xCE: 'Đ', 0xCF: 'Ŀ',
+	0xD0: 'Â', 0xD1: 'Ä', 0xD2: 'Ê', 0xD3: 'Ë', 0xD4: 'Î', 0xD5: 'Ï', 0xD6: 'Ô', 0xD7: 'Ö',
+	0xD8: 'Û', 0xD9: 'Ü', 0xDA: 'ř', 0xDB: 'č', 0xDC: 'š', 0xDD: 'ž', 0xDE: 'đ', 0xDF: 'ŀ',
+}
+
+// decodeText converts RDS characters to a string. The printable ASCII
+// range is shared with ASCII; control and unknown characters become '?'
+// and the RadioText end marker a space.
+func decodeText(chars []byte) string {
+	var sb strings.Builder
+	for _, c := range chars {
+		switch r, ok := latin[c]; {
+		case ok:
+			sb.WriteRune(r)
+		case c == '\r' || c == 0:
+			sb.WriteByte(' ')
+		case c >= 0x20 && c < 0x7F:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,233 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Chunk IDs of optional metadata chunks
+var (
+	listChunkID = [4]byte{'L', 'I', 'S', 'T'}
+	infoListID  = [4]byte{'I', 'N', 'F', 'O'}
+	factChunkID = [4]byte{'f', 'a', 'c', 't'}
+	bextChunkID = [4]byte{'b', 'e', 'x', 't'}
+	auxiChunkID = [4]byte{'a', 'u', 'x', 'i'}
+)
+
+// Chunk describes a RIFF chunk found in a WAV file
+type Chunk struct {
+	ID     [4]byte
+	Size   uint64 // Body size, resolved from ds64 for RF64/BW64 files
+	Offset int64  // Offset of the chunk body from the start of the file
+}
+
+// Name returns the chunk ID as a string, e.g. "fmt " or "LIST"
+func (c Chunk) Name() string {
+	return string(c.ID[:])
+}
+
+// BroadcastExtension holds the descriptive fields of a Broadcast Wave bext chunk
+type BroadcastExtension struct {
+	Description         string
+	Originator          string
+	OriginatorReference string
+	OriginationDate     string // yyyy-mm-dd
+	OriginationTime     string // hh:mm:ss
+	TimeReference       uint64 // First sample count since midnight
+	Version             uint16
+}
+
+// AuxiMetadata holds the receiver settings SDR#, HDSDR and SpectraVue store
+// in the auxi chunk of baseband recordings
+type AuxiMetadata struct {
+	StartTime    time.Time // Recording start, from a Windows SYSTEMTIME
+	StopTime     time.Time // Recording stop, from a Windows SYSTEMTIME
+	CenterFreq   uint32    // Tuned RF frequency at DC in Hz
+	ADFrequency  uint32    // ADC sample rate in Hz
+	IFFrequency  uint32    // Intermediate frequency in Hz
+	Bandwidth    uint32    // Recorded bandwidth in Hz
+	IQOffset     uint32    // DC offset of the I/Q data
+	NextFilename string    // Next file of a split recording, if any
+}
+
+// WAVMetadata holds metadata parsed from optional chunks
+type WAVMetadata struct {
+	Info             map[string]string   // LIST/INFO entries keyed by ID, e.g. "INAM" or "ICMT"
+	FactSampleLength uint32              // Sample frames per channel from the fact chunk
+	Broadcast        *BroadcastExtension // Contents of the bext chunk
+	Auxi             *AuxiMetadata       // Contents of the auxi chunk
+}
+
+// FindChunk returns the first chunk with the given ID, e.g. "auxi"
+func (r *WAVReader) FindChunk(id string) (Chunk, bool) {
+	for _, chunk := range r.Chunks {
+		if chunk.Name() == id {
+			return chunk, true
+		}
+	}
+	return Chunk{}, false
+}
+
+// ReadChunk returns the body of the first chunk with the given ID
+func (r *WAVReader) ReadChunk(id string) ([]byte, error) {
+	chunk, ok := r.FindChunk(id)
+	if !ok {
+		return nil, fmt.Errorf("%q chunk not found", id)
+	}
+	return r.chunkBody(chunk)
+}
+
+// chunkBody reads the body of a chunk without moving the read position
+func (r *WAVReader) chunkBody(chunk Chunk) ([]byte, error) {
+	body := make([]byte, chunk.Size)
+	if _, err := r.file.ReadAt(body, chunk.Offset); err != nil {
+		return nil, fmt.Errorf("failed to read %q chunk: %w", chunk.Name(), err)
+	}
+	return body, nil
+}
+
+// parseFmt fills the format fields of the header from a fmt chunk body
+func (r *WAVReader) parseFmt(chunk Chunk, body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("fmt chunk too short: %d bytes", len(body))
+	}
+
+	h := &r.Header
+	h.Subchunk1ID = chunk.ID
+	h.Subchunk1Size = uint32(chunk.Size)
+	h.AudioFormat = binary.LittleEndian.Uint16(body[0:2])
+	h.NumChannels = binary.LittleEndian.Uint16(body[2:4])
+	h.SampleRate = binary.LittleEndian.Uint32(body[4:8])
+	h.ByteRate = binary.LittleEndian.Uint32(body[8:12])
+	h.BlockAlign = binary.LittleEndian.Uint16(body[12:14])
+	h.BitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+
+	// WAVE_FORMAT_EXTENSIBLE carries cbSize, valid bits, channel mask and sub-format
+	if h.AudioFormat == WaveFormatExtensible {
+		if len(body) < 40 {
+			return fmt.Errorf("extensible fmt chunk too short: %d bytes", len(body))
+		}
+		r.Extension = &WAVFormatExtension{
+			ValidBitsPerSample: binary.LittleEndian.Uint16(body[18:20]),
+			ChannelMask:        binary.LittleEndian.Uint32(body[20:24]),
+		}
+		copy(r.Extension.SubFormat[:], body[24:40])
+	}
+	return nil
+}
+
+// parseMetadata decodes the optional chunks this package understands;
+// other chunks are only recorded in Chunks. Metadata that cannot be read
+// is skipped, since the audio does not depend on it.
+func (r *WAVReader) parseMetadata(chunk Chunk) {
+	switch chunk.ID {
+	case listChunkID, factChunkID, bextChunkID, auxiChunkID:
+	default:
+		return
+	}
+
+	body, err := r.chunkBody(chunk)
+	if err != nil {
+		return
+	}
+
+	switch chunk.ID {
+	case listChunkID:
+		if len(body) >= 4 && bytes.Equal(body[:4], infoListID[:]) {
+			r.Metadata.Info = parseInfoList(body[4:])
+		}
+	case factChunkID:
+		if len(body) >= 4 {
+			r.Metadata.FactSampleLength = binary.LittleEndian.Uint32(body)
+		}
+	case bextChunkID:
+		r.Metadata.Broadcast = parseBroadcastExtension(body)
+	case auxiChunkID:
+		r.Metadata.Auxi = parseAuxi(body)
+	}
+}
+
+// parseInfoList decodes the sub-chunks of a LIST/INFO chunk
+func parseInfoList(body []byte) map[string]string {
+	info := make(map[string]string)
+	for len(body) >= 8 {
+		id := string(body[:4])
+		size := int(binary.LittleEndian.Uint32(body[4:8]))
+		body = body[8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		info[id] = cString(body[:size])
+
+		size += size % 2
+		if size > len(body) {
+			break
+		}
+		body = body[size:]
+	}
+	return info
+}
+
+// parseBroadcastExtension decodes the fixed fields of a bext chunk
+func parseBroadcastExtension(body []byte) *BroadcastExtension {
+	// Description(256) Originator(32) OriginatorReference(32) Date(10) Time(8)
+	// TimeReferenceLow(4) TimeReferenceHigh(4) Version(2)
+	if len(body) < 348 {
+		return nil
+	}
+	return &BroadcastExtension{
+		Description:         cString(body[0:256]),
+		Originator:          cString(body[256:288]),
+		OriginatorReference: cString(body[288:320]),
+		OriginationDate:     cString(body[320:330]),
+		OriginationTime:     cString(body[330:338]),
+		TimeReference:       binary.LittleEndian.Uint64(body[338:346]),
+		Version:             binary.LittleEndian.Uint16(body[346:348]),
+	}
+}
+
+// parseAuxi decodes the binary auxi chunk layout:
+// StartTime(16) StopTime(16) CenterFreq(4) ADFrequency(4) IFFrequency(4)
+// Bandwidth(4) IQOffset(4) Unused(16) NextFilename(96)
+func parseAuxi(body []byte) *AuxiMetadata {
+	if len(body) < 52 {
+		return nil
+	}
+	auxi := &AuxiMetadata{
+		StartTime:   parseSystemTime(body[0:16]),
+		StopTime:    parseSystemTime(body[16:32]),
+		CenterFreq:  binary.LittleEndian.Uint32(body[32:36]),
+		ADFrequency: binary.LittleEndian.Uint32(body[36:40]),
+		IFFrequency: binary.LittleEndian.Uint32(body[40:44]),
+		Bandwidth:   binary.LittleEndian.Uint32(body[44:48]),
+		IQOffset:    binary.LittleEndian.Uint32(body[48:52]),
+	}
+	if len(body) > 68 {
+		auxi.NextFilename = cString(body[68:])
+	}
+	return auxi
+}
+
+// parseSystemTime decodes a Windows SYSTEMTIME (year, month, day of week,
+// day, hour, minute, second, milliseconds) as UTC
+func parseSystemTime(b []byte) time.Time {
+	field := func(i int) int {
+		return int(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	if field(0) == 0 {
+		return time.Time{}
+	}
+	return time.Date(field(0), time.Month(field(1)), field(3),
+		field(4), field(5), field(6), field(7)*int(time.Millisecond), time.UTC)
+}
+
+// cString converts a NUL-padded byte field to a string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
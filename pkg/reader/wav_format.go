@@ -0,0 +1,127 @@
+package reader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// WAVE format tags used in the fmt chunk
+const (
+	WaveFormatPCM        uint16 = 0x0001
+	WaveFormatIEEEFloat  uint16 = 0x0003
+	WaveFormatExtensible uint16 = 0xFFFE
+)
+
+// Sub-format GUIDs of WAVE_FORMAT_EXTENSIBLE (KSDATAFORMAT_SUBTYPE_*)
+var (
+	subFormatPCM       = [16]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+	subFormatIEEEFloat = [16]byte{0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+)
+
+// WAVFormatExtension holds the extra fields of a WAVE_FORMAT_EXTENSIBLE fmt chunk
+type WAVFormatExtension struct {
+	ValidBitsPerSample uint16   // Number of significant bits in each sample
+	ChannelMask        uint32   // Speaker position of each channel
+	SubFormat          [16]byte // GUID of the actual sample encoding
+}
+
+// SampleFormat describes how individual samples are encoded in a WAV file
+type SampleFormat struct {
+	AudioFormat   uint16 // WaveFormatPCM or WaveFormatIEEEFloat
+	BitsPerSample uint16
+	Extensible    bool // Stored with a WAVE_FORMAT_EXTENSIBLE header
+}
+
+// Common sample formats
+var (
+	PCM8    = SampleFormat{AudioFormat: WaveFormatPCM, BitsPerSample: 8}
+	PCM16   = SampleFormat{AudioFormat: WaveFormatPCM, BitsPerSample: 16}
+	PCM24   = SampleFormat{AudioFormat: WaveFormatPCM, BitsPerSample: 24}
+	PCM32   = SampleFormat{AudioFormat: WaveFormatPCM, BitsPerSample: 32}
+	Float32 = SampleFormat{AudioFormat: WaveFormatIEEEFloat, BitsPerSample: 32}
+	Float64 = SampleFormat{AudioFormat: WaveFormatIEEEFloat, BitsPerSample: 64}
+)
+
+// Validate checks that the sample format can be decoded and encoded
+func (f SampleFormat) Validate() error {
+	switch f.AudioFormat {
+	case WaveFormatPCM:
+		switch f.BitsPerSample {
+		case 8, 16, 24, 32:
+			return nil
+		}
+	case WaveFormatIEEEFloat:
+		switch f.BitsPerSample {
+		case 32, 64:
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported audio format: %d", f.AudioFormat)
+	}
+	return fmt.Errorf("unsupported bits per sample for format %d: %d", f.AudioFormat, f.BitsPerSample)
+}
+
+// BytesPerSample returns the storage size of one sample of one channel
+func (f SampleFormat) BytesPerSample() int {
+	return int(f.BitsPerSample+7) / 8
+}
+
+// pcmScale returns the positive full-scale value of a PCM sample
+func (f SampleFormat) pcmScale() float64 {
+	if f.BitsPerSample == 8 {
+		return 127.0
+	}
+	return math.Exp2(float64(f.BitsPerSample-1)) - 1
+}
+
+// decode converts one encoded sample to a float in the range [-1, 1]
+func (f SampleFormat) decode(b []byte) float64 {
+	if f.AudioFormat == WaveFormatIEEEFloat {
+		if f.BitsPerSample == 64 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+
+	var value int32
+	switch f.BitsPerSample {
+	case 8:
+		// 8-bit PCM is unsigned with a 128 offset
+		value = int32(b[0]) - 128
+	case 16:
+		value = int32(int16(binary.LittleEndian.Uint16(b)))
+	case 24:
+		value = int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+	case 32:
+		value = int32(binary.LittleEndian.Uint32(b))
+	}
+	return float64(value) / f.pcmScale()
+}
+
+// encode writes one sample, clipping PCM values to full scale
+func (f SampleFormat) encode(b []byte, v float64) {
+	if f.AudioFormat == WaveFormatIEEEFloat {
+		if f.BitsPerSample == 64 {
+			binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+		} else {
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+		}
+		return
+	}
+
+	scale := f.pcmScale()
+	value := int32(math.Max(-scale-1, math.Min(scale, v*scale)))
+	switch f.BitsPerSample {
+	case 8:
+		b[0] = uint8(value + 128)
+	case 16:
+		binary.LittleEndian.PutUint16(b, uint16(int16(value)))
+	case 24:
+		b[0] = byte(value)
+		b[1] = byte(value >> 8)
+		b[2] = byte(value >> 16)
+	case 32:
+		binary.LittleEndian.PutUint32(b, uint32(value))
+	}
+}
@@ -1,9 +1,11 @@
 package reader
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 )
 
@@ -15,14 +17,16 @@ var (
 	dataChunkID   = [4]byte{'d', 'a', 't', 'a'}
 )
 
-// WAVHeader represents the header structure of a WAV file
+// WAVHeader represents the canonical 44-byte header of a WAV file.
+// When reading, the fields are filled from the fmt and data chunks
+// wherever they appear in the file.
 type WAVHeader struct {
 	ChunkID       [4]byte // Contains "RIFF"
 	ChunkSize     uint32  // Size of the entire file minus 8 bytes
 	Format        [4]byte // Contains "WAVE"
 	Subchunk1ID   [4]byte // Contains "fmt "
-	Subchunk1Size uint32  // Size of the fmt chunk (16 for PCM)
-	AudioFormat   uint16  // Audio format (1 for PCM)
+	Subchunk1Size uint32  // Size of the fmt chunk (16 for PCM, 40 for extensible)
+	AudioFormat   uint16  // Audio format (1 for PCM, 3 for IEEE float, 0xFFFE for extensible)
 	NumChannels   uint16  // Number of channels
 	SampleRate    uint32  // Sample rate (e.g., 44100)
 	ByteRate      uint32  // SampleRate * NumChannels * BitsPerSample/8
@@ -34,8 +38,15 @@ type WAVHeader struct {
 
 // WAVReader provides functionality to read WAV files
 type WAVReader struct {
-	file   *os.File
-	Header WAVHeader
+	file      *os.File
+	Header    WAVHeader
+	Extension *WAVFormatExtension // Set for WAVE_FORMAT_EXTENSIBLE files
+	Chunks    []Chunk             // All chunks in file order
+	Metadata  WAVMetadata
+	DS64      *DS64  // Set for RF64/BW64 files
+	DataSize  uint64 // Size of the data chunk, which may exceed Subchunk2Size for RF64/BW64
+	remaining uint64 // Unread bytes of the data chunk
+	buf       []byte // Frame buffer reused by block reads
 }
 
 // NewWAVReader creates a new WAV reader for the specified file
@@ -62,8 +73,8 @@ func NewWAVReader(filename string) (*WAVReader, error) {
 
 // validateHeader checks if the WAV header is valid
 func (r *WAVReader) validateHeader() error {
-	if r.Header.ChunkID != riffChunkID {
-		return fmt.Errorf("not a RIFF file")
+	if r.Header.ChunkID != riffChunkID && r.Header.ChunkID != rf64ChunkID && r.Header.ChunkID != bw64ChunkID {
+		return fmt.Errorf("not a RIFF, RF64 or BW64 file")
 	}
 	if r.Header.Format != waveFormat {
 		return fmt.Errorf("not a WAVE file")
@@ -71,8 +82,15 @@ func (r *WAVReader) validateHeader() error {
 	if r.Header.Subchunk1ID != fmtSubchunkID {
 		return fmt.Errorf("fmt chunk not found")
 	}
-	if r.Header.AudioFormat != 1 { // PCM = 1
-		return fmt.Errorf("unsupported audio format: %d", r.Header.AudioFormat)
+	if err := r.SampleFormat().Validate(); err != nil {
+		return err
+	}
+	if r.Header.NumChannels == 0 {
+		return fmt.Errorf("invalid channel count: 0")
+	}
+	if int(r.Header.BlockAlign) < int(r.Header.NumChannels)*r.SampleFormat().BytesPerSample() {
+		return fmt.Errorf("block align %d too small for %d channels of %d bits",
+			r.Header.BlockAlign, r.Header.NumChannels, r.Header.BitsPerSample)
 	}
 	if r.Header.Subchunk2ID != dataChunkID {
 		return fmt.Errorf("data chunk not found")
@@ -80,26 +98,226 @@ func (r *WAVReader) validateHeader() error {
 	return nil
 }
 
+// readHeader walks the RIFF chunks of the file, parsing fmt and known
+// metadata chunks, and leaves the read position at the start of the data
 func (r *WAVReader) readHeader() error {
-	return binary.Read(r.file, binary.LittleEndian, &r.Header)
-}
+	h := &r.Header
+	for _, field := range []interface{}{&h.ChunkID, &h.ChunkSize, &h.Format} {
+		if err := binary.Read(r.file, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	switch h.ChunkID {
+	case riffChunkID, rf64ChunkID, bw64ChunkID:
+	default:
+		// Reported by validateHeader
+		return nil
+	}
+	if h.Format != waveFormat {
+		return nil
+	}
 
-// ReadSamples reads all audio samples from the WAV file
-func (r *WAVReader) ReadSamples() ([]float64, error) {
-	numSamples := r.Header.Subchunk2Size / uint32(r.Header.BlockAlign)
-	samples := make([]float64, numSamples)
-
-	for i := uint32(0); i < numSamples; i++ {
-		var value int16
-		if err := binary.Read(r.file, binary.LittleEndian, &value); err != nil {
-			if err == io.EOF {
-				break
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	fileSize := info.Size()
+
+	dataOffset := int64(-1)
+	offset := int64(12)
+	for offset+8 <= fileSize {
+		var header [8]byte
+		if _, err := r.file.ReadAt(header[:], offset); err != nil {
+			return fmt.Errorf("failed to read chunk header: %w", err)
+		}
+
+		chunk := Chunk{Offset: offset + 8}
+		copy(chunk.ID[:], header[:4])
+		chunk.Size = r.DS64.chunkSize(chunk.ID, binary.LittleEndian.Uint32(header[4:8]))
+
+		// Streaming recorders may leave the data size unset or too large,
+		// and no chunk body is read past the end of the file
+		if chunk.Offset+int64(chunk.Size) > fileSize {
+			chunk.Size = uint64(fileSize - chunk.Offset)
+		}
+		r.Chunks = append(r.Chunks, chunk)
+
+		switch chunk.ID {
+		case ds64ChunkID:
+			body, err := r.chunkBody(chunk)
+			if err != nil {
+				return err
 			}
-			return nil, fmt.Errorf("failed to read sample: %w", err)
+			if r.DS64, err = parseDS64(body); err != nil {
+				return err
+			}
+		case fmtSubchunkID:
+			body, err := r.chunkBody(chunk)
+			if err != nil {
+				return err
+			}
+			if err := r.parseFmt(chunk, body); err != nil {
+				return err
+			}
+		case dataChunkID:
+			dataOffset = chunk.Offset
+			r.DataSize = chunk.Size
+			h.Subchunk2ID = chunk.ID
+			h.Subchunk2Size = uint32(min(chunk.Size, rf64SizePlaceholder))
+		default:
+			r.parseMetadata(chunk)
+		}
+
+		// Chunk bodies are padded to an even size
+		offset = chunk.Offset + int64(chunk.Size) + int64(chunk.Size%2)
+	}
+
+	if dataOffset < 0 {
+		return nil
+	}
+	r.remaining = r.DataSize
+	_, err = r.file.Seek(dataOffset, io.SeekStart)
+	return err
+}
+
+// SampleFormat returns the sample encoding of the file, resolving the
+// sub-format of WAVE_FORMAT_EXTENSIBLE files
+func (r *WAVReader) SampleFormat() SampleFormat {
+	format := SampleFormat{
+		AudioFormat:   r.Header.AudioFormat,
+		BitsPerSample: r.Header.BitsPerSample,
+	}
+	if r.Header.AudioFormat == WaveFormatExtensible && r.Extension != nil {
+		format.Extensible = true
+		switch r.Extension.SubFormat {
+		case subFormatPCM:
+			format.AudioFormat = WaveFormatPCM
+		case subFormatIEEEFloat:
+			format.AudioFormat = WaveFormatIEEEFloat
 		}
-		samples[i] = float64(value) / 32767.0
+	}
+	return format
+}
+
+// ReadSamples reads all audio samples from the WAV file.
+// For multi-channel files the first channel is returned.
+func (r *WAVReader) ReadSamples() ([]float64, error) {
+	channels, err := r.ReadChannels()
+	if err != nil {
+		return nil, err
+	}
+	return channels[0], nil
+}
+
+// ReadChannels reads all remaining audio samples from the WAV file,
+// de-interleaved into one slice per channel
+func (r *WAVReader) ReadChannels() ([][]float64, error) {
+	// A truncated final frame is dropped
+	frames := r.remaining / uint64(r.Header.BlockAlign)
+	data, numFrames, err := r.readFrames(int(frames))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	channels := make([][]float64, r.Header.NumChannels)
+	for c := range channels {
+		channels[c] = make([]float64, numFrames)
+		r.decodeChannel(channels[c], data, c)
+	}
+	return channels, nil
+}
+
+// ReadBlock decodes up to len(buf) frames into buf and returns the number of
+// frames read. For multi-channel files the first channel is returned.
+// It returns io.EOF once no complete frame remains.
+func (r *WAVReader) ReadBlock(buf []float64) (int, error) {
+	data, n, err := r.readFrames(len(buf))
+	if err != nil {
+		return 0, err
+	}
+	r.decodeChannel(buf[:n], data, 0)
+	return n, nil
+}
+
+// ReadIQBlock decodes up to len(buf) frames of a 2-channel baseband recording
+// into buf, like ReadBlock, with the left channel as I and the right as Q
+func (r *WAVReader) ReadIQBlock(buf []complex128) (int, error) {
+	if r.Header.NumChannels != 2 {
+		return 0, fmt.Errorf("I/Q WAV files must have 2 channels, got %d", r.Header.NumChannels)
+	}
+
+	data, n, err := r.readFrames(len(buf))
+	if err != nil {
+		return 0, err
+	}
+
+	format := r.SampleFormat()
+	frameSize := int(r.Header.BlockAlign)
+	sampleSize := format.BytesPerSample()
+	for i := 0; i < n; i++ {
+		frame := data[i*frameSize:]
+		buf[i] = complex(format.decode(frame), format.decode(frame[sampleSize:]))
+	}
+	return n, nil
+}
+
+// readFrames reads up to count frames from the data chunk and returns the
+// raw bytes and the number of complete frames read
+func (r *WAVReader) readFrames(count int) ([]byte, int, error) {
+	frameSize := uint64(r.Header.BlockAlign)
+	size := min(uint64(count)*frameSize, r.remaining/frameSize*frameSize)
+	if size == 0 {
+		return nil, 0, io.EOF
+	}
+	if uint64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	data := r.buf[:size]
+
+	n, err := io.ReadFull(r.file, data)
+	r.remaining -= uint64(n)
+	if err == io.ErrUnexpectedEOF {
+		// The file ended early; a truncated final frame is dropped
+		r.remaining = 0
+		err = nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	frames := n / int(frameSize)
+	if frames == 0 {
+		return nil, 0, io.EOF
+	}
+	return data, frames, nil
+}
+
+// decodeChannel decodes one channel of the interleaved frames in data into dst
+func (r *WAVReader) decodeChannel(dst []float64, data []byte, channel int) {
+	format := r.SampleFormat()
+	frameSize := int(r.Header.BlockAlign)
+	offset := channel * format.BytesPerSample()
+	for i := range dst {
+		dst[i] = format.decode(data[i*frameSize+offset:])
+	}
+}
+
+// ReadIQ reads a 2-channel baseband recording as complex samples, with the
+// left channel as in-phase (I) and the right channel as quadrature (Q)
+func (r *WAVReader) ReadIQ() ([]complex128, error) {
+	if r.Header.NumChannels != 2 {
+		return nil, fmt.Errorf("I/Q WAV files must have 2 channels, got %d", r.Header.NumChannels)
+	}
+
+	channels, err := r.ReadChannels()
+	if err != nil {
+		return nil, err
 	}
 
+	samples := make([]complex128, len(channels[0]))
+	for i := range samples {
+		samples[i] = complex(channels[0][i], channels[1][i])
+	}
 	return samples, nil
 }
 
@@ -124,45 +342,169 @@ func ReadWavFile(filename string) ([]float64, float64, error) {
 	return samples, float64(reader.Header.SampleRate), nil
 }
 
-// WriteWavFile writes samples to a WAV file
+// ReadWavFileIQ is a convenience function to read a stereo I/Q WAV file
+func ReadWavFileIQ(filename string) ([]complex128, float64, error) {
+	reader, err := NewWAVReader(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+
+	samples, err := reader.ReadIQ()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return samples, float64(reader.Header.SampleRate), nil
+}
+
+// WriteWavFile writes samples to a 16-bit PCM WAV file
 func WriteWavFile(filename string, samples []float64, sampleRate float64) error {
+	return WriteWavFileFormat(filename, samples, sampleRate, PCM16)
+}
+
+// WriteWavFileFormat writes samples to a mono WAV file using the given sample format.
+// Files whose size exceeds the 4 GB RIFF limit are written as RF64.
+func WriteWavFileFormat(filename string, samples []float64, sampleRate float64, format SampleFormat) error {
+	return WriteWavFileChannels(filename, [][]float64{samples}, sampleRate, format)
+}
+
+// WriteWavFileChannels writes one slice of samples per channel, all of the
+// same length, to an interleaved WAV file (for stereo: left, right).
+// Files whose size exceeds the 4 GB RIFF limit are written as RF64.
+func WriteWavFileChannels(filename string, channels [][]float64, sampleRate float64, format SampleFormat) error {
+	if err := format.Validate(); err != nil {
+		return fmt.Errorf("invalid sample format: %w", err)
+	}
+	if err := validateChannels(channels); err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create WAV file: %w", err)
 	}
 	defer file.Close()
 
+	out := bufio.NewWriter(file)
+	frames := len(channels[0])
+
 	// Create WAV header
+	header := newHeader(sampleRate, len(channels), format)
+	dataSize := uint64(frames) * uint64(header.BlockAlign)
+	riffSize := 20 + uint64(header.Subchunk1Size) + dataSize + dataSize%2
+
+	// Switch to RF64 when the sizes do not fit the 32-bit RIFF fields
+	var ds64 *DS64
+	if needsRF64(riffSize) {
+		riffSize += 8 + ds64BodySize
+		ds64 = &DS64{RIFFSize: riffSize, DataSize: dataSize, SampleCount: uint64(frames)}
+		header.ChunkID = rf64ChunkID
+		header.ChunkSize = rf64SizePlaceholder
+		header.Subchunk2Size = rf64SizePlaceholder
+	} else {
+		header.ChunkSize = uint32(riffSize)
+		header.Subchunk2Size = uint32(dataSize)
+	}
+
+	// Write header
+	if err := writeHeader(out, header, format, ds64); err != nil {
+		return fmt.Errorf("failed to write WAV header: %w", err)
+	}
+
+	// Write samples
+	buf := make([]byte, header.BlockAlign)
+	for i := 0; i < frames; i++ {
+		encodeFrame(buf, channels, i, format)
+		if _, err := out.Write(buf); err != nil {
+			return fmt.Errorf("failed to write sample: %w", err)
+		}
+	}
+
+	// Data chunks are padded to an even size
+	if dataSize%2 == 1 {
+		if err := out.WriteByte(0); err != nil {
+			return fmt.Errorf("failed to write samples: %w", err)
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+	return nil
+}
+
+// validateChannels checks that there is at least one channel and that all
+// channels have the same length
+func validateChannels(channels [][]float64) error {
+	if len(channels) == 0 || len(channels) > math.MaxUint16 {
+		return fmt.Errorf("WAV files need 1 to %d channels, got %d", math.MaxUint16, len(channels))
+	}
+	for c, samples := range channels {
+		if len(samples) != len(channels[0]) {
+			return fmt.Errorf("channel %d has %d samples, channel 0 has %d", c, len(samples), len(channels[0]))
+		}
+	}
+	return nil
+}
+
+// encodeFrame encodes sample i of every channel into the interleaved frame buf
+func encodeFrame(buf []byte, channels [][]float64, i int, format SampleFormat) {
+	size := format.BytesPerSample()
+	for c, samples := range channels {
+		format.encode(buf[c*size:], samples[i])
+	}
+}
+
+// newHeader creates the header of a file with the given number of channels
+// in the given sample format, leaving the size fields to the caller
+func newHeader(sampleRate float64, channels int, format SampleFormat) WAVHeader {
 	header := WAVHeader{
 		ChunkID:       riffChunkID,
 		Format:        waveFormat,
 		Subchunk1ID:   fmtSubchunkID,
 		Subchunk1Size: 16,
-		AudioFormat:   1, // PCM
-		NumChannels:   1, // Mono
+		AudioFormat:   format.AudioFormat,
+		NumChannels:   uint16(channels),
 		SampleRate:    uint32(sampleRate),
-		BitsPerSample: 16,
+		BitsPerSample: format.BitsPerSample,
+		Subchunk2ID:   dataChunkID,
+	}
+	if format.Extensible {
+		header.Subchunk1Size = 40
+		header.AudioFormat = WaveFormatExtensible
 	}
 
 	// Calculate dependent fields
 	header.ByteRate = header.SampleRate * uint32(header.NumChannels) * uint32(header.BitsPerSample/8)
-	header.BlockAlign = header.NumChannels * header.BitsPerSample/8
-	header.Subchunk2ID = dataChunkID
-	header.Subchunk2Size = uint32(len(samples) * int(header.BlockAlign))
-	header.ChunkSize = 36 + header.Subchunk2Size
+	header.BlockAlign = header.NumChannels * header.BitsPerSample / 8
+	return header
+}
 
-	// Write header
-	if err := binary.Write(file, binary.LittleEndian, &header); err != nil {
-		return fmt.Errorf("failed to write WAV header: %w", err)
+// writeHeader writes the RIFF header, the ds64 chunk for RF64 files, the fmt
+// chunk (with the extensible sub-format when requested) and the data chunk header
+func writeHeader(w io.Writer, header WAVHeader, format SampleFormat, ds64 *DS64) error {
+	fields := []interface{}{header.ChunkID, header.ChunkSize, header.Format}
+	if ds64 != nil {
+		fields = append(fields, ds64ChunkID, uint32(ds64BodySize), ds64.encode())
 	}
-
-	// Write samples
-	for _, sample := range samples {
-		value := int16(sample * 32767.0)
-		if err := binary.Write(file, binary.LittleEndian, value); err != nil {
-			return fmt.Errorf("failed to write sample: %w", err)
+	fields = append(fields,
+		header.Subchunk1ID, header.Subchunk1Size,
+		header.AudioFormat, header.NumChannels, header.SampleRate, header.ByteRate, header.BlockAlign, header.BitsPerSample,
+	)
+	if format.Extensible {
+		subFormat := subFormatPCM
+		if format.AudioFormat == WaveFormatIEEEFloat {
+			subFormat = subFormatIEEEFloat
 		}
+		fields = append(fields, uint16(22), format.BitsPerSample, uint32(0), subFormat)
 	}
+	fields = append(fields, header.Subchunk2ID, header.Subchunk2Size)
 
+	for _, field := range fields {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
 	return nil
 }
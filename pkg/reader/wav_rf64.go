@@ -0,0 +1,81 @@
+package reader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// RF64 (EBU Tech 3306) and BW64 (ITU-R BS.2088) replace the RIFF ID and
+// store 64-bit sizes in a ds64 chunk, setting the 32-bit sizes to 0xFFFFFFFF
+var (
+	rf64ChunkID = [4]byte{'R', 'F', '6', '4'}
+	bw64ChunkID = [4]byte{'B', 'W', '6', '4'}
+	ds64ChunkID = [4]byte{'d', 's', '6', '4'}
+)
+
+// rf64SizePlaceholder marks a 32-bit size field whose value is held in ds64
+const rf64SizePlaceholder = math.MaxUint32
+
+// ds64BodySize is the size of a ds64 chunk without a size table
+const ds64BodySize = 28
+
+// DS64 holds the 64-bit sizes of an RF64/BW64 file
+type DS64 struct {
+	RIFFSize    uint64            // Size of the file minus 8 bytes
+	DataSize    uint64            // Size of the data chunk
+	SampleCount uint64            // Sample frames per channel (fact chunk equivalent)
+	Table       map[string]uint64 // 64-bit sizes of other oversized chunks
+}
+
+// parseDS64 decodes a ds64 chunk body
+func parseDS64(body []byte) (*DS64, error) {
+	if len(body) < ds64BodySize {
+		return nil, fmt.Errorf("ds64 chunk too short: %d bytes", len(body))
+	}
+
+	ds64 := &DS64{
+		RIFFSize:    binary.LittleEndian.Uint64(body[0:8]),
+		DataSize:    binary.LittleEndian.Uint64(body[8:16]),
+		SampleCount: binary.LittleEndian.Uint64(body[16:24]),
+		Table:       make(map[string]uint64),
+	}
+
+	tableLength := int(binary.LittleEndian.Uint32(body[24:28]))
+	table := body[ds64BodySize:]
+	for i := 0; i < tableLength && len(table) >= 12; i++ {
+		ds64.Table[string(table[:4])] = binary.LittleEndian.Uint64(table[4:12])
+		table = table[12:]
+	}
+	return ds64, nil
+}
+
+// encode serializes the ds64 chunk body without a size table
+func (d *DS64) encode() []byte {
+	body := make([]byte, ds64BodySize)
+	binary.LittleEndian.PutUint64(body[0:8], d.RIFFSize)
+	binary.LittleEndian.PutUint64(body[8:16], d.DataSize)
+	binary.LittleEndian.PutUint64(body[16:24], d.SampleCount)
+	return body
+}
+
+// chunkSize resolves the 64-bit size of a chunk whose 32-bit size field
+// holds the RF64 placeholder
+func (d *DS64) chunkSize(id [4]byte, size uint32) uint64 {
+	if d == nil || size != rf64SizePlaceholder {
+		return uint64(size)
+	}
+	if id == dataChunkID {
+		return d.DataSize
+	}
+	if tableSize, ok := d.Table[string(id[:])]; ok {
+		return tableSize
+	}
+	return uint64(size)
+}
+
+// needsRF64 reports whether a file with the given RIFF size exceeds the
+// 4 GB limit of the 32-bit RIFF size field
+func needsRF64(riffSize uint64) bool {
+	return riffSize > math.MaxUint32
+}
@@ -0,0 +1,172 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// junkChunkID marks the space reserved for a ds64 chunk in streamed files
+var junkChunkID = [4]byte{'J', 'U', 'N', 'K'}
+
+// WAVWriter writes a WAV file block by block. The header sizes are patched
+// on Close, switching the file to RF64 when it exceeds 4 GB.
+type WAVWriter struct {
+	file   *os.File
+	out    *bufio.Writer
+	header WAVHeader
+	format SampleFormat
+	frames uint64 // Frames written so far
+	buf    []byte // Encoding buffer reused across blocks
+}
+
+// NewWAVWriter creates a WAV file and writes its header. Until Close is
+// called the size fields hold 0xFFFFFFFF, so an interrupted recording is
+// still readable up to the last complete frame.
+func NewWAVWriter(filename string, sampleRate float64, format SampleFormat) (*WAVWriter, error) {
+	return NewWAVWriterChannels(filename, sampleRate, 1, format)
+}
+
+// NewWAVWriterChannels creates a WAV file with the given number of
+// interleaved channels, written with WriteChannels
+func NewWAVWriterChannels(filename string, sampleRate float64, channels int, format SampleFormat) (*WAVWriter, error) {
+	if err := format.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid sample format: %w", err)
+	}
+	if channels < 1 || channels > math.MaxUint16 {
+		return nil, fmt.Errorf("WAV files need 1 to %d channels, got %d", math.MaxUint16, channels)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WAV file: %w", err)
+	}
+
+	header := newHeader(sampleRate, channels, format)
+	header.ChunkSize = rf64SizePlaceholder
+	header.Subchunk2Size = rf64SizePlaceholder
+
+	// Reserve room for a ds64 chunk as JUNK (EBU Tech 3306), so Close can
+	// convert the file to RF64 without moving the samples
+	var head bytes.Buffer
+	if err := writeHeader(&head, header, format, &DS64{}); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write WAV header: %w", err)
+	}
+	copy(head.Bytes()[12:16], junkChunkID[:])
+
+	w := &WAVWriter{
+		file:   file,
+		out:    bufio.NewWriter(file),
+		header: header,
+		format: format,
+	}
+	if _, err := w.out.Write(head.Bytes()); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write WAV header: %w", err)
+	}
+	return w, nil
+}
+
+// WriteBlock encodes and appends samples to the data chunk of a mono file
+func (w *WAVWriter) WriteBlock(samples []float64) error {
+	return w.WriteChannels([][]float64{samples})
+}
+
+// WriteChannels encodes and appends one block per channel, all of the same
+// length, interleaving them into frames
+func (w *WAVWriter) WriteChannels(channels [][]float64) error {
+	if len(channels) != int(w.header.NumChannels) {
+		return fmt.Errorf("WAV file has %d channels, got %d", w.header.NumChannels, len(channels))
+	}
+	if err := validateChannels(channels); err != nil {
+		return err
+	}
+
+	frames := len(channels[0])
+	frameSize := int(w.header.BlockAlign)
+	if need := frames * frameSize; cap(w.buf) < need {
+		w.buf = make([]byte, need)
+	}
+	data := w.buf[:frames*frameSize]
+
+	for i := 0; i < frames; i++ {
+		encodeFrame(data[i*frameSize:], channels, i, w.format)
+	}
+	if _, err := w.out.Write(data); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+	w.frames += uint64(frames)
+	return nil
+}
+
+// SamplesWritten returns the number of samples written so far per channel
+func (w *WAVWriter) SamplesWritten() uint64 {
+	return w.frames
+}
+
+// Close pads the data chunk, patches the header sizes and closes the file.
+// Calls after the first return nil.
+func (w *WAVWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.finish()
+	if closeErr := w.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close WAV file: %w", closeErr)
+	}
+	w.file = nil
+	return err
+}
+
+// finish flushes the samples and rewrites the size fields
+func (w *WAVWriter) finish() error {
+	dataSize := w.frames * uint64(w.header.BlockAlign)
+
+	// Data chunks are padded to an even size
+	if dataSize%2 == 1 {
+		if err := w.out.WriteByte(0); err != nil {
+			return fmt.Errorf("failed to write samples: %w", err)
+		}
+	}
+	if err := w.out.Flush(); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+
+	// RIFF header, reserved ds64/JUNK chunk and fmt chunk precede the data
+	ds64Offset := int64(12)
+	fmtOffset := ds64Offset + 8 + ds64BodySize
+	dataSizeOffset := fmtOffset + 8 + int64(w.header.Subchunk1Size) + 4
+	riffSize := uint64(dataSizeOffset) + 4 + dataSize + dataSize%2 - 8
+
+	if needsRF64(riffSize) {
+		ds64 := &DS64{RIFFSize: riffSize, DataSize: dataSize, SampleCount: w.frames}
+		if err := w.writeAt(0, rf64ChunkID); err != nil {
+			return err
+		}
+		if err := w.writeAt(ds64Offset, ds64ChunkID); err != nil {
+			return err
+		}
+		return w.writeAt(ds64Offset+8, ds64.encode())
+	}
+
+	if err := w.writeAt(4, uint32(riffSize)); err != nil {
+		return err
+	}
+	return w.writeAt(dataSizeOffset, uint32(dataSize))
+}
+
+// writeAt overwrites a header field at the given file offset
+func (w *WAVWriter) writeAt(offset int64, value interface{}) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+		return err
+	}
+	if _, err := w.file.WriteAt(buf.Bytes(), offset); err != nil {
+		return fmt.Errorf("failed to update WAV header: %w", err)
+	}
+	return nil
+}
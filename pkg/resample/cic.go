@@ -0,0 +1,97 @@
+package resample
+
+import (
+	"fmt"
+	"math"
+)
+
+// CIC is a cascaded integrator-comb decimator: stages integrators at the
+// input rate and stages combs at the output rate, a moving sum over factor
+// samples applied stages times without multiplications. The integrators
+// run on fixed-point integers whose wrap-around cancels in the combs, so
+// they never drift.
+type CIC struct {
+	factor, stages int
+	scale          float64 // Fixed-point scale of the input
+	gain           float64 // factor^stages
+	integrators    []int64
+	combs          []int64 // Previous comb inputs
+	phase          int     // Position of the next input in its group of factor
+}
+
+// cicHeadroom is the number of integer bits left for input magnitude
+const cicHeadroom = 8
+
+// NewCIC creates a CIC decimator by factor with the given number of stages
+func NewCIC(factor, stages int) (*CIC, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("decimation factor must be positive, got %d", factor)
+	}
+	if stages < 1 {
+		return nil, fmt.Errorf("number of stages must be positive, got %d", stages)
+	}
+
+	// The sums grow by stages*log2(factor) bits; the rest is fraction
+	growth := int(math.Ceil(float64(stages) * math.Log2(float64(factor))))
+	fraction := 63 - cicHeadroom - growth
+	if fraction < 16 {
+		return nil, fmt.Errorf("decimation by %d with %d stages needs too many bits", factor, stages)
+	}
+	return &CIC{
+		factor:      factor,
+		stages:      stages,
+		scale:       math.Ldexp(1, fraction),
+		gain:        math.Pow(float64(factor), float64(stages)),
+		integrators: make([]int64, stages),
+		combs:       make([]int64, stages),
+	}, nil
+}
+
+// Process decimates a block of samples into a new slice
+func (c *CIC) Process(samples []float64) []float64 {
+	result := make([]float64, 0, len(samples)/c.factor+1)
+	for _, x := range samples {
+		v := int64(math.Round(x * c.scale))
+		for i := range c.integrators {
+			c.integrators[i] += v
+			v = c.integrators[i]
+		}
+
+		// Output at the first input of each group, like a polyphase
+		// decimator
+		emit := c.phase == 0
+		if c.phase++; c.phase == c.factor {
+			c.phase = 0
+		}
+		if !emit {
+			continue
+		}
+		for i := range c.combs {
+			v, c.combs[i] = v-c.combs[i], v
+		}
+		result = append(result, float64(v)/c.scale/c.gain)
+	}
+	return result
+}
+
+// Reset clears the integrators and combs
+func (c *CIC) Reset() {
+	clear(c.integrators)
+	clear(c.combs)
+	c.phase = 0
+}
+
+// Latency returns the delay in output samples
+func (c *CIC) Latency() float64 {
+	return float64(c.stages) * float64(c.factor-1) / 2 / float64(c.factor)
+}
+
+// Response returns the magnitude response at freq in cycles per output
+// sample, which droops towards the output Nyquist frequency
+func (c *CIC) Response(freq float64) float64 {
+	if freq == 0 {
+		return 1
+	}
+	r := float64(c.factor)
+	return math.Pow(math.Abs(math.Sin(math.Pi*freq)/(r*math.Sin(math.Pi*freq/r))), float64(c.stages))
+}
@@ -0,0 +1,124 @@
+package resample
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// CICMinFactor is the decimation factor from which a decimator starts
+	// with a CIC filter
+	CICMinFactor = 16
+	// CICStages is the number of stages of a decimator's CIC filter
+	CICStages = 5
+	// maxFIRFactor bounds the decimation left to the compensation filter
+	maxFIRFactor = 8
+)
+
+// Decimator reduces the sample rate by an integer factor in stages. Large
+// factors are split into a CIC filter, which does most of the decimation
+// cheaply, and a polyphase FIR filter that decimates the rest while
+// flattening the CIC passband droop and removing what it aliases.
+type Decimator struct {
+	factor int
+	cic    *CIC       // nil for a single FIR stage
+	fir    *Resampler // Final stage
+}
+
+// NewDecimator creates a decimator by factor
+func NewDecimator(factor int) (*Decimator, error) {
+	if err := validateFactors(1, factor); err != nil {
+		return nil, err
+	}
+
+	// The FIR stage decimates by the largest small divisor
+	firFactor := 0
+	if factor >= CICMinFactor {
+		for d := maxFIRFactor; d >= 2; d-- {
+			if factor%d == 0 {
+				firFactor = d
+				break
+			}
+		}
+	}
+	if firFactor == 0 {
+		fir, err := NewResampler(1, factor)
+		if err != nil {
+			return nil, err
+		}
+		return &Decimator{factor: factor, fir: fir}, nil
+	}
+
+	cic, err := NewCIC(factor/firFactor, CICStages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Frequencies in units of the CIC output rate
+	out := 1 / float64(firFactor)
+	pass, stop := Passband*out, out/2
+	taps, err := lowpass(1, pass, stop, DefaultAttenuation)
+	if err != nil {
+		return nil, err
+	}
+	taps, err = compensate(taps, cic, pass)
+	if err != nil {
+		return nil, err
+	}
+	fir, err := NewResamplerWithTaps(1, firFactor, taps)
+	if err != nil {
+		return nil, err
+	}
+	return &Decimator{factor: factor, cic: cic, fir: fir}, nil
+}
+
+// compensate sharpens the low-pass taps with the three-tap filter
+// [-a, 1+2a, -a], whose rising response cancels the CIC droop at DC and at
+// the passband edge
+func compensate(taps []float64, cic *CIC, edge float64) ([]float64, error) {
+	droop := cic.Response(edge)
+	if droop <= 0 {
+		return nil, fmt.Errorf("CIC response vanishes in the passband")
+	}
+	a := (1/droop - 1) / (2 * (1 - math.Cos(2*math.Pi*edge)))
+	sharpen := []float64{-a, 1 + 2*a, -a}
+
+	result := make([]float64, len(taps)+len(sharpen)-1)
+	for i, t := range taps {
+		for j, s := range sharpen {
+			result[i+j] += t * s
+		}
+	}
+	return result, nil
+}
+
+// Factor returns the decimation factor
+func (d *Decimator) Factor() int {
+	return d.factor
+}
+
+// Process decimates a block of samples into a new slice
+func (d *Decimator) Process(samples []float64) []float64 {
+	if d.cic != nil {
+		samples = d.cic.Process(samples)
+	}
+	return d.fir.Process(samples)
+}
+
+// Reset clears the state of every stage
+func (d *Decimator) Reset() {
+	if d.cic != nil {
+		d.cic.Reset()
+	}
+	d.fir.Reset()
+}
+
+// Latency returns the delay of both stages in output samples
+func (d *Decimator) Latency() float64 {
+	latency := d.fir.Latency()
+	if d.cic != nil {
+		_, down := d.fir.Factors()
+		latency += d.cic.Latency() / float64(down)
+	}
+	return latency
+}
@@ -0,0 +1,202 @@
+// Package resample converts sample rates: a polyphase rational (L/M)
+// resampler with automatic anti-alias filter design, and a multistage
+// decimator that uses a CIC filter for large factors
+package resample
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+const (
+	// DefaultAttenuation is the stopband attenuation in dB of the
+	// designed anti-alias filters
+	DefaultAttenuation = 80.0
+	// Passband is the fraction of the narrower of the input and output
+	// rates kept flat; the stopband starts at half the narrower rate, so
+	// nothing aliases into the output
+	Passband = 0.4
+	// MaxFactor bounds the interpolation and decimation factors of a
+	// rational rate change
+	MaxFactor = 1 << 16
+)
+
+// Converter changes the sample rate of a signal block by block. Converters
+// are streaming: consecutive calls to Process continue one signal, and the
+// concatenated output equals the output for the whole signal at once.
+type Converter interface {
+	Process(samples []float64) []float64
+	// Reset clears the state so the next block starts a new signal
+	Reset()
+	// Latency returns the delay of the output relative to the input in
+	// output samples
+	Latency() float64
+}
+
+// New returns a converter from inRate to outRate: a multistage decimator
+// for large integer decimations, a polyphase resampler otherwise
+func New(inRate, outRate float64) (Converter, error) {
+	up, down, err := Rational(inRate, outRate)
+	if err != nil {
+		return nil, err
+	}
+	if up == 1 && down >= CICMinFactor {
+		return NewDecimator(down)
+	}
+	return NewResampler(up, down)
+}
+
+// Rational returns the interpolation and decimation factors, in lowest
+// terms, that convert inRate to outRate
+func Rational(inRate, outRate float64) (up, down int, err error) {
+	if inRate <= 0 || outRate <= 0 {
+		return 0, 0, fmt.Errorf("sample rates must be positive, got %g and %g", inRate, outRate)
+	}
+
+	// Continued fraction convergents of the ratio
+	ratio := outRate / inRate
+	p0, q0, p1, q1 := 0, 1, 1, 0
+	x := ratio
+	for {
+		a := math.Floor(x)
+		if a > MaxFactor {
+			break
+		}
+		p, q := int(a)*p1+p0, int(a)*q1+q0
+		if p > MaxFactor || q > MaxFactor {
+			break
+		}
+		p0, q0, p1, q1 = p1, q1, p, q
+		if math.Abs(float64(p)/float64(q)-ratio) <= 1e-12*ratio || x == a {
+			return p, q, nil
+		}
+		x = 1 / (x - a)
+	}
+	return 0, 0, fmt.Errorf("cannot convert %g Hz to %g Hz with factors up to %d", inRate, outRate, MaxFactor)
+}
+
+// Resampler changes the sample rate by the rational factor up/down with a
+// polyphase FIR filter: only the filter phase that produces each output
+// sample is evaluated, so zeros are never stuffed and samples never
+// discarded.
+type Resampler struct {
+	up, down int
+	taps     []float64   // Filter at the interpolated rate, scaled by up
+	phases   [][]float64 // phases[p][k] = taps[p + k*up]
+	history  []float64   // The previous len(phases[0])-1 inputs, oldest first
+	offset   int         // Interpolated-rate position of the next output from the next input
+}
+
+// NewResampler creates a resampler by up/down with an anti-alias filter of
+// DefaultAttenuation that keeps Passband of the narrower rate
+func NewResampler(up, down int) (*Resampler, error) {
+	if err := validateFactors(up, down); err != nil {
+		return nil, err
+	}
+	// Frequencies in units of the input rate, at the interpolated rate up
+	narrower := math.Min(1, float64(up)/float64(down))
+	taps, err := lowpass(float64(up), Passband*narrower, narrower/2, DefaultAttenuation)
+	if err != nil {
+		return nil, err
+	}
+	return NewResamplerWithTaps(up, down, taps)
+}
+
+// NewResamplerWithTaps creates a resampler by up/down with the given filter
+// taps at the interpolated rate. The taps are scaled by up, so a filter
+// with unit passband gain keeps the signal level.
+func NewResamplerWithTaps(up, down int, taps []float64) (*Resampler, error) {
+	if err := validateFactors(up, down); err != nil {
+		return nil, err
+	}
+	if len(taps) == 0 {
+		return nil, fmt.Errorf("filter has no taps")
+	}
+
+	r := &Resampler{up: up, down: down, taps: make([]float64, len(taps))}
+	for i, t := range taps {
+		r.taps[i] = t * float64(up)
+	}
+	length := (len(taps) + up - 1) / up
+	r.phases = make([][]float64, up)
+	for p := range r.phases {
+		r.phases[p] = make([]float64, length)
+		for k := range r.phases[p] {
+			if i := p + k*up; i < len(r.taps) {
+				r.phases[p][k] = r.taps[i]
+			}
+		}
+	}
+	r.history = make([]float64, length-1)
+	return r, nil
+}
+
+func validateFactors(up, down int) error {
+	if up < 1 || down < 1 {
+		return fmt.Errorf("resampling factors must be positive, got %d/%d", up, down)
+	}
+	if up > MaxFactor || down > MaxFactor {
+		return fmt.Errorf("resampling factors must be at most %d, got %d/%d", MaxFactor, up, down)
+	}
+	return nil
+}
+
+// lowpass designs a Kaiser-window low-pass filter at sampleRate, flat to
+// pass and attenuating from stop
+func lowpass(sampleRate, pass, stop, attenuation float64) ([]float64, error) {
+	if stop >= sampleRate/2 {
+		return []float64{1}, nil
+	}
+	bands := []filter.Band{
+		{Low: 0, High: pass, Gain: 1},
+		{Low: stop, High: sampleRate / 2},
+	}
+	return filter.DesignKaiser(0, bands, sampleRate, attenuation)
+}
+
+// Factors returns the interpolation and decimation factors
+func (r *Resampler) Factors() (up, down int) {
+	return r.up, r.down
+}
+
+// Taps returns the filter taps at the interpolated rate, scaled by the
+// interpolation factor
+func (r *Resampler) Taps() []float64 {
+	return r.taps
+}
+
+// Process resamples a block of samples into a new slice
+func (r *Resampler) Process(samples []float64) []float64 {
+	length := len(r.phases[0])
+	buf := append(r.history, samples...)
+	end := len(samples) * r.up
+
+	result := make([]float64, 0, (end-r.offset)/r.down+1)
+	t := r.offset
+	for ; t < end; t += r.down {
+		phase := r.phases[t%r.up]
+		window := buf[t/r.up : t/r.up+length]
+		var sum float64
+		for k, h := range phase {
+			sum += h * window[length-1-k]
+		}
+		result = append(result, sum)
+	}
+	r.offset = t - end
+	r.history = slices.Clone(buf[len(buf)-(length-1):])
+	return result
+}
+
+// Reset clears the input history
+func (r *Resampler) Reset() {
+	clear(r.history)
+	r.offset = 0
+}
+
+// Latency returns the filter delay in output samples
+func (r *Resampler) Latency() float64 {
+	return float64(len(r.taps)-1) / 2 / float64(r.down)
+}
@@ -0,0 +1,142 @@
+package sigmf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Datatype describes the sample encoding declared by core:datatype,
+// for example "cf32_le" or "ri16_be"
+type Datatype struct {
+	Complex   bool
+	Kind      byte // 'f' (float), 'i' (signed) or 'u' (unsigned)
+	Bits      int
+	ByteOrder binary.ByteOrder
+}
+
+// ParseDatatype parses a core:datatype string
+func ParseDatatype(s string) (Datatype, error) {
+	var d Datatype
+	if len(s) < 3 {
+		return d, fmt.Errorf("invalid datatype: %q", s)
+	}
+
+	switch s[0] {
+	case 'c':
+		d.Complex = true
+	case 'r':
+	default:
+		return d, fmt.Errorf("invalid datatype %q: must start with 'c' or 'r'", s)
+	}
+
+	d.Kind = s[1]
+	if d.Kind != 'f' && d.Kind != 'i' && d.Kind != 'u' {
+		return d, fmt.Errorf("invalid datatype %q: unknown kind %q", s, d.Kind)
+	}
+
+	bits, order, _ := strings.Cut(s[2:], "_")
+	var err error
+	if d.Bits, err = strconv.Atoi(bits); err != nil {
+		return d, fmt.Errorf("invalid datatype %q: bad bit width", s)
+	}
+
+	switch {
+	case d.Kind == 'f' && d.Bits != 32 && d.Bits != 64,
+		d.Kind != 'f' && d.Bits != 8 && d.Bits != 16 && d.Bits != 32:
+		return d, fmt.Errorf("unsupported datatype: %s", s)
+	}
+
+	switch order {
+	case "le":
+		d.ByteOrder = binary.LittleEndian
+	case "be":
+		d.ByteOrder = binary.BigEndian
+	case "":
+		if d.Bits != 8 {
+			return d, fmt.Errorf("invalid datatype %q: byte order required", s)
+		}
+		d.ByteOrder = binary.LittleEndian
+	default:
+		return d, fmt.Errorf("invalid datatype %q: unknown byte order %q", s, order)
+	}
+
+	return d, nil
+}
+
+// String formats the datatype as a core:datatype string
+func (d Datatype) String() string {
+	prefix := "r"
+	if d.Complex {
+		prefix = "c"
+	}
+	s := fmt.Sprintf("%s%c%d", prefix, d.Kind, d.Bits)
+	if d.Bits == 8 {
+		return s
+	}
+	if d.ByteOrder == binary.BigEndian {
+		return s + "_be"
+	}
+	return s + "_le"
+}
+
+// ComponentSize returns the size in bytes of a single real or I/Q component
+func (d Datatype) ComponentSize() int {
+	return d.Bits / 8
+}
+
+// SampleSize returns the size in bytes of one (possibly complex) sample
+func (d Datatype) SampleSize() int {
+	if d.Complex {
+		return 2 * d.ComponentSize()
+	}
+	return d.ComponentSize()
+}
+
+// decode converts one component to a float, scaling integers to [-1, 1]
+func (d Datatype) decode(b []byte) float64 {
+	switch d.Kind {
+	case 'f':
+		if d.Bits == 64 {
+			return math.Float64frombits(d.ByteOrder.Uint64(b))
+		}
+		return float64(math.Float32frombits(d.ByteOrder.Uint32(b)))
+	case 'i':
+		switch d.Bits {
+		case 8:
+			return float64(int8(b[0])) / (1 << 7)
+		case 16:
+			return float64(int16(d.ByteOrder.Uint16(b))) / (1 << 15)
+		default:
+			return float64(int32(d.ByteOrder.Uint32(b))) / (1 << 31)
+		}
+	default:
+		switch d.Bits {
+		case 8:
+			return (float64(b[0]) - 127.5) / 127.5
+		case 16:
+			return (float64(d.ByteOrder.Uint16(b)) - 32767.5) / 32767.5
+		default:
+			return (float64(d.ByteOrder.Uint32(b)) - 2147483647.5) / 2147483647.5
+		}
+	}
+}
+
+// encode writes one component; only float datatypes are written by this package
+func (d Datatype) encode(b []byte, v float64) {
+	if d.Bits == 64 {
+		d.ByteOrder.PutUint64(b, math.Float64bits(v))
+		return
+	}
+	d.ByteOrder.PutUint32(b, math.Float32bits(float32(v)))
+}
+
+var (
+	// ComplexFloat32 is the datatype used when writing complex recordings
+	ComplexFloat32 = Datatype{Complex: true, Kind: 'f', Bits: 32, ByteOrder: binary.LittleEndian}
+
+	// RealFloat32 is the datatype used when writing real recordings
+	RealFloat32 = Datatype{Kind: 'f', Bits: 32, ByteOrder: binary.LittleEndian}
+)
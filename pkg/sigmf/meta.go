@@ -0,0 +1,224 @@
+// Package sigmf reads and writes SigMF recordings: a JSON metadata file
+// (.sigmf-meta) describing a binary dataset (.sigmf-data).
+package sigmf
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Version is the SigMF specification version written to new recordings
+	Version = "1.2.0"
+
+	// Namespace is the extension namespace used for processing history
+	Namespace = "sdrparser"
+)
+
+// Meta is the content of a .sigmf-meta file
+type Meta struct {
+	Global      Global       `json:"global"`
+	Captures    []Capture    `json:"captures"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+// Global holds the recording-wide metadata. Keys not modelled here are
+// preserved in Extra so that rewriting a file does not lose them.
+type Global struct {
+	Datatype    string         `json:"core:datatype"`
+	SampleRate  float64        `json:"core:sample_rate,omitempty"`
+	Version     string         `json:"core:version"`
+	Description string         `json:"core:description,omitempty"`
+	Author      string         `json:"core:author,omitempty"`
+	Recorder    string         `json:"core:recorder,omitempty"`
+	Hardware    string         `json:"core:hw,omitempty"`
+	Extensions  []Extension    `json:"core:extensions,omitempty"`
+	History     []HistoryEntry `json:"sdrparser:history,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Extension declares a non-core namespace used in the metadata
+type Extension struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Optional bool   `json:"optional"`
+}
+
+// HistoryEntry records one processing step applied to the dataset
+type HistoryEntry struct {
+	Command    string                 `json:"command"`
+	Timestamp  string                 `json:"timestamp,omitempty"`
+	Source     string                 `json:"source,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// Capture describes a contiguous segment of the dataset
+type Capture struct {
+	SampleStart uint64  `json:"core:sample_start"`
+	Frequency   float64 `json:"core:frequency,omitempty"`
+	Datetime    string  `json:"core:datetime,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Annotation marks a region of interest in time and frequency
+type Annotation struct {
+	SampleStart   uint64  `json:"core:sample_start"`
+	SampleCount   uint64  `json:"core:sample_count,omitempty"`
+	FreqLowerEdge float64 `json:"core:freq_lower_edge,omitempty"`
+	FreqUpperEdge float64 `json:"core:freq_upper_edge,omitempty"`
+	Label         string  `json:"core:label,omitempty"`
+	Comment       string  `json:"core:comment,omitempty"`
+	Generator     string  `json:"core:generator,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// NewMeta creates metadata for a new recording
+func NewMeta(datatype string, sampleRate float64) *Meta {
+	return &Meta{
+		Global: Global{
+			Datatype:   datatype,
+			SampleRate: sampleRate,
+			Version:    Version,
+		},
+		Captures:    []Capture{{SampleStart: 0}},
+		Annotations: []Annotation{},
+	}
+}
+
+// CenterFrequency returns the RF frequency of the first capture, or 0 if unknown
+func (m *Meta) CenterFrequency() float64 {
+	if len(m.Captures) == 0 {
+		return 0
+	}
+	return m.Captures[0].Frequency
+}
+
+// StartTime returns the timestamp of the first capture, or the zero time if unknown
+func (m *Meta) StartTime() time.Time {
+	if len(m.Captures) == 0 || m.Captures[0].Datetime == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, m.Captures[0].Datetime)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// AddHistory appends a processing step and declares the history extension
+func (m *Meta) AddHistory(entry HistoryEntry) {
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	m.Global.History = append(m.Global.History, entry)
+
+	for _, ext := range m.Global.Extensions {
+		if ext.Name == Namespace {
+			return
+		}
+	}
+	m.Global.Extensions = append(m.Global.Extensions, Extension{
+		Name:     Namespace,
+		Version:  "1.0.0",
+		Optional: true,
+	})
+}
+
+// AddAnnotations appends annotations, keeping them ordered by sample_start
+// as the specification requires
+func (m *Meta) AddAnnotations(annotations ...Annotation) {
+	m.Annotations = append(m.Annotations, annotations...)
+	sort.SliceStable(m.Annotations, func(i, j int) bool {
+		return m.Annotations[i].SampleStart < m.Annotations[j].SampleStart
+	})
+}
+
+func (g *Global) UnmarshalJSON(data []byte) error {
+	type plain Global
+	return unmarshalWithExtra(data, (*plain)(g), &g.Extra)
+}
+
+func (g Global) MarshalJSON() ([]byte, error) {
+	type plain Global
+	return marshalWithExtra(plain(g), g.Extra)
+}
+
+func (c *Capture) UnmarshalJSON(data []byte) error {
+	type plain Capture
+	return unmarshalWithExtra(data, (*plain)(c), &c.Extra)
+}
+
+func (c Capture) MarshalJSON() ([]byte, error) {
+	type plain Capture
+	return marshalWithExtra(plain(c), c.Extra)
+}
+
+func (a *Annotation) UnmarshalJSON(data []byte) error {
+	type plain Annotation
+	return unmarshalWithExtra(data, (*plain)(a), &a.Extra)
+}
+
+func (a Annotation) MarshalJSON() ([]byte, error) {
+	type plain Annotation
+	return marshalWithExtra(plain(a), a.Extra)
+}
+
+// unmarshalWithExtra decodes data into v and collects keys v does not
+// declare into extra
+func unmarshalWithExtra(data []byte, v interface{}, extra *map[string]json.RawMessage) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, key := range jsonKeys(v) {
+		delete(all, key)
+	}
+
+	*extra = nil
+	if len(all) > 0 {
+		*extra = all
+	}
+	return nil
+}
+
+// marshalWithExtra encodes v and merges in the preserved extra keys
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, exists := all[key]; !exists {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// jsonKeys returns the JSON object keys declared by the struct v points to
+func jsonKeys(v interface{}) []string {
+	t := reflect.TypeOf(v).Elem()
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,209 @@
+package sigmf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	MetaExt = ".sigmf-meta"
+	DataExt = ".sigmf-data"
+)
+
+// IsRecording reports whether filename names a SigMF metadata or dataset file
+func IsRecording(filename string) bool {
+	return strings.HasSuffix(filename, MetaExt) || strings.HasSuffix(filename, DataExt)
+}
+
+// Paths returns the metadata and dataset paths for a recording given by
+// its base name or either of its file names
+func Paths(name string) (metaPath, dataPath string) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, MetaExt), DataExt)
+	return name + MetaExt, name + DataExt
+}
+
+// ReadMeta reads and parses a .sigmf-meta file
+func ReadMeta(path string) (*Meta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SigMF metadata: %w", err)
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse SigMF metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// WriteMeta writes metadata as an indented .sigmf-meta file
+func WriteMeta(path string, meta *Meta) error {
+	if meta.Annotations == nil {
+		meta.Annotations = []Annotation{}
+	}
+	if meta.Captures == nil {
+		meta.Captures = []Capture{}
+	}
+
+	data, err := json.MarshalIndent(meta, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SigMF metadata: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write SigMF metadata: %w", err)
+	}
+	return nil
+}
+
+// AppendAnnotations adds annotations to an existing .sigmf-meta file,
+// preserving all other metadata
+func AppendAnnotations(name string, annotations ...Annotation) error {
+	metaPath, _ := Paths(name)
+	meta, err := ReadMeta(metaPath)
+	if err != nil {
+		return err
+	}
+	meta.AddAnnotations(annotations...)
+	return WriteMeta(metaPath, meta)
+}
+
+// Recording is an opened SigMF recording
+type Recording struct {
+	Meta     *Meta
+	MetaPath string
+	DataPath string
+	datatype Datatype
+}
+
+// Open reads the metadata of a recording and validates its datatype
+func Open(name string) (*Recording, error) {
+	metaPath, dataPath := Paths(name)
+	meta, err := ReadMeta(metaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	datatype, err := ParseDatatype(meta.Global.Datatype)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Global.SampleRate <= 0 {
+		return nil, fmt.Errorf("SigMF metadata has no core:sample_rate")
+	}
+	if n, ok := meta.Global.Extra["core:num_channels"]; ok && string(n) != "1" {
+		return nil, fmt.Errorf("multi-channel SigMF recordings are not supported")
+	}
+
+	return &Recording{
+		Meta:     meta,
+		MetaPath: metaPath,
+		DataPath: dataPath,
+		datatype: datatype,
+	}, nil
+}
+
+// Datatype returns the parsed core:datatype of the recording
+func (r *Recording) Datatype() Datatype {
+	return r.datatype
+}
+
+// ReadComplex reads the whole dataset as complex samples; real datasets
+// are returned with a zero quadrature component
+func (r *Recording) ReadComplex() ([]complex128, error) {
+	var samples []complex128
+	err := r.readDataset(func(b []byte) {
+		size := r.datatype.ComponentSize()
+		if r.datatype.Complex {
+			samples = append(samples, complex(r.datatype.decode(b[:size]), r.datatype.decode(b[size:])))
+		} else {
+			samples = append(samples, complex(r.datatype.decode(b), 0))
+		}
+	})
+	return samples, err
+}
+
+// ReadReal reads the whole dataset of a real-valued recording
+func (r *Recording) ReadReal() ([]float64, error) {
+	if r.datatype.Complex {
+		return nil, fmt.Errorf("recording is complex (%s)", r.datatype)
+	}
+
+	var samples []float64
+	err := r.readDataset(func(b []byte) {
+		samples = append(samples, r.datatype.decode(b))
+	})
+	return samples, err
+}
+
+// readDataset calls fn with the encoded bytes of each sample in the dataset
+func (r *Recording) readDataset(fn func(b []byte)) error {
+	file, err := os.Open(r.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to open SigMF dataset: %w", err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, r.datatype.SampleSize())
+	in := bufio.NewReader(file)
+	for {
+		if _, err := io.ReadFull(in, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read SigMF dataset: %w", err)
+		}
+		fn(buf)
+	}
+}
+
+// WriteComplex writes complex samples as a cf32_le recording with the given metadata
+func WriteComplex(name string, samples []complex128, meta *Meta) error {
+	meta.Global.Datatype = ComplexFloat32.String()
+	return writeRecording(name, meta, len(samples), func(b []byte, i int) {
+		ComplexFloat32.encode(b[:4], real(samples[i]))
+		ComplexFloat32.encode(b[4:], imag(samples[i]))
+	})
+}
+
+// WriteReal writes real samples as an rf32_le recording with the given metadata
+func WriteReal(name string, samples []float64, meta *Meta) error {
+	meta.Global.Datatype = RealFloat32.String()
+	return writeRecording(name, meta, len(samples), func(b []byte, i int) {
+		RealFloat32.encode(b, samples[i])
+	})
+}
+
+func writeRecording(name string, meta *Meta, count int, encode func(b []byte, i int)) error {
+	metaPath, dataPath := Paths(name)
+	datatype, err := ParseDatatype(meta.Global.Datatype)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(dataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create SigMF dataset: %w", err)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	buf := make([]byte, datatype.SampleSize())
+	for i := 0; i < count; i++ {
+		encode(buf, i)
+		if _, err := out.Write(buf); err != nil {
+			return fmt.Errorf("failed to write SigMF dataset: %w", err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write SigMF dataset: %w", err)
+	}
+
+	if meta.Global.Version == "" {
+		meta.Global.Version = Version
+	}
+	return WriteMeta(metaPath, meta)
+}
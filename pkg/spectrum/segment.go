@@ -0,0 +1,104 @@
+package spectrum
+
+import (
+	"github.com/Vivirinter/sdr-parser/pkg/fft"
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+)
+
+// segmenter cuts a signal arriving in blocks into segments of a fixed
+// length whose starts are step samples apart
+type segmenter struct {
+	length  int
+	step    int
+	pending []complex128 // Samples not yet part of a complete segment
+}
+
+func newSegmenter(length, step int) *segmenter {
+	return &segmenter{length: length, step: step}
+}
+
+// addReal appends a real block and calls fn for every completed segment
+func (s *segmenter) addReal(samples []float64, fn func(segment []complex128)) {
+	for _, v := range samples {
+		s.pending = append(s.pending, complex(v, 0))
+	}
+	s.consume(fn)
+}
+
+// addIQ appends a complex block and calls fn for every completed segment
+func (s *segmenter) addIQ(samples []complex128, fn func(segment []complex128)) {
+	s.pending = append(s.pending, samples...)
+	s.consume(fn)
+}
+
+func (s *segmenter) consume(fn func(segment []complex128)) {
+	start := 0
+	for ; start+s.length <= len(s.pending); start += s.step {
+		fn(s.pending[start : start+s.length])
+	}
+	s.pending = append(s.pending[:0], s.pending[min(start, len(s.pending)):]...)
+}
+
+// analyzer computes windowed periodograms scaled to a power spectral density
+type analyzer struct {
+	window []float64
+	scale  float64 // 1 / (sampleRate * sum(w²))
+	plan   *fft.Plan
+	work   []complex128
+}
+
+func newAnalyzer(t window.Type, n int, beta, sampleRate float64) (*analyzer, error) {
+	w, err := window.Periodic(t, n, beta)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := fft.NewPlan(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var energy float64
+	for _, v := range w {
+		energy += v * v
+	}
+	return &analyzer{
+		window: w,
+		scale:  1 / (sampleRate * energy),
+		plan:   plan,
+		work:   make([]complex128, n),
+	}, nil
+}
+
+// periodogram writes the density of one segment to dst in FFT order
+func (a *analyzer) periodogram(dst []float64, segment []complex128) {
+	for i, v := range segment {
+		a.work[i] = v * complex(a.window[i], 0)
+	}
+	a.plan.Forward(a.work, a.work)
+	for k, v := range a.work {
+		dst[k] = (real(v)*real(v) + imag(v)*imag(v)) * a.scale
+	}
+}
+
+// arrange orders a density in FFT order by ascending frequency: two-sided
+// for complex input, and one-sided for real input with the negative
+// frequencies folded onto the positive ones
+func arrange(density []float64, sampleRate float64, iq bool) ([]float64, []float64) {
+	n := len(density)
+	if iq {
+		return fft.Shift(fft.Frequencies(n, sampleRate)), fft.Shift(density)
+	}
+
+	// DC and Nyquist appear once
+	bins := n/2 + 1
+	freqs := make([]float64, bins)
+	power := make([]float64, bins)
+	for k := range power {
+		freqs[k] = float64(k) * sampleRate / float64(n)
+		power[k] = density[k]
+		if k > 0 && !(n%2 == 0 && k == n/2) {
+			power[k] *= 2
+		}
+	}
+	return freqs, power
+}
@@ -0,0 +1,140 @@
+package spectrum
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+)
+
+// SpectrogramConfig selects the frames of a short-time Fourier transform
+type SpectrogramConfig struct {
+	FFTSize      int         // Samples per frame
+	Hop          int         // Samples between frame starts
+	Window       window.Type // Frame window
+	Beta         float64     // Kaiser shape parameter
+	FramesPerRow int         // Consecutive frames averaged into one row (default 1)
+}
+
+// Validate checks the configuration
+func (c SpectrogramConfig) Validate() error {
+	if c.FFTSize < 2 {
+		return fmt.Errorf("FFT size must be at least 2, got %d", c.FFTSize)
+	}
+	if c.Hop < 1 {
+		return fmt.Errorf("hop must be positive, got %d", c.Hop)
+	}
+	if c.FramesPerRow < 0 {
+		return fmt.Errorf("frames per row must not be negative, got %d", c.FramesPerRow)
+	}
+	return c.Window.Validate()
+}
+
+// Spectrogram accumulates a short-time Fourier transform block by block.
+// Each row holds the power spectral density in dB of FramesPerRow
+// consecutive frames, averaged so that long captures fit a bounded number
+// of rows; rows are ordered in time and bins by ascending frequency.
+type Spectrogram struct {
+	config     SpectrogramConfig
+	sampleRate float64
+	iq         bool
+	segments   *segmenter
+	analyzer   *analyzer
+	periodic   []float64   // Periodogram of the current frame, in FFT order
+	sum        []float64   // Sum of the periodograms of the current row
+	frames     int         // Frames in the current row
+	total      int         // Frames added so far
+	rows       [][]float32 // Completed rows
+}
+
+// NewSpectrogram creates a spectrogram for real (iq false) or complex input
+func NewSpectrogram(config SpectrogramConfig, sampleRate float64, iq bool) (*Spectrogram, error) {
+	if config.FramesPerRow == 0 {
+		config.FramesPerRow = 1
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate must be positive")
+	}
+
+	analyzer, err := newAnalyzer(config.Window, config.FFTSize, config.Beta, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Spectrogram{
+		config:     config,
+		sampleRate: sampleRate,
+		iq:         iq,
+		segments:   newSegmenter(config.FFTSize, config.Hop),
+		analyzer:   analyzer,
+		periodic:   make([]float64, config.FFTSize),
+		sum:        make([]float64, config.FFTSize),
+	}, nil
+}
+
+// AddReal adds a block of a real signal
+func (s *Spectrogram) AddReal(samples []float64) {
+	s.segments.addReal(samples, s.add)
+}
+
+// AddIQ adds a block of a complex signal
+func (s *Spectrogram) AddIQ(samples []complex128) {
+	s.segments.addIQ(samples, s.add)
+}
+
+// add accumulates the periodogram of one frame
+func (s *Spectrogram) add(frame []complex128) {
+	s.analyzer.periodogram(s.periodic, frame)
+	for k, v := range s.periodic {
+		s.sum[k] += v
+	}
+	s.frames++
+	s.total++
+	if s.frames == s.config.FramesPerRow {
+		s.rows = append(s.rows, s.row())
+	}
+}
+
+// row converts the current sum into a row in dB and starts the next one
+func (s *Spectrogram) row() []float32 {
+	density := make([]float64, len(s.sum))
+	for k, v := range s.sum {
+		density[k] = v / float64(s.frames)
+		s.sum[k] = 0
+	}
+	s.frames = 0
+
+	_, power := arrange(density, s.sampleRate, s.iq)
+	row := make([]float32, len(power))
+	for k, v := range power {
+		row[k] = float32(10 * math.Log10(math.Max(v, 1e-300)))
+	}
+	return row
+}
+
+// Frames returns the number of frames added so far
+func (s *Spectrogram) Frames() int {
+	return s.total
+}
+
+// Rows returns the rows added so far, completing a partial last row
+func (s *Spectrogram) Rows() [][]float32 {
+	if s.frames > 0 {
+		s.rows = append(s.rows, s.row())
+	}
+	return s.rows
+}
+
+// Frequencies returns the bin frequencies in Hz: from -fs/2 for complex
+// input, and from 0 to fs/2 for real input
+func (s *Spectrogram) Frequencies() []float64 {
+	freqs, _ := arrange(make([]float64, s.config.FFTSize), s.sampleRate, s.iq)
+	return freqs
+}
+
+// RowDuration returns the time in seconds between the starts of two rows
+func (s *Spectrogram) RowDuration() float64 {
+	return float64(s.config.Hop*s.config.FramesPerRow) / s.sampleRate
+}
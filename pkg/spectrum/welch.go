@@ -0,0 +1,187 @@
+// Package spectrum estimates the power spectral density of real and complex
+// signals
+package spectrum
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+)
+
+// WelchConfig selects the segmentation and window of a Welch estimate
+type WelchConfig struct {
+	SegmentLength int         `json:"segment_length"`
+	Overlap       float64     `json:"overlap"` // Fraction of a segment shared with the next, in [0, 1)
+	Window        window.Type `json:"window"`
+	Beta          float64     `json:"beta,omitempty"` // Kaiser shape parameter
+}
+
+// Validate checks the configuration
+func (c WelchConfig) Validate() error {
+	if c.SegmentLength < 2 {
+		return fmt.Errorf("segment length must be at least 2, got %d", c.SegmentLength)
+	}
+	if c.Overlap < 0 || c.Overlap >= 1 {
+		return fmt.Errorf("overlap must be in [0, 1), got %g", c.Overlap)
+	}
+	if c.step() < 1 {
+		return fmt.Errorf("overlap %g leaves no step between segments of %d samples", c.Overlap, c.SegmentLength)
+	}
+	return c.Window.Validate()
+}
+
+// step returns the number of samples between segment starts
+func (c WelchConfig) step() int {
+	return c.SegmentLength - int(math.Round(c.Overlap*float64(c.SegmentLength)))
+}
+
+// PSD is a power spectral density estimate
+type PSD struct {
+	Frequencies []float64 // Bin frequencies in Hz, ascending
+	Power       []float64 // Power spectral density in units²/Hz
+	SampleRate  float64
+	Segments    int  // Number of averaged segments
+	OneSided    bool // Set for real input, whose negative frequencies are folded in
+}
+
+// PowerDB returns the density in dB relative to 1 unit²/Hz
+func (p *PSD) PowerDB() []float64 {
+	db := make([]float64, len(p.Power))
+	for i, v := range p.Power {
+		db[i] = 10 * math.Log10(math.Max(v, 1e-300))
+	}
+	return db
+}
+
+// Shift offsets the bin frequencies, e.g. by the center frequency of an
+// I/Q capture to give absolute RF frequencies
+func (p *PSD) Shift(offset float64) {
+	for i := range p.Frequencies {
+		p.Frequencies[i] += offset
+	}
+}
+
+// Peak returns the frequency and density of the strongest bin
+func (p *PSD) Peak() (float64, float64) {
+	best := 0
+	for k, v := range p.Power {
+		if v > p.Power[best] {
+			best = k
+		}
+	}
+	return p.Frequencies[best], p.Power[best]
+}
+
+// TotalPower integrates the density over frequency
+func (p *PSD) TotalPower() float64 {
+	if len(p.Frequencies) < 2 {
+		return 0
+	}
+	binWidth := p.Frequencies[1] - p.Frequencies[0]
+	var sum float64
+	for _, v := range p.Power {
+		sum += v
+	}
+	return sum * binWidth
+}
+
+// Welch accumulates a Welch PSD estimate block by block: the signal is cut
+// into overlapping windowed segments whose periodograms are averaged.
+// Blocks may have any length; a trailing partial segment is ignored.
+type Welch struct {
+	config     WelchConfig
+	sampleRate float64
+	iq         bool
+	segments   *segmenter
+	analyzer   *analyzer
+	periodic   []float64 // Periodogram of the current segment, in FFT order
+	sum        []float64 // Sum of periodograms in FFT order
+	count      int
+}
+
+// NewWelch creates an estimator for real (iq false) or complex input
+func NewWelch(config WelchConfig, sampleRate float64, iq bool) (*Welch, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate must be positive")
+	}
+
+	analyzer, err := newAnalyzer(config.Window, config.SegmentLength, config.Beta, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Welch{
+		config:     config,
+		sampleRate: sampleRate,
+		iq:         iq,
+		segments:   newSegmenter(config.SegmentLength, config.step()),
+		analyzer:   analyzer,
+		periodic:   make([]float64, config.SegmentLength),
+		sum:        make([]float64, config.SegmentLength),
+	}, nil
+}
+
+// AddReal adds a block of a real signal
+func (w *Welch) AddReal(samples []float64) {
+	w.segments.addReal(samples, w.add)
+}
+
+// AddIQ adds a block of a complex signal
+func (w *Welch) AddIQ(samples []complex128) {
+	w.segments.addIQ(samples, w.add)
+}
+
+// add accumulates the periodogram of one segment
+func (w *Welch) add(segment []complex128) {
+	w.analyzer.periodogram(w.periodic, segment)
+	for k, v := range w.periodic {
+		w.sum[k] += v
+	}
+	w.count++
+}
+
+// Segments returns the number of segments averaged so far
+func (w *Welch) Segments() int {
+	return w.count
+}
+
+// Result returns the estimate from the segments added so far. Complex
+// input gives a two-sided density from -fs/2 to fs/2; real input gives a
+// one-sided density from 0 to fs/2.
+func (w *Welch) Result() (*PSD, error) {
+	if w.count == 0 {
+		return nil, fmt.Errorf("signal is shorter than one segment of %d samples", w.config.SegmentLength)
+	}
+
+	density := make([]float64, len(w.sum))
+	for k, v := range w.sum {
+		density[k] = v / float64(w.count)
+	}
+
+	psd := &PSD{SampleRate: w.sampleRate, Segments: w.count, OneSided: !w.iq}
+	psd.Frequencies, psd.Power = arrange(density, w.sampleRate, w.iq)
+	return psd, nil
+}
+
+// WelchReal estimates the one-sided PSD of a real signal
+func WelchReal(samples []float64, sampleRate float64, config WelchConfig) (*PSD, error) {
+	w, err := NewWelch(config, sampleRate, false)
+	if err != nil {
+		return nil, err
+	}
+	w.AddReal(samples)
+	return w.Result()
+}
+
+// WelchIQ estimates the two-sided PSD of a complex baseband signal
+func WelchIQ(samples []complex128, sampleRate float64, config WelchConfig) (*PSD, error) {
+	w, err := NewWelch(config, sampleRate, true)
+	if err != nil {
+		return nil, err
+	}
+	w.AddIQ(samples)
+	return w.Result()
+}
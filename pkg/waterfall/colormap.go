@@ -0,0 +1,71 @@
+package waterfall
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Colormap names a mapping from normalized intensity to colour
+type Colormap string
+
+const (
+	Viridis   Colormap = "viridis"
+	Inferno   Colormap = "inferno"
+	Grayscale Colormap = "grayscale"
+)
+
+func (c Colormap) String() string {
+	return string(c)
+}
+
+// Validate checks that the colormap is supported
+func (c Colormap) Validate() error {
+	if _, ok := colormapStops[c]; !ok {
+		return fmt.Errorf("unsupported colormap: %s", c)
+	}
+	return nil
+}
+
+// colormapStops holds evenly spaced samples of each colormap, linearly
+// interpolated in between. Viridis and inferno are sampled from the
+// matplotlib tables at multiples of 1/8.
+var colormapStops = map[Colormap][]color.RGBA{
+	Viridis: {
+		{68, 1, 84, 255}, {72, 40, 120, 255}, {62, 74, 137, 255},
+		{49, 104, 142, 255}, {38, 130, 142, 255}, {31, 158, 137, 255},
+		{53, 183, 121, 255}, {110, 206, 88, 255}, {253, 231, 37, 255},
+	},
+	Inferno: {
+		{0, 0, 4, 255}, {31, 12, 72, 255}, {85, 15, 109, 255},
+		{136, 34, 106, 255}, {186, 54, 85, 255}, {227, 89, 51, 255},
+		{249, 142, 9, 255}, {248, 201, 50, 255}, {252, 255, 164, 255},
+	},
+	Grayscale: {
+		{0, 0, 0, 255}, {255, 255, 255, 255},
+	},
+}
+
+// At returns the colour for an intensity in [0, 1]; values outside the
+// range are clamped
+func (c Colormap) At(v float64) color.RGBA {
+	stops := colormapStops[c]
+	if len(stops) == 0 {
+		stops = colormapStops[Grayscale]
+	}
+	if math.IsNaN(v) || v < 0 {
+		v = 0
+	}
+	if v > 1 {
+		v = 1
+	}
+
+	pos := v * float64(len(stops)-1)
+	i := min(int(pos), len(stops)-2)
+	frac := pos - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + frac*(float64(b)-float64(a))))
+	}
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
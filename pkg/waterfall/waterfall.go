@@ -0,0 +1,262 @@
+// Package waterfall renders spectrograms as images with time and frequency
+// axes, using only the standard library
+package waterfall
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// DefaultMaxWidth caps the plot width when Options.Width is 0; wider
+// spectra are reduced by keeping the strongest bin of each column
+const DefaultMaxWidth = 2048
+
+// Options controls the appearance of a rendered waterfall
+type Options struct {
+	Colormap Colormap
+	MinDB    float64 // Level drawn with the lowest colour
+	MaxDB    float64 // Level drawn with the highest colour
+	Width    int     // Plot width in pixels; 0 gives one column per bin
+	Height   int     // Plot height in pixels; 0 gives one row per spectrum
+}
+
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	foreground = color.RGBA{0, 0, 0, 255}
+)
+
+// Render draws rows of power in dB, ordered in time from the top, against
+// the bin frequencies in Hz. rowDuration is the time between rows in
+// seconds and labels the time axis.
+func Render(rows [][]float32, frequencies []float64, rowDuration float64, opts Options) (*image.RGBA, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no spectra to render")
+	}
+	bins := len(frequencies)
+	if bins < 2 {
+		return nil, fmt.Errorf("at least 2 frequency bins are required, got %d", bins)
+	}
+	for i, row := range rows {
+		if len(row) != bins {
+			return nil, fmt.Errorf("row %d has %d bins, expected %d", i, len(row), bins)
+		}
+	}
+	if err := opts.Colormap.Validate(); err != nil {
+		return nil, err
+	}
+	if !(opts.MaxDB > opts.MinDB) {
+		return nil, fmt.Errorf("dB range is empty: %g to %g", opts.MinDB, opts.MaxDB)
+	}
+	if opts.Width < 0 || opts.Height < 0 {
+		return nil, fmt.Errorf("plot size must not be negative")
+	}
+
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = min(bins, DefaultMaxWidth)
+	}
+	if height == 0 {
+		height = len(rows)
+	}
+
+	// Layout: time labels left, frequency labels below, colour bar right
+	scale := 1
+	if width >= 800 {
+		scale = 2
+	}
+	pad, tick := 4*scale, 4*scale
+	lineHeight := glyphHeight * scale
+
+	duration := float64(len(rows)) * rowDuration
+	timeTicks := ticks(0, duration, max(2, height/(lineHeight*4)))
+	timeLabels := labels(timeTicks, 1)
+	labelWidth := 0
+	for _, l := range timeLabels {
+		labelWidth = max(labelWidth, textWidth(l, scale))
+	}
+
+	unit, unitName := frequencyUnit(frequencies)
+	levelTicks := ticks(opts.MinDB, opts.MaxDB, max(2, height/(lineHeight*4)))
+	levelLabels := labels(levelTicks, 1)
+	levelWidth := 0
+	for _, l := range levelLabels {
+		levelWidth = max(levelWidth, textWidth(l, scale))
+	}
+	barWidth := 12 * scale
+
+	left := pad + labelWidth + pad + tick
+	top := pad + lineHeight + pad
+	bottom := tick + pad + lineHeight + 2*pad + lineHeight + 2*pad
+	right := 3*pad + barWidth + tick + pad + levelWidth + pad
+	plot := image.Rect(left, top, left+width, top+height)
+
+	img := image.NewRGBA(image.Rect(0, 0, left+width+right, top+height+bottom))
+	fill(img, img.Bounds(), background)
+
+	// Spectra, reduced or stretched to the plot size by keeping the
+	// strongest bin and spectrum under each pixel
+	span := opts.MaxDB - opts.MinDB
+	for y := 0; y < height; y++ {
+		r0, r1 := cover(y, height, len(rows))
+		for x := 0; x < width; x++ {
+			b0, b1 := cover(x, width, bins)
+			level := float32(math.Inf(-1))
+			for _, row := range rows[r0:r1] {
+				level = max(level, slices.Max(row[b0:b1]))
+			}
+			img.SetRGBA(plot.Min.X+x, plot.Min.Y+y, opts.Colormap.At((float64(level)-opts.MinDB)/span))
+		}
+	}
+	frame(img, plot.Inset(-1))
+
+	// Time axis, in seconds from the first spectrum
+	for i, t := range timeTicks {
+		y := plot.Min.Y + int(math.Round(t/duration*float64(height)))
+		fill(img, image.Rect(plot.Min.X-1-tick, y, plot.Min.X-1, y+1), foreground)
+		l := timeLabels[i]
+		drawText(img, plot.Min.X-1-tick-pad-textWidth(l, scale), clampY(y-lineHeight/2, top, plot.Max.Y-lineHeight), l, scale, foreground)
+	}
+	drawText(img, pad, pad, "Time (s)", scale, foreground)
+
+	// Frequency axis, with pixels centred on the bins
+	binWidth := (frequencies[bins-1] - frequencies[0]) / float64(bins-1)
+	lo, hi := frequencies[0]-binWidth/2, frequencies[bins-1]+binWidth/2
+	freqTicks := ticks(frequencies[0]/unit, frequencies[bins-1]/unit, max(2, width/(glyphAdvance*scale*10)))
+	freqLabels := labels(freqTicks, 1)
+	for i, f := range freqTicks {
+		x := plot.Min.X + int(math.Round((f*unit-lo)/(hi-lo)*float64(width)))
+		fill(img, image.Rect(x, plot.Max.Y+1, x+1, plot.Max.Y+1+tick), foreground)
+		l := freqLabels[i]
+		drawText(img, x-textWidth(l, scale)/2, plot.Max.Y+1+tick+pad, l, scale, foreground)
+	}
+	title := "Frequency (" + unitName + ")"
+	drawText(img, plot.Min.X+(width-textWidth(title, scale))/2, plot.Max.Y+1+tick+pad+lineHeight+2*pad, title, scale, foreground)
+
+	// Colour bar
+	bar := image.Rect(plot.Max.X+3*pad, plot.Min.Y, plot.Max.X+3*pad+barWidth, plot.Max.Y)
+	for y := bar.Min.Y; y < bar.Max.Y; y++ {
+		v := 1 - (float64(y-bar.Min.Y)+0.5)/float64(height)
+		fill(img, image.Rect(bar.Min.X, y, bar.Max.X, y+1), opts.Colormap.At(v))
+	}
+	frame(img, bar.Inset(-1))
+	for i, level := range levelTicks {
+		y := bar.Max.Y - 1 - int(math.Round((level-opts.MinDB)/span*float64(height-1)))
+		fill(img, image.Rect(bar.Max.X+1, y, bar.Max.X+1+tick, y+1), foreground)
+		drawText(img, bar.Max.X+1+tick+pad, clampY(y-lineHeight/2, top, plot.Max.Y-lineHeight), levelLabels[i], scale, foreground)
+	}
+	drawText(img, bar.Min.X, pad, "dB", scale, foreground)
+
+	return img, nil
+}
+
+// AutoRange picks a dB range for rows: from the 10th percentile, near the
+// noise floor, to the strongest level
+func AutoRange(rows [][]float32) (float64, float64) {
+	var levels []float32
+	total := 0
+	for _, row := range rows {
+		total += len(row)
+	}
+	// Percentile from at most about a million levels
+	stride := max(1, total/(1<<20))
+	i := 0
+	peak := float32(math.Inf(-1))
+	for _, row := range rows {
+		for _, v := range row {
+			peak = max(peak, v)
+			if i%stride == 0 {
+				levels = append(levels, v)
+			}
+			i++
+		}
+	}
+	if len(levels) == 0 {
+		return 0, 1
+	}
+
+	slices.Sort(levels)
+	floor := float64(levels[len(levels)/10])
+	if !(float64(peak) > floor) {
+		return floor - 1, floor + 1
+	}
+	return floor, float64(peak)
+}
+
+// cover returns the range of n items shown by pixel i of size pixels
+func cover(i, size, n int) (int, int) {
+	lo := i * n / size
+	hi := max(lo+1, (i+1)*n/size)
+	return lo, hi
+}
+
+// frame draws a one pixel outline on the edge of r
+func frame(img *image.RGBA, r image.Rectangle) {
+	fill(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), foreground)
+	fill(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), foreground)
+	fill(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), foreground)
+	fill(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), foreground)
+}
+
+func clampY(y, lo, hi int) int {
+	return max(lo, min(y, hi))
+}
+
+// ticks returns round values in [lo, hi], at most about count of them, at a
+// step of 1, 2 or 5 times a power of ten
+func ticks(lo, hi float64, count int) []float64 {
+	if !(hi > lo) {
+		return []float64{lo}
+	}
+	raw := (hi - lo) / float64(count)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * magnitude
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+
+	var values []float64
+	for v := math.Ceil(lo/step) * step; v <= hi+step*1e-9; v += step {
+		if math.Abs(v) < step*1e-9 {
+			v = 0
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// labels formats tick values divided by unit with just enough decimals to
+// tell neighbouring ticks apart
+func labels(values []float64, unit float64) []string {
+	decimals := 0
+	if len(values) > 1 {
+		step := (values[1] - values[0]) / unit
+		decimals = max(0, int(-math.Floor(math.Log10(step)+1e-9)))
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strconv.FormatFloat(v/unit, 'f', decimals, 64)
+	}
+	return out
+}
+
+// frequencyUnit picks the unit of the frequency axis from the largest
+// frequency shown
+func frequencyUnit(frequencies []float64) (float64, string) {
+	largest := math.Max(math.Abs(frequencies[0]), math.Abs(frequencies[len(frequencies)-1]))
+	switch {
+	case largest >= 1e9:
+		return 1e9, "GHz"
+	case largest >= 1e6:
+		return 1e6, "MHz"
+	case largest >= 1e3:
+		return 1e3, "kHz"
+	}
+	return 1, "Hz"
+}
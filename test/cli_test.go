@@ -0,0 +1,295 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/internal/cli"
+	"github.com/Vivirinter/sdr-parser/pkg/reader"
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
+)
+
+func TestCLIRealSSB(t *testing.T) {
+	// A 1 kHz tone above and a 1.8 kHz tone below a 3 kHz carrier, as a
+	// real WAV recording: each sideband demodulates to its own tone
+	const fs = 12000.0
+	const carrier = 3000.0
+	usbTone := cosine(carrier+1000, fs, 12000)
+	lsbTone := cosine(carrier-1800, fs, 12000)
+	signal := make([]float64, len(usbTone))
+	for i := range signal {
+		signal[i] = 0.4 * (usbTone[i] + lsbTone[i])
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "ssb.wav")
+	if err := reader.WriteWavFile(input, signal, fs); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	if err := cli.Run([]string{"demod", "-i", input, "-t", "lsb", "-o", filepath.Join(dir, "none.wav")}); err == nil {
+		t.Error("Expected error for SSB of real input without --carrier")
+	}
+
+	tests := []struct {
+		demodType     string
+		wanted, other float64
+	}{
+		{"usb", 1000, 1800},
+		{"lsb", 1800, 1000},
+	}
+	for _, tt := range tests {
+		output := filepath.Join(dir, tt.demodType+".wav")
+		args := []string{"demod", "-i", input, "-t", tt.demodType, "--carrier", "3000", "-o", output}
+		if err := cli.Run(args); err != nil {
+			t.Fatalf("%s: demod failed: %v", tt.demodType, err)
+		}
+		audio, rate, err := reader.ReadWavFile(output)
+		if err != nil {
+			t.Fatalf("%s: failed to read output: %v", tt.demodType, err)
+		}
+		if rate != fs || len(audio) != len(signal) {
+			t.Fatalf("%s: got %d samples at %g Hz, expected %d at %g Hz", tt.demodType, len(audio), rate, len(signal), fs)
+		}
+
+		// Skip the Hilbert transformer transients
+		skip := int(fs) / 10
+		wanted := toneAmplitude(audio, tt.wanted, fs, skip)
+		other := toneAmplitude(audio, tt.other, fs, skip)
+		if wanted < 0.5 {
+			t.Errorf("%s: %g Hz amplitude %f, expected it near the normalized peak 0.7", tt.demodType, tt.wanted, wanted)
+		}
+		if rejection := 20 * math.Log10(wanted/other); rejection < 30 {
+			t.Errorf("%s: opposite sideband rejected by %.1f dB, expected at least 30 dB", tt.demodType, rejection)
+		}
+	}
+}
+
+func TestCLIGainModes(t *testing.T) {
+	// A WAV recording is streamed, unless --sigmf output needs it whole;
+	// either way each gain mode gives the same audio
+	const fs = 12000.0
+	signal := make([]float64, 3*int(fs))
+	for i := range signal {
+		envelope := 0.2 + 0.1*math.Sin(2*math.Pi*2*float64(i)/fs) + 0.6*float64(i)/float64(len(signal))
+		signal[i] = envelope * math.Cos(2*math.Pi*3000*float64(i)/fs)
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "am.wav")
+	if err := reader.WriteWavFile(input, signal, fs); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, gain := range []string{"normalize", "agc", "fixed"} {
+		var outputs [2][]float64
+		for i, extra := range [][]string{nil, {"--sigmf"}} {
+			output := filepath.Join(dir, fmt.Sprintf("%s_%d.wav", gain, i))
+			args := append([]string{"demod", "-i", input, "-t", "am", "--gain", gain, "-o", output}, extra...)
+			if err := cli.Run(args); err != nil {
+				t.Fatalf("%s: demod failed: %v", gain, err)
+			}
+			audio, _, err := reader.ReadWavFile(output)
+			if err != nil {
+				t.Fatalf("%s: failed to read output: %v", gain, err)
+			}
+			outputs[i] = audio
+		}
+		streamed, whole := outputs[0], outputs[1]
+		if len(streamed) != len(whole) {
+			t.Fatalf("%s: streamed %d samples, whole %d", gain, len(streamed), len(whole))
+		}
+		for i := range streamed {
+			if math.Abs(streamed[i]-whole[i]) > 1.5/32767 {
+				t.Fatalf("%s: sample %d streamed %g, whole %g", gain, i, streamed[i], whole[i])
+			}
+		}
+		if gain == "normalize" {
+			if peak := peakAmplitude(whole); math.Abs(peak-0.7) > 1e-3 {
+				t.Errorf("normalize: peak %g, expected 0.7", peak)
+			}
+		}
+	}
+
+	if err := cli.Run([]string{"demod", "-i", input, "--gain", "loud", "-o", filepath.Join(dir, "x.wav")}); err == nil {
+		t.Error("Expected error for an unknown gain mode")
+	}
+}
+
+// centredMedian is the median over a window centred on each sample and
+// cut short at both ends of the signal
+func centredMedian(x []float64, window int) []float64 {
+	half := window / 2
+	y := make([]float64, len(x))
+	for i := range x {
+		w := append([]float64(nil), x[max(0, i-half):min(len(x), i+half+1)]...)
+		sort.Float64s(w)
+		if len(w)%2 == 0 {
+			y[i] = (w[len(w)/2-1] + w[len(w)/2]) / 2
+		} else {
+			y[i] = w[len(w)/2]
+		}
+	}
+	return y
+}
+
+func TestCLIFilterAlignment(t *testing.T) {
+	// filter drops the delay of the median and linear-phase FIR filters, so
+	// their output lines up with the input, streamed or read whole
+	const fs = 8000.0
+	rng := rand.New(rand.NewSource(1))
+	signal := make([]float64, 5000)
+	for i := range signal {
+		signal[i] = 0.5 * math.Sin(2*math.Pi*50*float64(i)/fs)
+		if rng.Intn(50) == 0 {
+			signal[i] = 0.9
+		}
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.wav")
+	if err := reader.WriteWavFile(input, signal, fs); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	quantized, _, err := reader.ReadWavFile(input)
+	if err != nil {
+		t.Fatalf("Failed to read input: %v", err)
+	}
+
+	run := func(name, input string, args ...string) []float64 {
+		output := filepath.Join(dir, name+".wav")
+		if err := cli.Run(append([]string{"filter", "-i", input, "-o", output}, args...)); err != nil {
+			t.Fatalf("%s: filter failed: %v", name, err)
+		}
+		y, _, err := reader.ReadWavFile(output)
+		if err != nil {
+			t.Fatalf("%s: failed to read output: %v", name, err)
+		}
+		if len(y) != len(signal) {
+			t.Fatalf("%s: output has %d samples, expected %d", name, len(y), len(signal))
+		}
+		return y
+	}
+
+	expected := centredMedian(quantized, 7)
+	for _, extra := range [][]string{nil, {"--sigmf"}} {
+		name := "median" + strings.Join(extra, "")
+		y := run(name, input, append([]string{"-t", "median", "-w", "7"}, extra...)...)
+		for i := range y {
+			if math.Abs(y[i]-expected[i]) > 1.0/32767 {
+				t.Fatalf("%s: sample %d is %g, centred median %g", name, i, y[i], expected[i])
+			}
+		}
+	}
+
+	// A low-pass FIR passes a 50 Hz tone without delay
+	tone := filepath.Join(dir, "tone.wav")
+	if err := reader.WriteWavFile(tone, cosine(50, fs, len(signal)), fs); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	y := run("kaiser", tone, "-t", "fir_kaiser", "-c", "1000", "--transition", "400")
+	for i := 200; i < len(y)-200; i++ {
+		if expected := math.Cos(2 * math.Pi * 50 * float64(i) / fs); math.Abs(y[i]-expected) > 0.002 {
+			t.Fatalf("kaiser: sample %d is %g, expected %g", i, y[i], expected)
+		}
+	}
+}
+
+func TestCLISigMFAnnotations(t *testing.T) {
+	// Annotations of SigMF input follow the output sample rate, and demodulated
+	// audio loses their RF frequency edges
+	const fs = 48000.0
+	dir := t.TempDir()
+	input := filepath.Join(dir, "capture")
+
+	samples := make([]complex128, 48000)
+	for i := range samples {
+		samples[i] = complex(0.5+0.25*math.Cos(2*math.Pi*1000*float64(i)/fs), 0)
+	}
+	meta := sigmf.NewMeta("", fs)
+	meta.Captures[0].Frequency = 100e6
+	meta.AddAnnotations(sigmf.Annotation{
+		SampleStart:   4800,
+		SampleCount:   2400,
+		FreqLowerEdge: 100e6 - 5e3,
+		FreqUpperEdge: 100e6 + 5e3,
+		Label:         "burst",
+	})
+	if err := sigmf.WriteComplex(input, samples, meta); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	output := filepath.Join(dir, "audio.wav")
+	args := []string{"demod", "-i", input + sigmf.MetaExt, "-t", "am", "--audio-rate", "12000", "--sigmf", "-o", output}
+	if err := cli.Run(args); err != nil {
+		t.Fatalf("demod failed: %v", err)
+	}
+	result, err := sigmf.ReadMeta(sigmfPath(output))
+	if err != nil {
+		t.Fatalf("Failed to read output metadata: %v", err)
+	}
+	if len(result.Annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, got %+v", result.Annotations)
+	}
+	a := result.Annotations[0]
+	if a.SampleStart != 1200 || a.SampleCount != 600 || a.Label != "burst" {
+		t.Errorf("Expected the burst at samples 1200+600, got %+v", a)
+	}
+	if a.FreqLowerEdge != 0 || a.FreqUpperEdge != 0 {
+		t.Errorf("Expected no frequency edges on audio, got %+v", a)
+	}
+}
+
+// sigmfPath returns the metadata path of the SigMF copy written with output
+func sigmfPath(output string) string {
+	metaPath, _ := sigmf.Paths(strings.TrimSuffix(output, filepath.Ext(output)))
+	return metaPath
+}
+
+func TestCLIFilterIQ(t *testing.T) {
+	// With --iq a stereo baseband WAV is filtered as I/Q, both channels,
+	// into a stereo WAV; without it the second channel is not dropped silently
+	const fs = 8000.0
+	dir := t.TempDir()
+	input := filepath.Join(dir, "baseband.wav")
+
+	i := make([]float64, 8000)
+	q := make([]float64, len(i))
+	for k := range i {
+		phase := 2 * math.Pi * 50 * float64(k) / fs
+		noise := 0.2 * math.Cos(2*math.Pi*3000*float64(k)/fs)
+		i[k], q[k] = 0.7*math.Cos(phase)+noise, 0.7*math.Sin(phase)+noise
+	}
+	if err := reader.WriteWavFileChannels(input, [][]float64{i, q}, fs, reader.PCM16); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, extra := range [][]string{nil, {"--sigmf"}} {
+		name := "iq" + strings.Join(extra, "")
+		output := filepath.Join(dir, name+".wav")
+		args := []string{"filter", "-i", input, "--iq", "-t", "fir_kaiser", "-c", "1000", "--transition", "400", "-o", output}
+		if err := cli.Run(append(args, extra...)); err != nil {
+			t.Fatalf("%s: filter failed: %v", name, err)
+		}
+		y, _, err := reader.ReadWavFileIQ(output)
+		if err != nil {
+			t.Fatalf("%s: failed to read output: %v", name, err)
+		}
+		if len(y) != len(i) {
+			t.Fatalf("%s: output has %d samples, expected %d", name, len(y), len(i))
+		}
+		for k := 200; k < len(y)-200; k++ {
+			phase := 2 * math.Pi * 50 * float64(k) / fs
+			if expected := complex(0.7*math.Cos(phase), 0.7*math.Sin(phase)); cmplx.Abs(y[k]-expected) > 0.003 {
+				t.Fatalf("%s: sample %d is %v, expected %v", name, k, y[k], expected)
+			}
+		}
+	}
+
+	if err := cli.Run([]string{"filter", "-i", input, "-o", filepath.Join(dir, "mono.wav")}); err == nil {
+		t.Error("Expected error for stereo input without --iq")
+	}
+}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 
 	"github.com/Vivirinter/sdr-parser/pkg/demod"
@@ -27,8 +29,8 @@ func TestFMDemodulation(t *testing.T) {
 	
 	output, metrics := demodulator.Demodulate(samples)
 	
-	if len(output) != len(samples)-1 {
-		t.Errorf("Expected output length %d, got %d", len(samples)-1, len(output))
+	if len(output) != len(samples) {
+		t.Errorf("Expected output length %d, got %d", len(samples), len(output))
 	}
 	
 	if metrics.CurrentGain <= 0 {
@@ -61,3 +63,195 @@ func TestSSBDemodulation(t *testing.T) {
 		})
 	}
 }
+
+func TestIQDemodulation(t *testing.T) {
+	// Complex tone at +1/8 of the sample rate with unit envelope
+	samples := make([]complex128, 256)
+	for i := range samples {
+		samples[i] = cmplx.Rect(1, 2*math.Pi*float64(i)/8)
+	}
+
+	am, _ := (&demod.AMDemod{}).DemodulateIQ(samples)
+	for i, v := range am {
+		if math.Abs(v-1) > 1e-9 {
+			t.Fatalf("AM envelope at %d: expected 1, got %f", i, v)
+		}
+	}
+
+	// The tone is 1 kHz at 8 kHz, so a 1 kHz deviation gives full scale
+	fmd := demod.NewIQDemodulator(demod.DemodulatorConfig{Type: demod.FM, SampleRate: 8000, Deviation: 1000})
+	fm, _ := fmd.DemodulateIQ(samples)
+	if len(fm) != len(samples) {
+		t.Errorf("Expected FM output length %d, got %d", len(samples), len(fm))
+	}
+	for i, v := range fm[1:] {
+		if math.Abs(v-1) > 1e-9 {
+			t.Fatalf("FM output at %d: expected constant 1, got %f", i+1, v)
+		}
+	}
+
+	// A positive-frequency tone lies entirely in the upper sideband; compare
+	// un-normalized amplitudes away from the Hilbert filter edge transients
+	usb, usbMetrics := (&demod.USBDemod{}).DemodulateIQ(samples)
+	lsb, lsbMetrics := (&demod.LSBDemod{}).DemodulateIQ(samples)
+	usbAmp := peakAmplitude(usb[64:192]) / usbMetrics.CurrentGain
+	lsbAmp := peakAmplitude(lsb[64:192]) / lsbMetrics.CurrentGain
+	if lsbAmp > 0.05*usbAmp {
+		t.Errorf("Expected LSB to reject the upper sideband: USB %f, LSB %f", usbAmp, lsbAmp)
+	}
+}
+
+func peakAmplitude(samples []float64) float64 {
+	peak := 0.0
+	for _, v := range samples {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	return peak
+}
+
+func TestFMDiscriminator(t *testing.T) {
+	// A 400 Hz tone at 3 kHz deviation on a 12 kHz carrier, with the
+	// deviation configured to 3 kHz, demodulates to a full-scale tone
+	const fs = 48000.0
+	const deviation = 3000.0
+	n := 9600
+	iq := make([]complex128, n)
+	passband := make([]float64, n)
+	var phase float64
+	for i := range iq {
+		message := math.Cos(2 * math.Pi * 400 * float64(i) / fs)
+		phase += 2 * math.Pi * (12000 + deviation*message) / fs
+		iq[i] = cmplx.Rect(1, phase)
+		passband[i] = math.Cos(phase)
+	}
+
+	config := demod.DemodulatorConfig{Type: demod.FM, SampleRate: fs, Deviation: deviation}
+	d := demod.NewDemodulator(config)
+	audio, _ := d.(demod.IQDemodulator).DemodulateIQ(iq)
+	if amp := toneAmplitude(audio, 400, fs, 0); math.Abs(amp-1) > 0.01 {
+		t.Errorf("I/Q: tone amplitude %f, expected 1", amp)
+	}
+	if dc := toneAmplitude(audio, 0, fs, 0) / 2; math.Abs(dc-12000/deviation) > 0.01 {
+		t.Errorf("I/Q: carrier offset %f, expected %f", dc, 12000/deviation)
+	}
+
+	// Real input goes through the Hilbert transformer first
+	d.Reset()
+	audio, _ = d.Demodulate(passband)
+	if len(audio) != len(passband) {
+		t.Fatalf("real: output has %d samples, expected %d", len(audio), len(passband))
+	}
+	if amp := toneAmplitude(audio, 400, fs, 200); math.Abs(amp-1) > 0.02 {
+		t.Errorf("real: tone amplitude %f, expected 1", amp)
+	}
+
+	// 50 µs de-emphasis is 3 dB down at 1/(2π·50 µs) = 3183 Hz
+	corner := 1 / (2 * math.Pi * demod.Deemphasis50us)
+	for i := range iq {
+		iq[i] = cmplx.Rect(1, deviation/corner*math.Sin(2*math.Pi*corner*float64(i)/fs))
+	}
+	config.Deemphasis = demod.Deemphasis50us
+	d = demod.NewDemodulator(config)
+	audio, _ = d.(demod.IQDemodulator).DemodulateIQ(iq)
+	if gain := toneAmplitude(audio, corner, fs, 500); math.Abs(20*math.Log10(gain)+3) > 0.3 {
+		t.Errorf("de-emphasis gain at %.0f Hz is %.2f dB, expected -3 dB", corner, 20*math.Log10(gain))
+	}
+}
+
+func TestFMPresets(t *testing.T) {
+	for name, expected := range map[string]demod.FMPreset{"nbfm": demod.NBFM, "wbfm": demod.WBFM, "fm": demod.WBFM} {
+		preset, err := demod.ParseFMPreset(name)
+		if err != nil || preset != expected {
+			t.Errorf("%s: got %+v, %v", name, preset, err)
+		}
+		if demodType, err := demod.ParseDemodulationType(name); err != nil || demodType != demod.FM {
+			t.Errorf("%s: parsed as %v, %v", name, demodType, err)
+		}
+	}
+	if _, err := demod.ParseFMPreset("am"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+	if err := (demod.DemodulatorConfig{Deviation: -1}).Validate(); err == nil {
+		t.Error("expected an error for a negative deviation")
+	}
+}
+
+func TestAMGain(t *testing.T) {
+	// A rising envelope keeps its shape through a fixed gain, whether the
+	// signal is demodulated at once or in blocks
+	const n = 3000
+	envelope := make([]float64, n)
+	iq := make([]complex128, n)
+	for i := range iq {
+		envelope[i] = float64(i+1) / n
+		iq[i] = cmplx.Rect(envelope[i], 2*math.Pi*float64(i)/8)
+	}
+
+	config := demod.DemodulatorConfig{Type: demod.AM, ManualGain: 2}
+	d := demod.NewIQDemodulator(config)
+	whole, metrics := d.DemodulateIQ(iq)
+	if metrics.CurrentGain != 2 {
+		t.Errorf("Expected gain 2, got %g", metrics.CurrentGain)
+	}
+	for i, v := range whole {
+		if math.Abs(v-2*envelope[i]) > 1e-9 {
+			t.Fatalf("Output %d is %g, expected %g", i, v, 2*envelope[i])
+		}
+	}
+	d.(demod.Demodulator).Reset()
+	blocks := inBlocks(n, func(start, end int) []float64 {
+		output, _ := d.DemodulateIQ(iq[start:end])
+		return output
+	})
+	assertSameOutput(t, "AM", whole, blocks)
+
+	// Normalizing the whole output scales its peak to 0.7 and keeps the
+	// ratios between samples
+	metrics = demod.Normalize(whole)
+	if math.Abs(metrics.CurrentGain-0.35) > 1e-9 {
+		t.Errorf("Expected normalizing gain 0.35, got %g", metrics.CurrentGain)
+	}
+	for _, i := range []int{0, 10, 20, n / 2, n - 1} {
+		if expected := 0.7 * envelope[i]; math.Abs(whole[i]-expected) > 1e-9 {
+			t.Errorf("Normalized output %d is %g, expected %g", i, whole[i], expected)
+		}
+	}
+}
+
+func TestAMAGC(t *testing.T) {
+	// A step in the carrier level is brought back to the target, and the
+	// gain follows the same path in blocks
+	const fs = 48000.0
+	const n = 48000
+	iq := make([]complex128, n)
+	for i := range iq {
+		level := 0.1
+		if i >= n/2 {
+			level = 0.8
+		}
+		iq[i] = cmplx.Rect(level, 2*math.Pi*float64(i)/8)
+	}
+
+	config := demod.DemodulatorConfig{Type: demod.AM, SampleRate: fs, GainMode: demod.AGC, AGCConfig: demod.DefaultAGC}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid AGC configuration: %v", err)
+	}
+	d := demod.NewIQDemodulator(config)
+	whole, _ := d.DemodulateIQ(iq)
+	for _, i := range []int{n/2 - 1, n - 1} {
+		if math.Abs(whole[i]-demod.DefaultAGC.Target) > 0.01 {
+			t.Errorf("Output %d is %g, expected the target %g", i, whole[i], demod.DefaultAGC.Target)
+		}
+	}
+	d.(demod.Demodulator).Reset()
+	blocks := inBlocks(n, func(start, end int) []float64 {
+		output, _ := d.DemodulateIQ(iq[start:end])
+		return output
+	})
+	assertSameOutput(t, "AGC", whole, blocks)
+
+	config.SampleRate = 0
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for AGC without a sample rate")
+	}
+}
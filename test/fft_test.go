@@ -0,0 +1,152 @@
+package test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/fft"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+// fftSizes covers powers of two, mixed radices, small primes and primes
+// large enough to use Bluestein's algorithm
+var fftSizes = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 15, 16, 30, 49, 60, 64, 97, 100, 128, 210, 257, 1000, 1024}
+
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := range out {
+		for j, v := range x {
+			out[k] += v * cmplx.Rect(1, -2*math.Pi*float64(j*k%n)/float64(n))
+		}
+	}
+	return out
+}
+
+func randomComplex(rng *rand.Rand, n int) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+	}
+	return x
+}
+
+func maxError(a, b []complex128) float64 {
+	worst := 0.0
+	for i := range a {
+		worst = math.Max(worst, cmplx.Abs(a[i]-b[i]))
+	}
+	return worst
+}
+
+func TestFFTAgainstDFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range fftSizes {
+		x := randomComplex(rng, n)
+		expected := naiveDFT(x)
+
+		plan, err := fft.NewPlan(n)
+		if err != nil {
+			t.Fatalf("n=%d: failed to create plan: %v", n, err)
+		}
+		got := make([]complex128, n)
+		if err := plan.Forward(got, x); err != nil {
+			t.Fatalf("n=%d: forward transform failed: %v", n, err)
+		}
+		if e := maxError(got, expected); e > 1e-9*float64(n) {
+			t.Errorf("n=%d: forward error %g", n, e)
+		}
+
+		// In-place inverse restores the input
+		if err := plan.Inverse(got, got); err != nil {
+			t.Fatalf("n=%d: inverse transform failed: %v", n, err)
+		}
+		if e := maxError(got, x); e > 1e-9 {
+			t.Errorf("n=%d: round-trip error %g", n, e)
+		}
+	}
+}
+
+func TestRealFFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range fftSizes {
+		x := make([]float64, n)
+		xc := make([]complex128, n)
+		for i := range x {
+			x[i] = rng.NormFloat64()
+			xc[i] = complex(x[i], 0)
+		}
+
+		got := fft.RFFT(x)
+		if len(got) != n/2+1 {
+			t.Fatalf("n=%d: expected %d bins, got %d", n, n/2+1, len(got))
+		}
+		if e := maxError(got, naiveDFT(xc)[:n/2+1]); e > 1e-9*float64(n) {
+			t.Errorf("n=%d: real transform error %g", n, e)
+		}
+
+		back, err := fft.IRFFT(got, n)
+		if err != nil {
+			t.Fatalf("n=%d: inverse real transform failed: %v", n, err)
+		}
+		for i := range x {
+			if math.Abs(back[i]-x[i]) > 1e-9 {
+				t.Errorf("n=%d: round-trip sample %d: expected %f, got %f", n, i, x[i], back[i])
+				break
+			}
+		}
+	}
+}
+
+func TestFFTHelpers(t *testing.T) {
+	freqs := fft.Frequencies(5, 10)
+	expected := []float64{0, 2, 4, -4, -2}
+	for i := range expected {
+		if freqs[i] != expected[i] {
+			t.Errorf("Frequencies: bin %d: expected %g, got %g", i, expected[i], freqs[i])
+		}
+	}
+
+	shifted := fft.Shift(freqs)
+	for i, f := range []float64{-4, -2, 0, 2, 4} {
+		if shifted[i] != f {
+			t.Errorf("Shift: bin %d: expected %g, got %g", i, f, shifted[i])
+		}
+	}
+}
+
+func TestFilterFrequencyResponse(t *testing.T) {
+	// A 4-tap moving average has zeros at fs/4 and fs/2
+	b := []float64{0.25, 0.25, 0.25, 0.25}
+	freqs, response, err := filter.FrequencyResponse(b, []float64{1}, 8, 8000)
+	if err != nil {
+		t.Fatalf("Failed to compute frequency response: %v", err)
+	}
+	if len(freqs) != 5 || freqs[2] != 2000 || freqs[4] != 4000 {
+		t.Errorf("Unexpected frequencies %v", freqs)
+	}
+	if cmplx.Abs(response[0]-1) > 1e-12 || cmplx.Abs(response[2]) > 1e-12 || cmplx.Abs(response[4]) > 1e-12 {
+		t.Errorf("Unexpected response %v", response)
+	}
+
+	// The FFT evaluation matches direct evaluation of the Butterworth response
+	bw := filter.NewButterworthFilter()
+	if err := bw.Configure(filter.FilterConfig{Type: filter.Butterworth, Order: 4, CutoffFreq: 1000, SampleRate: 8000}); err != nil {
+		t.Fatalf("Failed to configure filter: %v", err)
+	}
+	freqs, response, err = filter.FilterResponse(bw, 64, 8000)
+	if err != nil {
+		t.Fatalf("Failed to compute frequency response: %v", err)
+	}
+	direct, err := bw.GetFrequencyResponse(freqs)
+	if err != nil {
+		t.Fatalf("Failed to evaluate frequency response: %v", err)
+	}
+	for i := range freqs {
+		if math.Abs(cmplx.Abs(response[i])-direct[i]) > 1e-9 {
+			t.Errorf("%.0f Hz: FFT magnitude %f, direct %f", freqs[i], cmplx.Abs(response[i]), direct[i])
+		}
+	}
+}
@@ -0,0 +1,726 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/internal/adapters/filters"
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+// gainDB returns the magnitude response of b/a in dB at freq Hz
+func gainDB(b, a []float64, freq, sampleRate float64) float64 {
+	z := cmplx.Rect(1, -2*math.Pi*freq/sampleRate)
+	var num, den complex128
+	for n := len(b) - 1; n >= 0; n-- {
+		num = num*z + complex(b[n], 0)
+	}
+	for n := len(a) - 1; n >= 0; n-- {
+		den = den*z + complex(a[n], 0)
+	}
+	return 20 * math.Log10(cmplx.Abs(num/den))
+}
+
+func TestButterworthResponses(t *testing.T) {
+	const sampleRate = 48000.0
+	cases := []struct {
+		config   filter.FilterConfig
+		pass     []float64 // Frequencies with gain near 0 dB
+		stop     []float64 // Frequencies attenuated by at least 40 dB
+		edges    []float64 // Frequencies at -3 dB
+		coeffLen int
+	}{
+		{
+			config:   filter.FilterConfig{Order: 4, CutoffFreq: 2000},
+			pass:     []float64{0, 200},
+			stop:     []float64{12000, 20000},
+			edges:    []float64{2000},
+			coeffLen: 5,
+		},
+		{
+			config:   filter.FilterConfig{Order: 4, ResponseType: filter.HighPass, CutoffFreq: 2000},
+			pass:     []float64{20000, 24000},
+			stop:     []float64{0, 300},
+			edges:    []float64{2000},
+			coeffLen: 5,
+		},
+		{
+			config:   filter.FilterConfig{Order: 3, ResponseType: filter.BandPass, LowFreq: 4000, HighFreq: 6000},
+			pass:     []float64{4900},
+			stop:     []float64{0, 500, 20000},
+			edges:    []float64{4000, 6000},
+			coeffLen: 7,
+		},
+		{
+			config:   filter.FilterConfig{Order: 3, ResponseType: filter.BandStop, LowFreq: 4000, HighFreq: 6000},
+			pass:     []float64{0, 200, 23000},
+			stop:     []float64{4900},
+			edges:    []float64{4000, 6000},
+			coeffLen: 7,
+		},
+	}
+
+	for _, tc := range cases {
+		config := tc.config
+		config.Type = filter.Butterworth
+		config.SampleRate = sampleRate
+		name := config.ResponseType.String()
+
+		f := filter.NewButterworthFilter()
+		if err := f.Configure(config); err != nil {
+			t.Fatalf("%s: failed to configure filter: %v", name, err)
+		}
+		b, a := f.Coefficients()
+		if len(b) != tc.coeffLen || len(a) != tc.coeffLen {
+			t.Errorf("%s: expected %d coefficients, got %d/%d", name, tc.coeffLen, len(b), len(a))
+		}
+
+		for _, freq := range tc.pass {
+			if g := gainDB(b, a, freq, sampleRate); math.Abs(g) > 0.1 {
+				t.Errorf("%s: expected passband gain 0 dB at %g Hz, got %.2f dB", name, freq, g)
+			}
+		}
+		for _, freq := range tc.stop {
+			if g := gainDB(b, a, freq, sampleRate); g > -40 {
+				t.Errorf("%s: expected stopband below -40 dB at %g Hz, got %.2f dB", name, freq, g)
+			}
+		}
+		for _, freq := range tc.edges {
+			if g := gainDB(b, a, freq, sampleRate); math.Abs(g+3.01) > 0.05 {
+				t.Errorf("%s: expected -3 dB at the %g Hz edge, got %.2f dB", name, freq, g)
+			}
+		}
+	}
+
+	// Band edges are required and ordered
+	invalid := []filter.FilterConfig{
+		{Type: filter.Butterworth, Order: 2, SampleRate: sampleRate, ResponseType: filter.BandPass, LowFreq: 6000, HighFreq: 4000},
+		{Type: filter.Butterworth, Order: 2, SampleRate: sampleRate, ResponseType: filter.BandStop, CutoffFreq: 1000},
+		{Type: filter.Butterworth, Order: 2, SampleRate: sampleRate, ResponseType: filter.HighPass, CutoffFreq: 30000},
+		{Type: filter.Butterworth, Order: 2, SampleRate: sampleRate, ResponseType: "notch", CutoffFreq: 1000},
+	}
+	for _, config := range invalid {
+		if err := filter.NewButterworthFilter().Configure(config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestButterworthAdapter(t *testing.T) {
+	f, err := filters.NewFilterAdapter("butterworth")
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+	err = f.Configure(map[string]interface{}{
+		"order":         4,
+		"sampleRate":    8000.0,
+		"response_type": "highpass",
+		"cutoff_freq":   1000.0,
+		"window_size":   5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure adapter: %v", err)
+	}
+
+	// A DC offset is removed by the high-pass
+	samples := make([]float64, 4000)
+	for i := range samples {
+		samples[i] = 1 + math.Sin(2*math.Pi*3000*float64(i)/8000)
+	}
+	filtered, err := f.Filter(domain.NewSignal(samples, 8000))
+	if err != nil {
+		t.Fatalf("Failed to filter: %v", err)
+	}
+	var mean float64
+	for _, v := range filtered.Samples[2000:] {
+		mean += v
+	}
+	if mean /= 2000; math.Abs(mean) > 1e-3 {
+		t.Errorf("Expected DC to be removed, got mean %f", mean)
+	}
+
+	// The factory validates the parameters of each filter type, so
+	// Butterworth filters need no window size
+	factory := filter.NewFilterFactory()
+	config := filter.FilterConfig{Type: filter.Butterworth, Order: 2, CutoffFreq: 1000, SampleRate: 8000}
+	if _, err := factory.CreateFilter(filter.Butterworth, config); err != nil {
+		t.Errorf("Failed to create Butterworth filter without a window size: %v", err)
+	}
+	if _, err := factory.CreateFilter(filter.Median, filter.FilterConfig{Type: filter.Median}); err == nil {
+		t.Error("Expected error for median filter without a window size")
+	}
+}
+
+func TestMedianAlignment(t *testing.T) {
+	// The window ends at the current sample: an impulse is removed and a
+	// step appears Latency() samples late, where a centred window puts it
+	// on the step itself
+	f := filter.NewMedianFilter()
+	if err := f.Configure(filter.FilterConfig{Type: filter.Median, WindowSize: 5}); err != nil {
+		t.Fatalf("Failed to configure median filter: %v", err)
+	}
+	if f.Latency() != 2 {
+		t.Fatalf("Expected latency 2, got %g", f.Latency())
+	}
+
+	const step = 20
+	input := make([]float64, 40)
+	for i := step; i < len(input); i++ {
+		input[i] = 1
+	}
+	input[10] = 5
+	output, err := f.Process(input)
+	if err != nil {
+		t.Fatalf("Failed to filter: %v", err)
+	}
+	for i, v := range output {
+		expected := 0.0
+		if i >= step+int(f.Latency()) {
+			expected = 1
+		}
+		if v != expected {
+			t.Fatalf("Output %d is %g, expected %g", i, v, expected)
+		}
+	}
+}
+
+func TestSOSHighOrder(t *testing.T) {
+	const sampleRate = 2.4e6
+	configs := []filter.FilterConfig{
+		{Type: filter.Butterworth, Order: 10, CutoffFreq: 5000, SampleRate: sampleRate},
+		{Type: filter.Butterworth, Order: 10, ResponseType: filter.HighPass, CutoffFreq: 2000, SampleRate: sampleRate},
+		{Type: filter.Butterworth, Order: 10, ResponseType: filter.BandPass, LowFreq: 95000, HighFreq: 105000, SampleRate: sampleRate},
+	}
+	for _, config := range configs {
+		name := config.ResponseType.String()
+		f := filter.NewButterworthFilter()
+		if err := f.Configure(config); err != nil {
+			t.Fatalf("%s: order 10 at 2.4 MS/s should be stable: %v", name, err)
+		}
+
+		// Sections keep the poles inside the unit circle
+		for i, q := range f.Sections() {
+			if math.Abs(q.A2) >= 1 || math.Abs(q.A1) >= 1+q.A2 {
+				t.Errorf("%s: section %d is unstable: a1=%g a2=%g", name, i, q.A1, q.A2)
+			}
+		}
+
+		edges := []float64{config.CutoffFreq}
+		if config.ResponseType == filter.BandPass {
+			edges = []float64{config.LowFreq, config.HighFreq}
+		}
+		response, _ := f.GetFrequencyResponse(edges)
+		for i, g := range response {
+			if db := 20 * math.Log10(g); math.Abs(db+3.01) > 0.05 {
+				t.Errorf("%s: expected -3 dB at %g Hz, got %.2f dB", name, edges[i], db)
+			}
+		}
+
+		// A long tone stays bounded and passes at unity gain in the passband
+		freq := 100.0
+		switch config.ResponseType {
+		case filter.HighPass:
+			freq = 100000
+		case filter.BandPass:
+			freq = 100000
+		}
+		tone := make([]float64, 200000)
+		for i := range tone {
+			tone[i] = math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+		}
+		output, err := f.Process(tone)
+		if err != nil {
+			t.Fatalf("%s: failed to process: %v", name, err)
+		}
+		peak := 0.0
+		for _, v := range output[len(output)/2:] {
+			peak = math.Max(peak, math.Abs(v))
+		}
+		if math.Abs(peak-1) > 0.01 {
+			t.Errorf("%s: expected passband tone amplitude 1, got %f", name, peak)
+		}
+	}
+}
+
+func TestSOSEngine(t *testing.T) {
+	// A single section matches the direct-form difference equation
+	q := filter.Biquad{B0: 0.2, B1: 0.3, B2: 0.1, A1: -0.5, A2: 0.25}
+	sos, err := filter.NewSOS([]filter.Biquad{q, q})
+	if err != nil {
+		t.Fatalf("Failed to create cascade: %v", err)
+	}
+	b, a := sos.Coefficients()
+	if len(b) != 5 || len(a) != 5 {
+		t.Fatalf("Expected 5 expanded coefficients, got %d/%d", len(b), len(a))
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	x := make([]float64, 500)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	y := make([]float64, len(x))
+	for n := range x {
+		for k := range b {
+			if n-k >= 0 {
+				y[n] += b[k] * x[n-k]
+			}
+		}
+		for k := 1; k < len(a); k++ {
+			if n-k >= 0 {
+				y[n] -= a[k] * y[n-k]
+			}
+		}
+	}
+
+	whole := sos.Process(x)
+	for i := range y {
+		if math.Abs(whole[i]-y[i]) > 1e-9 {
+			t.Fatalf("Sample %d: cascade %g, direct form %g", i, whole[i], y[i])
+		}
+	}
+
+	// State carries over between blocks
+	sos.Reset()
+	blocks := inBlocks(len(x), func(start, end int) []float64 {
+		return sos.Process(x[start:end])
+	})
+	assertSameOutput(t, "sos", whole, blocks)
+
+	// The section response matches the expanded response
+	freqs, response, err := filter.SectionResponse(sos.Sections(), 256, 1000)
+	if err != nil {
+		t.Fatalf("Failed to evaluate response: %v", err)
+	}
+	_, expanded, _ := filter.FrequencyResponse(b, a, 256, 1000)
+	for k := range response {
+		if cmplx.Abs(response[k]-expanded[k]) > 1e-9 {
+			t.Fatalf("%g Hz: sections %v, expanded %v", freqs[k], response[k], expanded[k])
+		}
+	}
+
+	if _, err := filter.NewSOS(nil); err == nil {
+		t.Error("Expected error for an empty cascade")
+	}
+}
+
+func TestChebyshevEllipticDesigns(t *testing.T) {
+	const (
+		sampleRate  = 48000.0
+		ripple      = 1.0
+		attenuation = 50.0
+	)
+
+	// scan returns the largest and smallest gain in dB over [lo, hi] Hz
+	scan := func(f interface {
+		GetFrequencyResponse([]float64) ([]float64, error)
+	}, lo, hi float64) (float64, float64) {
+		var freqs []float64
+		for freq := lo; freq <= hi; freq += (hi - lo) / 400 {
+			freqs = append(freqs, freq)
+		}
+		response, _ := f.GetFrequencyResponse(freqs)
+		maxDB, minDB := math.Inf(-1), math.Inf(1)
+		for _, g := range response {
+			db := 20 * math.Log10(g)
+			maxDB, minDB = math.Max(maxDB, db), math.Min(minDB, db)
+		}
+		return maxDB, minDB
+	}
+
+	type band struct{ lo, hi float64 }
+	responses := []struct {
+		config filter.FilterConfig
+		pass   []band // Passband up to the design edges
+		stop   []band // Stopband from the design edges
+		wide   []band // Stopband well beyond the edges, for Chebyshev I
+	}{
+		{
+			config: filter.FilterConfig{CutoffFreq: 4000},
+			pass:   []band{{0, 4000}},
+			stop:   []band{{4000, 24000}},
+			wide:   []band{{10000, 24000}},
+		},
+		{
+			config: filter.FilterConfig{ResponseType: filter.HighPass, CutoffFreq: 4000},
+			pass:   []band{{4000, 24000}},
+			stop:   []band{{0, 4000}},
+			wide:   []band{{0, 1500}},
+		},
+		{
+			config: filter.FilterConfig{ResponseType: filter.BandPass, LowFreq: 8000, HighFreq: 12000},
+			pass:   []band{{8000, 12000}},
+			stop:   []band{{0, 8000}, {12000, 24000}},
+			wide:   []band{{0, 4000}, {18000, 24000}},
+		},
+		{
+			config: filter.FilterConfig{ResponseType: filter.BandStop, LowFreq: 8000, HighFreq: 12000},
+			pass:   []band{{0, 8000}, {12000, 24000}},
+			stop:   []band{{8000, 12000}},
+			wide:   []band{{9500, 10500}},
+		},
+	}
+
+	factory := filter.NewFilterFactory()
+	for _, filterType := range []filter.FilterType{filter.ChebyshevI, filter.ChebyshevII, filter.Elliptic} {
+		for _, r := range responses {
+			config := r.config
+			config.Type = filterType
+			config.Order = 5
+			config.SampleRate = sampleRate
+			config.Ripple = ripple
+			config.Attenuation = attenuation
+			name := filterType.String() + " " + config.ResponseType.String()
+
+			created, err := factory.CreateFilter(filterType, config)
+			if err != nil {
+				t.Fatalf("%s: failed to create filter: %v", name, err)
+			}
+			f := created.(interface {
+				GetFrequencyResponse([]float64) ([]float64, error)
+			})
+
+			switch filterType {
+			case filter.ChebyshevI, filter.Elliptic:
+				// The passband stays within the ripple up to the edges
+				for _, b := range r.pass {
+					if maxDB, minDB := scan(f, b.lo, b.hi); maxDB > 0.01 || minDB < -ripple-0.01 {
+						t.Errorf("%s: passband %g-%g Hz spans %.2f to %.2f dB, expected within %g dB",
+							name, b.lo, b.hi, minDB, maxDB, ripple)
+					}
+				}
+			case filter.ChebyshevII:
+				// The stopband holds the attenuation from the edges
+				for _, b := range r.stop {
+					if maxDB, _ := scan(f, b.lo, b.hi); maxDB > -attenuation+0.01 {
+						t.Errorf("%s: stopband %g-%g Hz reaches %.2f dB, expected below %g dB",
+							name, b.lo, b.hi, maxDB, -attenuation)
+					}
+				}
+			}
+
+			// Well beyond the edges every design attenuates; Chebyshev I,
+			// which has no attenuation setting, at least by 30 dB
+			limit := -attenuation + 0.01
+			if filterType == filter.ChebyshevI {
+				limit = -30
+			}
+			for _, b := range r.wide {
+				if maxDB, _ := scan(f, b.lo, b.hi); maxDB > limit {
+					t.Errorf("%s: expected %g-%g Hz below %g dB, got %.2f dB", name, b.lo, b.hi, limit, maxDB)
+				}
+			}
+		}
+	}
+
+	// Ripple and attenuation are required where the design uses them
+	invalid := []filter.FilterConfig{
+		{Type: filter.ChebyshevI, Order: 4, CutoffFreq: 1000, SampleRate: sampleRate},
+		{Type: filter.ChebyshevII, Order: 4, CutoffFreq: 1000, SampleRate: sampleRate},
+		{Type: filter.Elliptic, Order: 4, CutoffFreq: 1000, SampleRate: sampleRate, Ripple: 3, Attenuation: 2},
+	}
+	for _, config := range invalid {
+		if _, err := factory.CreateFilter(config.Type, config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestFIRDesigns(t *testing.T) {
+	const sampleRate = 48000.0
+
+	// scan returns the largest deviation from gain over [lo, hi] Hz
+	scan := func(fir *filter.FIR, lo, hi, gain float64) float64 {
+		var worst float64
+		for freq := lo; freq <= hi; freq += (hi - lo) / 400 {
+			worst = math.Max(worst, math.Abs(cmplx.Abs(fir.Response(freq, sampleRate))-gain))
+		}
+		return worst
+	}
+
+	// Low-pass from the response type: 5-7 kHz transition, 60 dB stopband
+	factory := filter.NewFilterFactory()
+	for _, filterType := range []filter.FilterType{filter.FIRKaiser, filter.FIRRemez} {
+		config := filter.FilterConfig{
+			Type:            filterType,
+			CutoffFreq:      6000,
+			TransitionWidth: 2000,
+			Ripple:          0.5,
+			Attenuation:     60,
+			SampleRate:      sampleRate,
+		}
+		created, err := factory.CreateFilter(filterType, config)
+		if err != nil {
+			t.Fatalf("%s: failed to create filter: %v", filterType, err)
+		}
+		f := created.(interface{ Taps() []float64 })
+		taps := f.Taps()
+		if created.GetConfig().Taps != len(taps) {
+			t.Errorf("%s: config records %d taps, designed %d", filterType, created.GetConfig().Taps, len(taps))
+		}
+
+		// Linear phase: symmetric taps, delayed by half the length
+		for n := range taps {
+			if math.Abs(taps[n]-taps[len(taps)-1-n]) > 1e-12 {
+				t.Fatalf("%s: taps are not symmetric at %d", filterType, n)
+			}
+		}
+		if latency := created.Latency(); latency != float64(len(taps)-1)/2 {
+			t.Errorf("%s: expected latency %g, got %g", filterType, float64(len(taps)-1)/2, latency)
+		}
+
+		fir, err := filter.NewFIR(taps)
+		if err != nil {
+			t.Fatalf("%s: failed to create FIR: %v", filterType, err)
+		}
+		// The length estimates are close; allow 2 dB on the stopband
+		if dev := scan(fir, 0, 5000, 1); dev > 0.04 {
+			t.Errorf("%s: passband deviates by %g", filterType, dev)
+		}
+		if dev := scan(fir, 7000, 24000, 0); 20*math.Log10(dev) > -58 {
+			t.Errorf("%s: stopband reaches %.1f dB, expected below -58 dB", filterType, 20*math.Log10(dev))
+		}
+	}
+
+	// Arbitrary bands: a 0.5 gain shelf between pass- and stopband, with
+	// the stopband error weighted 10 times
+	bands := []filter.Band{
+		{Low: 0, High: 3000, Gain: 1, Weight: 1},
+		{Low: 4000, High: 8000, Gain: 0.5, Weight: 1},
+		{Low: 9000, High: 24000, Gain: 0, Weight: 10},
+	}
+	taps, err := filter.DesignRemez(101, bands, sampleRate)
+	if err != nil {
+		t.Fatalf("Failed to design multiband filter: %v", err)
+	}
+	fir, _ := filter.NewFIR(taps)
+	pass, shelf, stop := scan(fir, 0, 3000, 1), scan(fir, 4000, 8000, 0.5), scan(fir, 9000, 24000, 0)
+
+	// Equiripple: the weighted errors peak at the same level in every band
+	for name, dev := range map[string]float64{"passband": pass, "shelf": shelf, "weighted stopband": 10 * stop} {
+		if math.Abs(dev-pass)/pass > 0.05 {
+			t.Errorf("%s error %g, expected equal to passband error %g", name, dev, pass)
+		}
+	}
+	if pass > 0.02 {
+		t.Errorf("Multiband error %g too large", pass)
+	}
+
+	taps, err = filter.DesignKaiser(0, bands, sampleRate, 60)
+	if err != nil {
+		t.Fatalf("Failed to design windowed multiband filter: %v", err)
+	}
+	fir, _ = filter.NewFIR(taps)
+	if dev := scan(fir, 4000, 8000, 0.5); dev > 0.002 {
+		t.Errorf("Windowed shelf deviates by %g", dev)
+	}
+
+	// An even length has a zero at Nyquist, so it cannot be a high-pass
+	highpass := []filter.Band{{Low: 0, High: 5000}, {Low: 7000, High: 24000, Gain: 1}}
+	if _, err := filter.DesignRemez(50, highpass, sampleRate); err == nil {
+		t.Error("Expected error for an even-length high-pass")
+	}
+	if _, err := filter.DesignRemez(51, highpass, sampleRate); err != nil {
+		t.Errorf("Failed to design odd-length high-pass: %v", err)
+	}
+
+	invalid := []filter.FilterConfig{
+		{Type: filter.FIRKaiser, CutoffFreq: 1000, Attenuation: 60, SampleRate: sampleRate},
+		{Type: filter.FIRKaiser, CutoffFreq: 1000, TransitionWidth: 200, SampleRate: sampleRate},
+		{Type: filter.FIRRemez, CutoffFreq: 1000, TransitionWidth: 200, SampleRate: sampleRate},
+		{Type: filter.FIRRemez, Taps: 31, SampleRate: sampleRate, Bands: []filter.Band{{Low: 0, High: 2000, Gain: 1}, {Low: 1000, High: 24000}}},
+	}
+	for _, config := range invalid {
+		if _, err := factory.CreateFilter(config.Type, config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
+
+func TestFastConvolution(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	signal := make([]float64, 6000)
+	for i := range signal {
+		signal[i] = rng.NormFloat64()
+	}
+
+	for _, numTaps := range []int{filter.FastConvolutionTaps - 1, filter.FastConvolutionTaps, 257, 1000} {
+		taps := make([]float64, numTaps)
+		for i := range taps {
+			taps[i] = rng.NormFloat64() / float64(numTaps)
+		}
+		direct, err := filter.NewDirectFIR(taps)
+		if err != nil {
+			t.Fatalf("%d taps: failed to create filter: %v", numTaps, err)
+		}
+		fast, err := filter.NewFIR(taps)
+		if err != nil {
+			t.Fatalf("%d taps: failed to create filter: %v", numTaps, err)
+		}
+
+		expected := direct.Process(signal)
+		whole := fast.Process(signal)
+		for i := range expected {
+			if math.Abs(whole[i]-expected[i]) > 1e-10 {
+				t.Fatalf("%d taps: sample %d is %g, direct convolution %g", numTaps, i, whole[i], expected[i])
+			}
+		}
+
+		// Blocks shorter and longer than the taps continue the convolution
+		fast.Reset()
+		blocks := inBlocks(len(signal), func(start, end int) []float64 {
+			return fast.Process(signal[start:end])
+		})
+		for i := range expected {
+			if math.Abs(blocks[i]-expected[i]) > 1e-10 {
+				t.Fatalf("%d taps: block sample %d is %g, direct convolution %g", numTaps, i, blocks[i], expected[i])
+			}
+		}
+	}
+}
+
+// convolver is the block interface shared by the convolution engines
+type convolver interface {
+	Process(samples []float64) []float64
+}
+
+func benchmarkConvolution[C convolver](b *testing.B, create func([]float64) (C, error)) {
+	rng := rand.New(rand.NewSource(1))
+	signal := make([]float64, 1<<16)
+	for i := range signal {
+		signal[i] = rng.NormFloat64()
+	}
+	for _, numTaps := range []int{16, 64, 256, 1024} {
+		b.Run(fmt.Sprintf("taps=%d", numTaps), func(b *testing.B) {
+			taps := make([]float64, numTaps)
+			for i := range taps {
+				taps[i] = rng.NormFloat64()
+			}
+			c, err := create(taps)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(8 * len(signal)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Process(signal)
+			}
+		})
+	}
+}
+
+func BenchmarkFIRDirect(b *testing.B) {
+	benchmarkConvolution(b, filter.NewDirectFIR)
+}
+
+func BenchmarkFIROverlapSave(b *testing.B) {
+	benchmarkConvolution(b, filter.NewOverlapSave)
+}
+
+func TestPulseShapingFilters(t *testing.T) {
+	const (
+		sps  = 8
+		span = 12
+	)
+
+	// RRC ⊗ RRC is a raised cosine: unit gain at its centre and zero at
+	// every other symbol instant, also when span*sps is odd and the centre
+	// of each filter falls between two taps
+	for _, shape := range []struct{ span, sps int }{{span, sps}, {13, 7}} {
+		for _, rollOff := range []float64{0.2, 0.35, 0.5, 1} {
+			taps, err := filter.RootRaisedCosineTaps(rollOff, shape.span, shape.sps)
+			if err != nil {
+				t.Fatalf("β=%g: failed to design filter: %v", rollOff, err)
+			}
+			if len(taps) != shape.span*shape.sps+1 {
+				t.Fatalf("β=%g: expected %d taps, got %d", rollOff, shape.span*shape.sps+1, len(taps))
+			}
+			rc := make([]float64, 2*len(taps)-1)
+			for i, a := range taps {
+				for j, b := range taps {
+					rc[i+j] += a * b
+				}
+			}
+			centre := len(taps) - 1
+			if math.Abs(rc[centre]-1) > 1e-12 {
+				t.Errorf("β=%g span=%d: raised cosine peak %g, expected 1", rollOff, shape.span, rc[centre])
+			}
+			for k := shape.sps; k <= centre; k += shape.sps {
+				if isi := math.Max(math.Abs(rc[centre-k]), math.Abs(rc[centre+k])); isi > 5e-3 {
+					t.Errorf("β=%g span=%d: inter-symbol interference %g at %d symbols", rollOff, shape.span, isi, k/shape.sps)
+				}
+			}
+		}
+	}
+
+	// Shaping symbols at the transmitter and matched filtering at the
+	// receiver recovers the symbols at the instants after both delays
+	factory := filter.NewFilterFactory()
+	config := filter.FilterConfig{
+		Type:             filter.RootRaisedCosine,
+		SymbolSpan:       span,
+		SamplesPerSymbol: sps,
+		RollOff:          0.35,
+		SampleRate:       9600,
+	}
+	tx, err := factory.CreateFilter(config.Type, config)
+	if err != nil {
+		t.Fatalf("Failed to create transmit filter: %v", err)
+	}
+	rx, err := factory.CreateFilter(config.Type, config)
+	if err != nil {
+		t.Fatalf("Failed to create matched filter: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(5))
+	symbols := make([]float64, 200)
+	impulses := make([]float64, (len(symbols)+span)*sps)
+	for i := range symbols {
+		symbols[i] = float64(2*rng.Intn(2) - 1)
+		impulses[i*sps] = symbols[i]
+	}
+	shaped, _ := tx.Process(impulses)
+	received, _ := rx.Process(shaped)
+	delay := int(tx.Latency() + rx.Latency())
+	for i, symbol := range symbols {
+		if got := received[delay+i*sps]; math.Abs(got-symbol) > 0.02 {
+			t.Fatalf("Symbol %d: expected %g, got %g", i, symbol, got)
+		}
+	}
+
+	// A Gaussian filter keeps held symbols' level and is 3 dB down at BT
+	// times the symbol rate
+	gaussian, err := factory.CreateFilter(filter.Gaussian, filter.FilterConfig{
+		Type:             filter.Gaussian,
+		SymbolSpan:       4,
+		SamplesPerSymbol: sps,
+		BT:               0.3,
+		SampleRate:       9600,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Gaussian filter: %v", err)
+	}
+	b, a := gaussian.(filter.TransferFunction).Coefficients()
+	symbolRate := 9600.0 / sps
+	if dc := gainDB(b, a, 0, 9600); math.Abs(dc) > 1e-9 {
+		t.Errorf("Gaussian DC gain %g dB, expected 0 dB", dc)
+	}
+	if g := gainDB(b, a, 0.3*symbolRate, 9600); math.Abs(g+3.01) > 0.05 {
+		t.Errorf("Gaussian gain at BT %g dB, expected -3 dB", g)
+	}
+
+	invalid := []filter.FilterConfig{
+		{Type: filter.RootRaisedCosine, SymbolSpan: span, SamplesPerSymbol: sps, RollOff: 1.5, SampleRate: 9600},
+		{Type: filter.RootRaisedCosine, SamplesPerSymbol: sps, RollOff: 0.35, SampleRate: 9600},
+		{Type: filter.Gaussian, SymbolSpan: span, SamplesPerSymbol: sps, SampleRate: 9600},
+	}
+	for _, config := range invalid {
+		if _, err := factory.CreateFilter(config.Type, config); err == nil {
+			t.Errorf("Expected error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/demod"
+	"github.com/Vivirinter/sdr-parser/pkg/hilbert"
+)
+
+func TestHilbertTransformer(t *testing.T) {
+	if _, err := hilbert.Taps(64); err == nil {
+		t.Error("expected an error for an even number of taps")
+	}
+
+	// The analytic signal of cos is exp(jωn), delayed by the transformer
+	const fs = 8000.0
+	signal := cosine(1000, fs, 2000)
+	tr := hilbert.NewTransformer()
+	output := tr.Process(signal)
+	delay := tr.Latency()
+	for i := hilbert.DefaultTaps; i < len(output); i++ {
+		expected := cmplx.Rect(1, 2*math.Pi*1000*(float64(i)-delay)/fs)
+		if cmplx.Abs(output[i]-expected) > 0.01 {
+			t.Fatalf("sample %d: got %v, expected %v", i, output[i], expected)
+		}
+	}
+
+	tr.Reset()
+	var blocks []complex128
+	inBlocks(len(signal), func(start, end int) []float64 {
+		blocks = append(blocks, tr.Process(signal[start:end])...)
+		return nil
+	})
+	for i := range output {
+		if output[i] != blocks[i] {
+			t.Fatalf("sample %d differs: whole %v, blocks %v", i, output[i], blocks[i])
+		}
+	}
+}
+
+func TestAnalyticSignal(t *testing.T) {
+	// The magnitude of the analytic signal of an AM tone is its envelope
+	// and the phase advances with the carrier
+	const n = 1000
+	samples := make([]float64, n)
+	envelope := make([]float64, n)
+	for i := range samples {
+		envelope[i] = 1 + 0.5*math.Cos(2*math.Pi*float64(i)*4/n)
+		samples[i] = envelope[i] * math.Cos(2*math.Pi*float64(i)*100/n)
+	}
+
+	analytic := domain.NewIQSignal(hilbert.Analytic(samples), 48000)
+	magnitude := analytic.Magnitude().Samples
+	phase := analytic.Phase().Samples
+	for i := range samples {
+		if math.Abs(real(analytic.Samples[i])-samples[i]) > 1e-9 {
+			t.Fatalf("sample %d: real part %g != input %g", i, real(analytic.Samples[i]), samples[i])
+		}
+		if math.Abs(magnitude[i]-envelope[i]) > 1e-9 {
+			t.Fatalf("sample %d: magnitude %g != envelope %g", i, magnitude[i], envelope[i])
+		}
+		expected := math.Remainder(2*math.Pi*float64(i)*100/n, 2*math.Pi)
+		if math.Abs(math.Remainder(phase[i]-expected, 2*math.Pi)) > 1e-9 {
+			t.Fatalf("sample %d: phase %g != %g", i, phase[i], expected)
+		}
+	}
+
+	if len(hilbert.Analytic(nil)) != 0 {
+		t.Error("expected empty output for empty input")
+	}
+}
+
+func TestRealSSBDemodulation(t *testing.T) {
+	// A real signal with a 1 kHz tone above a 3 kHz carrier and a 1.8 kHz
+	// tone below it: each sideband demodulator keeps only its own tone
+	const fs = 12000.0
+	const carrier = 3000.0
+	usbTone := cosine(carrier+1000, fs, 2400)
+	lsbTone := cosine(carrier-1800, fs, 2400)
+	signal := make([]float64, len(usbTone))
+	for i := range signal {
+		signal[i] = usbTone[i] + lsbTone[i]
+	}
+
+	tests := []struct {
+		demodType     demod.DemodulationType
+		wanted, other float64
+	}{
+		{demod.USB, 1000, 1800},
+		{demod.LSB, 1800, 1000},
+	}
+	for _, tt := range tests {
+		d := demod.NewDemodulator(demod.DemodulatorConfig{Type: tt.demodType, Carrier: carrier / fs})
+		output, _ := d.Demodulate(signal)
+		if len(output) != len(signal) {
+			t.Fatalf("%s: output has %d samples, expected %d", tt.demodType, len(output), len(signal))
+		}
+
+		skip := 2 * hilbert.DefaultTaps
+		wanted := toneAmplitude(output, tt.wanted, fs, skip)
+		other := toneAmplitude(output, tt.other, fs, skip)
+		if rejection := 20 * math.Log10(wanted/other); rejection < 30 {
+			t.Errorf("%s: opposite sideband rejected by %.1f dB, expected at least 30 dB", tt.demodType, rejection)
+		}
+	}
+}
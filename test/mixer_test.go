@@ -0,0 +1,121 @@
+package test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/internal/adapters/filters"
+	"github.com/Vivirinter/sdr-parser/internal/domain"
+	"github.com/Vivirinter/sdr-parser/pkg/mixer"
+)
+
+func TestNCO(t *testing.T) {
+	const sampleRate = 48000.0
+
+	// After a whole number of cycles the phase returns exactly to zero
+	nco, err := mixer.NewNCO(1000, sampleRate)
+	if err != nil {
+		t.Fatalf("Failed to create NCO: %v", err)
+	}
+	for i := 0; i < 48000*10; i++ {
+		nco.Next()
+	}
+	if phase := nco.Phase(); math.Min(phase, 2*math.Pi-phase) > 1e-9 {
+		t.Errorf("Expected zero phase after 10000 cycles, got %g", phase)
+	}
+
+	// Samples follow exp(j2πft), also for negative frequencies
+	nco, _ = mixer.NewNCO(-3000, sampleRate)
+	for i := 0; i < 1000; i++ {
+		expected := cmplx.Rect(1, -2*math.Pi*3000*float64(i)/sampleRate)
+		if got := nco.Next(); cmplx.Abs(got-expected) > 1e-9 {
+			t.Fatalf("Sample %d: expected %v, got %v", i, expected, got)
+		}
+	}
+
+	// Frequency changes keep the phase continuous: the step between samples
+	// changes from the old increment to the new one without a jump
+	nco.Reset()
+	nco.SetFrequency(1000)
+	var samples []complex128
+	for i := 0; i < 100; i++ {
+		if i == 50 {
+			nco.SetFrequency(5000)
+		}
+		samples = append(samples, nco.Next())
+	}
+	for i := 1; i < len(samples); i++ {
+		step := cmplx.Phase(samples[i] / samples[i-1])
+		expected := 2 * math.Pi * 1000 / sampleRate
+		if i > 50 {
+			expected = 2 * math.Pi * 5000 / sampleRate
+		}
+		if math.Abs(step-expected) > 1e-9 {
+			t.Fatalf("Sample %d: phase step %g, expected %g", i, step, expected)
+		}
+	}
+
+	// Phases wrap into one turn, including negative phases too small to
+	// survive the wrap in floating point
+	for _, phase := range []float64{-1e-17, -math.Pi / 2, 5 * math.Pi / 2, 0} {
+		nco.SetPhase(phase)
+		if got, expected := nco.Next(), cmplx.Rect(1, phase); cmplx.Abs(got-expected) > 1e-9 {
+			t.Errorf("Phase %g: expected %v, got %v", phase, expected, got)
+		}
+	}
+
+	if _, err := mixer.NewNCO(1000, 0); err == nil {
+		t.Error("Expected error for a zero sample rate")
+	}
+}
+
+func TestMixerChannelSelection(t *testing.T) {
+	// Two carriers in a 1 MS/s capture: the wanted one at +100 kHz and an
+	// interferer at -150 kHz
+	const (
+		sampleRate = 1000000.0
+		n          = 20000
+	)
+	capture := make([]complex128, n)
+	for i := range capture {
+		t := float64(i) / sampleRate
+		capture[i] = cmplx.Rect(1, 2*math.Pi*100000*t) + cmplx.Rect(1, -2*math.Pi*150000*t+1)
+	}
+
+	m, err := mixer.NewMixer(-100000, sampleRate)
+	if err != nil {
+		t.Fatalf("Failed to create mixer: %v", err)
+	}
+	f, err := filters.NewIQFilterAdapter("fir_kaiser")
+	if err != nil {
+		t.Fatalf("Failed to create channel filter: %v", err)
+	}
+	err = f.Configure(map[string]interface{}{
+		"sampleRate":       sampleRate,
+		"cutoff_freq":      25000.0,
+		"transition_width": 10000.0,
+		"attenuation":      70.0,
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure channel filter: %v", err)
+	}
+
+	// Shift in blocks; the phase carries across them
+	var tuned []complex128
+	for start := 0; start < n; start += 777 {
+		tuned = append(tuned, m.Process(capture[start:min(start+777, n)])...)
+	}
+	channel, err := f.FilterIQ(domain.NewIQSignal(tuned, sampleRate))
+	if err != nil {
+		t.Fatalf("Failed to filter channel: %v", err)
+	}
+
+	// The wanted carrier is at DC with unit amplitude, the interferer gone
+	skip := 2 * int(f.(*filters.FilterAdapter).Latency())
+	for i := skip; i < n; i++ {
+		if d := cmplx.Abs(channel.Samples[i] - 1); d > 1e-3 {
+			t.Fatalf("Sample %d: expected the carrier at DC, got %v", i, channel.Samples[i])
+		}
+	}
+}
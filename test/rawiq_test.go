@@ -0,0 +1,92 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/rawiq"
+)
+
+func TestRawIQRoundTrip(t *testing.T) {
+	samples := make([]complex128, 100)
+	for i := range samples {
+		samples[i] = cmplx.Rect(0.9, float64(i)*0.3)
+	}
+
+	tests := []struct {
+		format    rawiq.SampleFormat
+		order     binary.ByteOrder
+		tolerance float64
+	}{
+		{rawiq.CU8, binary.LittleEndian, 1.0 / 127},
+		{rawiq.CS8, binary.LittleEndian, 1.0 / 127},
+		{rawiq.CS16, binary.LittleEndian, 1.0 / 32767},
+		{rawiq.CS16, binary.BigEndian, 1.0 / 32767},
+		{rawiq.CF32, binary.LittleEndian, 1e-6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			config := rawiq.Config{Format: tt.format, SampleRate: 2.4e6, ByteOrder: tt.order}
+
+			var buf bytes.Buffer
+			w, err := rawiq.NewWriter(&buf, config)
+			if err != nil {
+				t.Fatalf("Failed to create writer: %v", err)
+			}
+			if err := w.WriteBlock(samples); err != nil {
+				t.Fatalf("Failed to write samples: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Failed to close writer: %v", err)
+			}
+
+			if buf.Len() != len(samples)*tt.format.SampleSize() {
+				t.Errorf("Expected %d bytes, got %d", len(samples)*tt.format.SampleSize(), buf.Len())
+			}
+
+			r, err := rawiq.NewReader(&buf, config)
+			if err != nil {
+				t.Fatalf("Failed to create reader: %v", err)
+			}
+			got, err := r.ReadAll()
+			if err != nil {
+				t.Fatalf("Failed to read samples: %v", err)
+			}
+			if len(got) != len(samples) {
+				t.Fatalf("Expected %d samples, got %d", len(samples), len(got))
+			}
+			for i := range samples {
+				if cmplx.Abs(got[i]-samples[i]) > tt.tolerance*math.Sqrt2 {
+					t.Fatalf("Sample %d: expected %v, got %v", i, samples[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRawIQRtlSdrDecoding(t *testing.T) {
+	// rtl_sdr writes offset-binary bytes centered on 127.5
+	data := []byte{255, 0, 128, 127}
+	r, err := rawiq.NewReader(bytes.NewReader(data), rawiq.Config{Format: rawiq.CU8, SampleRate: 1})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read samples: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(got))
+	}
+	if got[0] != complex(1, -1) {
+		t.Errorf("Expected (1-1i), got %v", got[0])
+	}
+	if math.Abs(real(got[1])+imag(got[1])) > 1e-12 || real(got[1]) <= 0 {
+		t.Errorf("Expected symmetric values around zero, got %v", got[1])
+	}
+}
@@ -0,0 +1,199 @@
+package test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/rds"
+)
+
+const (
+	rdsPI = 0xD318
+	rdsPS = "TEST FM "
+	rdsRT = "Now playing: a test tone"
+)
+
+// rdsGroups returns the groups of a station broadcasting rdsPS, rdsRT and
+// the clock time 2024-05-01 12:34 UTC at +02:00, with programme type 10
+func rdsGroups() [][4]uint16 {
+	const pty = 10 << 5
+	var groups [][4]uint16
+	for seg := 0; seg < 4; seg++ {
+		chars := uint16(rdsPS[2*seg])<<8 | uint16(rdsPS[2*seg+1])
+		groups = append(groups, [4]uint16{rdsPI, 0x0000 | pty | uint16(seg), 0xE0CD, chars})
+	}
+	text := rdsRT + "\r"
+	for len(text)%4 != 0 {
+		text += " "
+	}
+	for seg := 0; seg < len(text)/4; seg++ {
+		c := text[4*seg:]
+		groups = append(groups, [4]uint16{rdsPI, 0x2000 | pty | uint16(seg),
+			uint16(c[0])<<8 | uint16(c[1]), uint16(c[2])<<8 | uint16(c[3])})
+	}
+
+	// MJD 60431 is 2024-05-01
+	const mjd, hour, minute = 60431, 12, 34
+	groups = append(groups, [4]uint16{rdsPI, 0x4000 | pty | mjd>>15,
+		uint16(mjd&0x7FFF)<<1 | hour>>4, uint16(hour&0xF)<<12 | minute<<6 | 4})
+	return groups
+}
+
+// rdsBits encodes groups into the transmitted bit stream
+func rdsBits(groups [][4]uint16) []byte {
+	offsets := [4]rds.Offset{rds.OffsetA, rds.OffsetB, rds.OffsetC, rds.OffsetD}
+	var bits []byte
+	for _, g := range groups {
+		for i, data := range g {
+			block := rds.EncodeBlock(data, offsets[i])
+			for b := 25; b >= 0; b-- {
+				bits = append(bits, byte(block>>b)&1)
+			}
+		}
+	}
+	return bits
+}
+
+// rdsMPX modulates bits onto the 57 kHz subcarrier as differentially
+// encoded biphase symbols, over a 19 kHz pilot and with noise
+func rdsMPX(bits []byte, sampleRate float64, rng *rand.Rand) []float64 {
+	n := int(float64(len(bits)) / rds.BitRate * sampleRate)
+	mpx := make([]float64, n)
+	var level byte
+	lastBit := -1
+	for i := range mpx {
+		t := float64(i) / sampleRate
+		pos := t * rds.BitRate
+		k := int(pos)
+		if k != lastBit {
+			level ^= bits[k]
+			lastBit = k
+		}
+		symbol := 2*float64(level) - 1
+		if pos-float64(k) >= 0.5 {
+			symbol = -symbol
+		}
+		mpx[i] = 0.05*symbol*math.Cos(2*math.Pi*rds.Subcarrier*t+1) +
+			0.1*math.Sin(2*math.Pi*19000*t) + 0.02*rng.NormFloat64()
+	}
+	return mpx
+}
+
+func TestRDSBlocks(t *testing.T) {
+	groups := rdsGroups()
+	bits := rdsBits(groups)
+
+	// Corrupt a 4-bit burst in block B of the second group and all of
+	// block D in the fourth
+	bits[104+26+3] ^= 1
+	bits[104+26+6] ^= 1
+	for i := 3*104 + 78; i < 4*104; i += 3 {
+		bits[i] ^= 1
+	}
+
+	// Start mid-group, after noise
+	rng := rand.New(rand.NewSource(3))
+	stream := make([]byte, 37)
+	for i := range stream {
+		stream[i] = byte(rng.Intn(2))
+	}
+	stream = append(stream, bits[26:]...)
+
+	d := rds.NewDecoder()
+	decoded := d.Push(stream)
+	if !d.Synced() {
+		t.Fatal("decoder did not sync")
+	}
+	if len(decoded) != len(groups) {
+		t.Fatalf("decoded %d groups, expected %d", len(decoded), len(groups))
+	}
+	if decoded[0].OK[0] || decoded[0].OK[1] || !decoded[0].OK[2] {
+		t.Errorf("first group: expected blocks A and B missing, got OK %v", decoded[0].OK)
+	}
+	if !decoded[1].OK[1] || decoded[1].Corrected != 1 || decoded[1].Blocks[1] != groups[1][1] {
+		t.Errorf("second group: burst not corrected: %+v", decoded[1])
+	}
+	if decoded[3].OK[3] {
+		t.Error("fourth group: expected block D to be uncorrectable")
+	}
+	for i := 4; i < len(groups); i++ {
+		if decoded[i].Blocks != groups[i] || decoded[i].OK != [4]bool{true, true, true, true} {
+			t.Fatalf("group %d: got %+v, expected %04X", i, decoded[i], groups[i])
+		}
+	}
+}
+
+func TestRDSStation(t *testing.T) {
+	s := rds.NewStation(false)
+	var messages []rds.Message
+	for i, g := range rdsGroups() {
+		group := rds.Group{Blocks: g, OK: [4]bool{true, true, true, true}, Bit: uint64(i * 104)}
+		messages = append(messages, s.Update(group)...)
+	}
+
+	expected := []rds.Message{
+		{PI: "D318"},
+		{PI: "D318", PTYName: "Pop Music"},
+		{PI: "D318", PS: rdsPS},
+		{PI: "D318", RadioText: rdsRT},
+		{PI: "D318", ClockTime: "2024-05-01T14:34:00+02:00"},
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("got %d messages, expected %d: %+v", len(messages), len(expected), messages)
+	}
+	for i, m := range messages {
+		if m.PI != expected[i].PI || m.PTYName != expected[i].PTYName || m.PS != expected[i].PS ||
+			m.RadioText != expected[i].RadioText || m.ClockTime != expected[i].ClockTime {
+			t.Errorf("message %d: got %+v, expected %+v", i, m, expected[i])
+		}
+	}
+	if messages[1].PTY == nil || *messages[1].PTY != 10 {
+		t.Errorf("expected PTY 10, got %v", messages[1].PTY)
+	}
+	if name := rds.PTYName(10, true); name != "Country" {
+		t.Errorf("RBDS PTY 10: got %q, expected Country", name)
+	}
+}
+
+func TestRDSDemodulator(t *testing.T) {
+	const fs = 228000.0
+	if _, err := rds.NewDemodulator(96000); err == nil {
+		t.Error("expected an error for a sample rate below MinSampleRate")
+	}
+
+	var groups [][4]uint16
+	for range 3 {
+		groups = append(groups, rdsGroups()...)
+	}
+	rng := rand.New(rand.NewSource(5))
+	mpx := rdsMPX(rdsBits(groups), fs, rng)
+
+	d, err := rds.NewDemodulator(fs)
+	if err != nil {
+		t.Fatalf("failed to create demodulator: %v", err)
+	}
+	decoder := rds.NewDecoder()
+	station := rds.NewStation(false)
+	var ps, text string
+	var good int
+	for start := 0; start < len(mpx); start += 4096 {
+		bits := d.Process(mpx[start:min(start+4096, len(mpx))])
+		for _, g := range decoder.Push(bits) {
+			if g.OK == [4]bool{true, true, true, true} {
+				good++
+			}
+			for _, m := range station.Update(g) {
+				ps += m.PS
+				text += m.RadioText
+			}
+		}
+	}
+
+	if ps != rdsPS || text != rdsRT {
+		t.Errorf("decoded PS %q and RadioText %q, expected %q and %q", ps, text, rdsPS, rdsRT)
+	}
+	if good < 2*len(groups)/3 {
+		t.Errorf("decoded %d of %d groups intact", good, len(groups))
+	}
+}
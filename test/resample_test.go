@@ -0,0 +1,210 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/resample"
+)
+
+// toneAmplitude returns the amplitude of the component at freq Hz,
+// skipping the filter transients at both ends
+func toneAmplitude(x []float64, freq, sampleRate float64, skip int) float64 {
+	var re, im float64
+	for i := skip; i < len(x)-skip; i++ {
+		phase := 2 * math.Pi * freq * float64(i) / sampleRate
+		re += x[i] * math.Cos(phase)
+		im += x[i] * math.Sin(phase)
+	}
+	return 2 * math.Hypot(re, im) / float64(len(x)-2*skip)
+}
+
+func cosine(freq, sampleRate float64, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = math.Cos(2 * math.Pi * freq * float64(i) / sampleRate)
+	}
+	return x
+}
+
+func TestRational(t *testing.T) {
+	cases := []struct {
+		in, out  float64
+		up, down int
+	}{
+		{44100, 48000, 160, 147},
+		{2400000, 48000, 1, 50},
+		{8000, 48000, 6, 1},
+		{48000, 48000, 1, 1},
+		{2048000, 48000, 3, 128},
+	}
+	for _, c := range cases {
+		up, down, err := resample.Rational(c.in, c.out)
+		if err != nil {
+			t.Fatalf("%g -> %g: %v", c.in, c.out, err)
+		}
+		if up != c.up || down != c.down {
+			t.Errorf("%g -> %g: expected %d/%d, got %d/%d", c.in, c.out, c.up, c.down, up, down)
+		}
+	}
+	if _, _, err := resample.Rational(48000, 0); err == nil {
+		t.Error("Expected error for a zero rate")
+	}
+	if _, _, err := resample.Rational(1, math.Pi); err == nil {
+		t.Error("Expected error for an irrational ratio")
+	}
+}
+
+func TestResampler(t *testing.T) {
+	const skip = 200
+	cases := []struct{ in, out float64 }{
+		{44100, 48000},
+		{48000, 44100},
+		{8000, 48000},
+		{48000, 8000},
+		{2400000, 48000}, // CIC and compensation FIR
+	}
+	for _, c := range cases {
+		conv, err := resample.New(c.in, c.out)
+		if err != nil {
+			t.Fatalf("%g -> %g: failed to create converter: %v", c.in, c.out, err)
+		}
+		n := int(c.in / 5)
+		narrower := math.Min(c.in, c.out)
+
+		// The passband keeps its level up to the edge
+		for _, freq := range []float64{1000, 0.39 * narrower} {
+			conv.Reset()
+			y := conv.Process(cosine(freq, c.in, n))
+			if expected := int(float64(n) * c.out / c.in); math.Abs(float64(len(y)-expected)) > 1 {
+				t.Fatalf("%g -> %g: expected %d samples, got %d", c.in, c.out, expected, len(y))
+			}
+			if a := toneAmplitude(y, freq, c.out, skip); math.Abs(a-1) > 0.002 {
+				t.Errorf("%g -> %g: %g Hz has amplitude %g, expected 1", c.in, c.out, freq, a)
+			}
+		}
+
+		// Tones above the output Nyquist frequency do not alias back
+		if c.out < c.in {
+			for _, freq := range []float64{0.55 * c.out, 1.3 * c.out} {
+				if freq >= c.in/2 {
+					continue
+				}
+				conv.Reset()
+				y := conv.Process(cosine(freq, c.in, n))
+				var peak float64
+				for _, v := range y[skip : len(y)-skip] {
+					peak = math.Max(peak, math.Abs(v))
+				}
+				if db := 20 * math.Log10(peak); db > -70 {
+					t.Errorf("%g -> %g: %g Hz aliases at %.1f dB", c.in, c.out, freq, db)
+				}
+			}
+		}
+	}
+}
+
+func TestResamplerStreaming(t *testing.T) {
+	// A ramp through a unity-gain linear-phase filter is the ramp delayed
+	// by the latency, and block boundaries do not change the output
+	const n = 20000
+	ramp := make([]float64, n)
+	for i := range ramp {
+		ramp[i] = float64(i) / n
+	}
+
+	converters := map[string]func() (resample.Converter, error){
+		"3/2":     func() (resample.Converter, error) { return resample.NewResampler(3, 2) },
+		"160/147": func() (resample.Converter, error) { return resample.NewResampler(160, 147) },
+		"1/50":    func() (resample.Converter, error) { return resample.NewDecimator(50) },
+		"1/7":     func() (resample.Converter, error) { return resample.NewDecimator(7) },
+	}
+	for name, create := range converters {
+		conv, err := create()
+		if err != nil {
+			t.Fatalf("%s: failed to create converter: %v", name, err)
+		}
+		whole := conv.Process(ramp)
+		conv.Reset()
+		blocks := inBlocks(n, func(start, end int) []float64 {
+			return conv.Process(ramp[start:end])
+		})
+		assertSameOutput(t, name, whole, blocks)
+
+		var up, down float64
+		switch c := conv.(type) {
+		case *resample.Resampler:
+			u, d := c.Factors()
+			up, down = float64(u), float64(d)
+		case *resample.Decimator:
+			up, down = 1, float64(c.Factor())
+		}
+		for _, k := range []int{len(whole) / 2, len(whole) - 100} {
+			expected := (float64(k) - conv.Latency()) * down / up / n
+			if math.Abs(whole[k]-expected) > 1e-4 {
+				t.Errorf("%s: latency %g, but output %d is %g, expected %g", name, conv.Latency(), k, whole[k], expected)
+			}
+		}
+	}
+}
+
+func TestCIC(t *testing.T) {
+	// Decimation by 8 with 3 stages matches a moving sum of 8 applied three
+	// times and sampled every 8 inputs from the first
+	cic, err := resample.NewCIC(8, 3)
+	if err != nil {
+		t.Fatalf("Failed to create CIC: %v", err)
+	}
+	x := cosine(1000, 48000, 4000)
+	for i := range x {
+		x[i] += 0.5 // A DC offset would make float integrators drift
+	}
+	y := cic.Process(x)
+
+	reference := x
+	for stage := 0; stage < 3; stage++ {
+		sum := make([]float64, len(reference))
+		for i := range reference {
+			for k := 0; k < 8 && i-k >= 0; k++ {
+				sum[i] += reference[i-k] / 8
+			}
+		}
+		reference = sum
+	}
+	if len(y) != len(x)/8 {
+		t.Fatalf("Expected %d outputs, got %d", len(x)/8, len(y))
+	}
+	for i := range y {
+		if math.Abs(y[i]-reference[8*i]) > 1e-9 {
+			t.Fatalf("Output %d: CIC %g, moving sums %g", i, y[i], reference[8*i])
+		}
+	}
+
+	if r := cic.Response(0.5); math.Abs(r-math.Pow(1/(8*math.Sin(math.Pi/16)), 3)) > 1e-12 {
+		t.Errorf("Unexpected response at Nyquist: %g", r)
+	}
+	if _, err := resample.NewCIC(1<<20, 5); err == nil {
+		t.Error("Expected error for a CIC too wide for 64-bit integers")
+	}
+}
+
+func BenchmarkDecimate(b *testing.B) {
+	// 2.4 MS/s to 48 kHz in one polyphase stage and with a CIC first
+	signal := cosine(1000, 2400000, 1<<18)
+	single, err := resample.NewResampler(1, 50)
+	if err != nil {
+		b.Fatal(err)
+	}
+	multistage, err := resample.NewDecimator(50)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for name, conv := range map[string]resample.Converter{"polyphase": single, "cic": multistage} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(8 * len(signal)))
+			for i := 0; i < b.N; i++ {
+				conv.Process(signal)
+			}
+		})
+	}
+}
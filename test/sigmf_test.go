@@ -0,0 +1,104 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/sigmf"
+)
+
+func TestSigMFDatatype(t *testing.T) {
+	tests := []struct {
+		datatype   string
+		complex    bool
+		sampleSize int
+	}{
+		{"cu8", true, 2},
+		{"ci16_le", true, 4},
+		{"cf32_be", true, 8},
+		{"rf64_le", false, 8},
+		{"ri8", false, 1},
+	}
+
+	for _, tt := range tests {
+		d, err := sigmf.ParseDatatype(tt.datatype)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tt.datatype, err)
+		}
+		if d.Complex != tt.complex || d.SampleSize() != tt.sampleSize {
+			t.Errorf("%s: got complex=%v size=%d", tt.datatype, d.Complex, d.SampleSize())
+		}
+		if d.String() != tt.datatype {
+			t.Errorf("Expected %s to format back unchanged, got %s", tt.datatype, d.String())
+		}
+	}
+
+	for _, invalid := range []string{"cf16_le", "ci16", "xf32_le", "cf32_me"} {
+		if _, err := sigmf.ParseDatatype(invalid); err == nil {
+			t.Errorf("Expected error for datatype %s", invalid)
+		}
+	}
+}
+
+func TestSigMFRecordingAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "capture")
+
+	meta := sigmf.NewMeta("", 2.4e6)
+	meta.Captures[0].Frequency = 100.1e6
+	meta.Captures[0].Datetime = "2024-05-01T12:00:00Z"
+	samples := []complex128{1, 1i, -1, -1i}
+	if err := sigmf.WriteComplex(name, samples, meta); err != nil {
+		t.Fatalf("Failed to write recording: %v", err)
+	}
+
+	// Add a foreign namespace key that must survive rewriting
+	metaPath, _ := sigmf.Paths(name)
+	data, _ := os.ReadFile(metaPath)
+	data = []byte(strings.Replace(string(data), `"global": {`, `"global": {"other:key": 42,`, 1))
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		t.Fatalf("Failed to patch metadata: %v", err)
+	}
+
+	err := sigmf.AppendAnnotations(name+sigmf.DataExt,
+		sigmf.Annotation{SampleStart: 3, SampleCount: 1, Label: "late"},
+		sigmf.Annotation{SampleStart: 1, SampleCount: 2, Label: "burst"},
+	)
+	if err != nil {
+		t.Fatalf("Failed to append annotations: %v", err)
+	}
+
+	recording, err := sigmf.Open(metaPath)
+	if err != nil {
+		t.Fatalf("Failed to open recording: %v", err)
+	}
+	if recording.Meta.CenterFrequency() != 100.1e6 {
+		t.Errorf("Expected center frequency 100.1 MHz, got %f", recording.Meta.CenterFrequency())
+	}
+	if recording.Meta.StartTime().Hour() != 12 {
+		t.Errorf("Unexpected start time %v", recording.Meta.StartTime())
+	}
+	if string(recording.Meta.Global.Extra["other:key"]) != "42" {
+		t.Errorf("Expected foreign key to be preserved, got %v", recording.Meta.Global.Extra)
+	}
+
+	annotations := recording.Meta.Annotations
+	if len(annotations) != 2 || annotations[0].Label != "burst" || annotations[1].Label != "late" {
+		t.Errorf("Expected annotations sorted by sample_start, got %+v", annotations)
+	}
+
+	got, err := recording.ReadComplex()
+	if err != nil {
+		t.Fatalf("Failed to read samples: %v", err)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("Expected %d samples, got %d", len(samples), len(got))
+	}
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Errorf("Sample %d: expected %v, got %v", i, samples[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/spectrum"
+	"github.com/Vivirinter/sdr-parser/pkg/waterfall"
+	"github.com/Vivirinter/sdr-parser/pkg/window"
+)
+
+func TestWindows(t *testing.T) {
+	types := []window.Type{window.Rectangular, window.Hann, window.Hamming, window.BlackmanHarris, window.FlatTop, window.Kaiser}
+	for _, wt := range types {
+		w, err := window.Symmetric(wt, 33, window.DefaultKaiserBeta)
+		if err != nil {
+			t.Fatalf("%s: %v", wt, err)
+		}
+		for i := range w {
+			if math.Abs(w[i]-w[len(w)-1-i]) > 1e-12 {
+				t.Errorf("%s: not symmetric at %d", wt, i)
+				break
+			}
+		}
+		if math.Abs(w[16]-1) > 1e-3 {
+			t.Errorf("%s: expected unit peak at the centre, got %f", wt, w[16])
+		}
+
+		periodic, err := window.Periodic(wt, 32, window.DefaultKaiserBeta)
+		if err != nil || len(periodic) != 32 {
+			t.Fatalf("%s: expected 32-point periodic window, got %d (%v)", wt, len(periodic), err)
+		}
+	}
+
+	if _, err := window.Symmetric("triangle", 8, 0); err == nil {
+		t.Error("Expected error for unsupported window")
+	}
+
+	// A Kaiser window with beta 0 is rectangular
+	w, _ := window.Symmetric(window.Kaiser, 8, 0)
+	for i, v := range w {
+		if math.Abs(v-1) > 1e-12 {
+			t.Errorf("Kaiser beta 0: sample %d is %f", i, v)
+		}
+	}
+}
+
+func TestWelchTone(t *testing.T) {
+	const (
+		sampleRate = 8000.0
+		freq       = 1000.0
+		amplitude  = 0.5
+	)
+	signal := make([]float64, 16384)
+	for i := range signal {
+		signal[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)
+	}
+
+	for _, wt := range []window.Type{window.Hann, window.BlackmanHarris, window.FlatTop, window.Kaiser} {
+		config := spectrum.WelchConfig{SegmentLength: 256, Overlap: 0.5, Window: wt, Beta: window.DefaultKaiserBeta}
+		psd, err := spectrum.WelchReal(signal, sampleRate, config)
+		if err != nil {
+			t.Fatalf("%s: %v", wt, err)
+		}
+
+		if len(psd.Frequencies) != 129 || psd.Frequencies[128] != sampleRate/2 {
+			t.Fatalf("%s: expected 129 bins up to Nyquist, got %d", wt, len(psd.Frequencies))
+		}
+		if peak, _ := psd.Peak(); peak != freq {
+			t.Errorf("%s: expected peak at %g Hz, got %g Hz", wt, freq, peak)
+		}
+		// Integrated density equals the signal power A²/2
+		if power := psd.TotalPower(); math.Abs(power-amplitude*amplitude/2) > 0.01 {
+			t.Errorf("%s: expected total power %f, got %f", wt, amplitude*amplitude/2, power)
+		}
+	}
+}
+
+func TestWelchIQ(t *testing.T) {
+	const sampleRate = 48000.0
+	rng := rand.New(rand.NewSource(3))
+
+	// Tone at -6 kHz plus white noise of variance 2 (1 per component)
+	samples := make([]complex128, 1<<15)
+	for i := range samples {
+		samples[i] = cmplx.Rect(1, -2*math.Pi*6000*float64(i)/sampleRate) +
+			complex(rng.NormFloat64(), rng.NormFloat64())
+	}
+
+	config := spectrum.WelchConfig{SegmentLength: 512, Overlap: 0.5, Window: window.Hann}
+	whole, err := spectrum.WelchIQ(samples, sampleRate, config)
+	if err != nil {
+		t.Fatalf("Failed to estimate PSD: %v", err)
+	}
+	if whole.OneSided || whole.Frequencies[0] != -sampleRate/2 {
+		t.Errorf("Expected two-sided spectrum from %g Hz, got %g Hz", -sampleRate/2, whole.Frequencies[0])
+	}
+	if peak, _ := whole.Peak(); peak != -6000 {
+		t.Errorf("Expected peak at -6000 Hz, got %g Hz", peak)
+	}
+
+	// Noise floor: variance 2 spread over the sample rate
+	floor := 0.0
+	for i, f := range whole.Frequencies {
+		if f > 0 {
+			floor += whole.Power[i]
+		}
+	}
+	floor /= float64(len(whole.Frequencies)/2 - 1)
+	if expected := 2 / sampleRate; math.Abs(floor-expected) > 0.1*expected {
+		t.Errorf("Expected noise floor %g, got %g", expected, floor)
+	}
+
+	// Block-by-block accumulation gives the same estimate
+	w, _ := spectrum.NewWelch(config, sampleRate, true)
+	for start := 0; start < len(samples); start += 1000 {
+		w.AddIQ(samples[start:min(start+1000, len(samples))])
+	}
+	blocks, err := w.Result()
+	if err != nil {
+		t.Fatalf("Failed to estimate PSD: %v", err)
+	}
+	if blocks.Segments != whole.Segments {
+		t.Fatalf("Expected %d segments, got %d", whole.Segments, blocks.Segments)
+	}
+	for i := range whole.Power {
+		if math.Abs(blocks.Power[i]-whole.Power[i]) > 1e-12*whole.Power[i] {
+			t.Fatalf("Bin %d: whole %g, blocks %g", i, whole.Power[i], blocks.Power[i])
+		}
+	}
+}
+
+func TestSpectrogram(t *testing.T) {
+	const sampleRate = 8000.0
+
+	// 1 kHz for the first half, 3 kHz for the second
+	signal := make([]float64, 8192)
+	for i := range signal {
+		freq := 1000.0
+		if i >= len(signal)/2 {
+			freq = 3000
+		}
+		signal[i] = math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+	}
+
+	config := spectrum.SpectrogramConfig{FFTSize: 256, Hop: 128, Window: window.Hann, FramesPerRow: 4}
+	stft, err := spectrum.NewSpectrogram(config, sampleRate, false)
+	if err != nil {
+		t.Fatalf("Failed to create spectrogram: %v", err)
+	}
+	for start := 0; start < len(signal); start += 1000 {
+		stft.AddReal(signal[start:min(start+1000, len(signal))])
+	}
+
+	// 63 frames of 256 samples fit at a hop of 128; the last row is partial
+	rows := stft.Rows()
+	if stft.Frames() != 63 || len(rows) != 16 {
+		t.Fatalf("Expected 63 frames in 16 rows, got %d in %d", stft.Frames(), len(rows))
+	}
+	freqs := stft.Frequencies()
+	if len(freqs) != 129 || len(rows[0]) != 129 {
+		t.Fatalf("Expected 129 bins, got %d", len(rows[0]))
+	}
+	if d := stft.RowDuration(); d != 4*128/sampleRate {
+		t.Errorf("Expected row duration %g s, got %g s", 4*128/sampleRate, d)
+	}
+
+	peak := func(row []float32) float64 {
+		best := 0
+		for k, v := range row {
+			if v > row[best] {
+				best = k
+			}
+		}
+		return freqs[best]
+	}
+	if f := peak(rows[0]); f != 1000 {
+		t.Errorf("Expected 1000 Hz in the first row, got %g Hz", f)
+	}
+	if f := peak(rows[len(rows)-1]); f != 3000 {
+		t.Errorf("Expected 3000 Hz in the last row, got %g Hz", f)
+	}
+
+	if _, err := spectrum.NewSpectrogram(spectrum.SpectrogramConfig{FFTSize: 256, Window: window.Hann}, sampleRate, false); err == nil {
+		t.Error("Expected error for zero hop")
+	}
+}
+
+func TestWaterfall(t *testing.T) {
+	for _, c := range []waterfall.Colormap{waterfall.Viridis, waterfall.Inferno, waterfall.Grayscale} {
+		lo, hi := c.At(0), c.At(1)
+		if int(lo.R)+int(lo.G)+int(lo.B) >= int(hi.R)+int(hi.G)+int(hi.B) {
+			t.Errorf("%s: expected the top of the map to be brighter than the bottom", c)
+		}
+		if c.At(-5) != lo || c.At(5) != hi {
+			t.Errorf("%s: expected levels outside the range to be clamped", c)
+		}
+	}
+	if err := waterfall.Colormap("jet").Validate(); err == nil {
+		t.Error("Expected error for unsupported colormap")
+	}
+
+	rows := make([][]float32, 50)
+	for i := range rows {
+		rows[i] = make([]float32, 64)
+		for k := range rows[i] {
+			rows[i][k] = -100
+		}
+		rows[i][16] = -20
+	}
+	freqs := make([]float64, 64)
+	for k := range freqs {
+		freqs[k] = 100e6 + float64(k-32)*1000
+	}
+
+	minDB, maxDB := waterfall.AutoRange(rows)
+	if minDB != -100 || maxDB != -20 {
+		t.Errorf("Expected auto range -100 to -20 dB, got %g to %g", minDB, maxDB)
+	}
+
+	img, err := waterfall.Render(rows, freqs, 0.01, waterfall.Options{Colormap: waterfall.Grayscale, MinDB: minDB, MaxDB: maxDB})
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	// The plot is framed by axes and labels on every side
+	if b := img.Bounds(); b.Dx() <= 64 || b.Dy() <= 50 {
+		t.Fatalf("Expected image larger than the 64x50 plot, got %v", b)
+	}
+
+	// Find the plot from its strongest column: the only white pixels in a row
+	white := 0
+	for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+		c := img.RGBAAt(x, img.Bounds().Dy()/2)
+		if c.R == 255 && c.G == 255 && c.B == 255 {
+			white++
+		}
+	}
+	if white == 0 {
+		t.Error("Expected the strongest bin to be drawn with the top colour")
+	}
+
+	if _, err := waterfall.Render(rows, freqs, 0.01, waterfall.Options{Colormap: waterfall.Viridis, MinDB: 0, MaxDB: 0}); err == nil {
+		t.Error("Expected error for an empty dB range")
+	}
+	if _, err := waterfall.Render(nil, freqs, 0.01, waterfall.Options{Colormap: waterfall.Viridis, MinDB: -1, MaxDB: 0}); err == nil {
+		t.Error("Expected error for no rows")
+	}
+}
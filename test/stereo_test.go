@@ -0,0 +1,136 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/demod"
+)
+
+// stereoMPX builds a broadcast FM multiplex of a 1 kHz tone on the left and
+// a 2.5 kHz tone on the right, with the pilot slightly off 19 kHz
+func stereoMPX(n int, sampleRate, pilot float64) []float64 {
+	mpx := make([]float64, n)
+	for i := range mpx {
+		t := float64(i) / sampleRate
+		left := 0.5 * math.Cos(2*math.Pi*1000*t)
+		right := 0.5 * math.Cos(2*math.Pi*2500*t)
+		theta := 2*math.Pi*(demod.PilotFreq+0.7)*t + 0.4
+		mpx[i] = 0.9*((left+right)/2+(left-right)/2*math.Sin(2*theta)) + pilot*math.Sin(theta)
+	}
+	return mpx
+}
+
+func TestStereoDecoder(t *testing.T) {
+	const fs = 240000.0
+	if _, err := demod.NewStereoDecoder(48000, 0); err == nil {
+		t.Error("expected an error for a sample rate below MinStereoRate")
+	}
+
+	d, err := demod.NewStereoDecoder(fs, 0)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	mpx := stereoMPX(int(fs)/2, fs, 0.1)
+	left, right := d.Process(mpx)
+	if !d.Stereo() {
+		t.Fatalf("pilot not detected, level %f", d.PilotLevel())
+	}
+
+	// Skip the pilot acquisition
+	skip := len(mpx) / 2
+	checks := []struct {
+		name          string
+		audio         []float64
+		wanted, other float64
+	}{
+		{"left", left[skip:], 1000, 2500},
+		{"right", right[skip:], 2500, 1000},
+	}
+	for _, c := range checks {
+		wanted := toneAmplitude(c.audio, c.wanted, fs, 0)
+		other := toneAmplitude(c.audio, c.other, fs, 0)
+		if math.Abs(wanted-0.45) > 0.01 {
+			t.Errorf("%s: %g Hz amplitude %f, expected 0.45", c.name, c.wanted, wanted)
+		}
+		if separation := 20 * math.Log10(wanted/other); separation < 30 {
+			t.Errorf("%s: channel separation %.1f dB, expected at least 30 dB", c.name, separation)
+		}
+	}
+
+	// Block by block output is the same
+	d.Reset()
+	var blockLeft, blockRight []float64
+	inBlocks(len(mpx), func(start, end int) []float64 {
+		l, r := d.Process(mpx[start:end])
+		blockLeft = append(blockLeft, l...)
+		blockRight = append(blockRight, r...)
+		return nil
+	})
+	assertSameOutput(t, "left", left, blockLeft)
+	assertSameOutput(t, "right", right, blockRight)
+}
+
+func TestStereoMonoFallback(t *testing.T) {
+	// Without a pilot both channels carry L+R
+	const fs = 240000.0
+	d, err := demod.NewStereoDecoder(fs, demod.Deemphasis50us)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	left, right := d.Process(stereoMPX(int(fs)/2, fs, 0))
+	if d.Stereo() {
+		t.Fatalf("stereo detected without a pilot, level %f", d.PilotLevel())
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			t.Fatalf("sample %d: left %g != right %g in mono", i, left[i], right[i])
+		}
+	}
+	if amp := toneAmplitude(left, 2500, fs, int(d.Latency())*4); amp < 0.1 {
+		t.Errorf("mono output lacks the right channel tone: amplitude %f", amp)
+	}
+}
+
+func TestStereoSwitchAlignment(t *testing.T) {
+	// With the left channel alone, right is L+R before the pilot is
+	// detected and silent after it; the switch happens one filter delay
+	// after the detection, where the detected audio leaves the filters
+	const fs = 240000.0
+	d, err := demod.NewStereoDecoder(fs, 0)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	n := int(fs) / 2
+	mpx := make([]float64, n)
+	for i := range mpx {
+		t := float64(i) / fs
+		left := 0.5 * math.Cos(2*math.Pi*1000*t)
+		theta := 2 * math.Pi * demod.PilotFreq * t
+		mpx[i] = 0.9*(left/2+left/2*math.Sin(2*theta)) + 0.1*math.Sin(theta)
+	}
+
+	left, right := d.Process(mpx)
+	switched := -1
+	for i := range left {
+		if left[i] != right[i] {
+			switched = i
+			break
+		}
+	}
+	if switched < 0 {
+		t.Fatalf("pilot not detected, level %f", d.PilotLevel())
+	}
+
+	// The pilot is detected at the sample one filter delay earlier
+	detected := switched - int(math.Round(d.Latency()))
+	d.Reset()
+	d.Process(mpx[:detected])
+	if d.Stereo() {
+		t.Fatalf("pilot detected before sample %d, but audio switched at %d", detected, switched)
+	}
+	d.Process(mpx[detected : detected+1])
+	if !d.Stereo() {
+		t.Errorf("pilot not detected at sample %d, but audio switched at %d", detected, switched)
+	}
+}
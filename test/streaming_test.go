@@ -0,0 +1,148 @@
+package test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/Vivirinter/sdr-parser/pkg/demod"
+	"github.com/Vivirinter/sdr-parser/pkg/filter"
+)
+
+// blockSizes splits a signal into uneven blocks, including single samples
+var blockSizes = []int{1, 7, 64, 3, 250, 1, 100}
+
+// inBlocks calls process on consecutive blocks of n samples and
+// concatenates the results
+func inBlocks(n int, process func(start, end int) []float64) []float64 {
+	var output []float64
+	for start, i := 0, 0; start < n; i++ {
+		end := min(start+blockSizes[i%len(blockSizes)], n)
+		output = append(output, process(start, end)...)
+		start = end
+	}
+	return output
+}
+
+func assertSameOutput(t *testing.T, name string, whole, blocks []float64) {
+	t.Helper()
+	if len(whole) != len(blocks) {
+		t.Fatalf("%s: whole-signal output has %d samples, block output %d", name, len(whole), len(blocks))
+	}
+	for i := range whole {
+		if math.Abs(whole[i]-blocks[i]) > 1e-12 {
+			t.Fatalf("%s: sample %d differs: whole %g, blocks %g", name, i, whole[i], blocks[i])
+		}
+	}
+}
+
+func streamingFilters(t *testing.T) map[string]filter.Filter {
+	t.Helper()
+	filters := map[string]filter.Filter{
+		"moving_average": filter.NewMovingAverageFilter(),
+		"median":         filter.NewMedianFilter(),
+		"butterworth":    filter.NewButterworthFilter(),
+		"fir_kaiser":     filter.NewKaiserFIRFilter(),
+	}
+	configs := map[string]filter.FilterConfig{
+		"moving_average": {Type: filter.MovingAverage, WindowSize: 8},
+		"median":         {Type: filter.Median, WindowSize: 7},
+		"butterworth":    {Type: filter.Butterworth, Order: 4, CutoffFreq: 1000, SampleRate: 8000},
+		"fir_kaiser":     {Type: filter.FIRKaiser, CutoffFreq: 1000, TransitionWidth: 400, Attenuation: 60, SampleRate: 8000},
+	}
+	for name, f := range filters {
+		if err := f.Configure(configs[name]); err != nil {
+			t.Fatalf("%s: failed to configure filter: %v", name, err)
+		}
+	}
+	return filters
+}
+
+func TestFilterStreaming(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	signal := make([]float64, 2000)
+	for i := range signal {
+		signal[i] = math.Sin(2*math.Pi*float64(i)/40) + 0.3*rng.NormFloat64()
+	}
+
+	for name, f := range streamingFilters(t) {
+		whole, err := f.Process(signal)
+		if err != nil {
+			t.Fatalf("%s: failed to process signal: %v", name, err)
+		}
+
+		f.Reset()
+		blocks := inBlocks(len(signal), func(start, end int) []float64 {
+			output, err := f.Process(signal[start:end])
+			if err != nil {
+				t.Fatalf("%s: failed to process block: %v", name, err)
+			}
+			return output
+		})
+		assertSameOutput(t, name, whole, blocks)
+	}
+}
+
+func TestFilterLatency(t *testing.T) {
+	// A ramp through a filter with unity DC gain settles to the input
+	// delayed by the filter's group delay
+	signal := make([]float64, 4000)
+	for i := range signal {
+		signal[i] = float64(i) / 1000
+	}
+
+	filters := streamingFilters(t)
+	for _, name := range []string{"moving_average", "median", "butterworth"} {
+		f := filters[name]
+		output, err := f.Process(signal)
+		if err != nil {
+			t.Fatalf("%s: failed to process signal: %v", name, err)
+		}
+
+		latency := f.Latency()
+		last := len(signal) - 1
+		expected := (float64(last) - latency) / 1000
+		if math.Abs(output[last]-expected) > 1e-6 {
+			t.Errorf("%s: latency %g samples, but ramp output %f != delayed input %f",
+				name, latency, output[last], expected)
+		}
+	}
+}
+
+func TestDemodulatorStreaming(t *testing.T) {
+	// AM tone on a carrier at 1/8 of the sample rate with a slow envelope,
+	// so the peak keeps rising across blocks
+	const n = 3000
+	signal := make([]float64, n)
+	iq := make([]complex128, n)
+	for i := range signal {
+		envelope := (1 + 0.5*math.Sin(2*math.Pi*float64(i)/300)) * float64(i+1) / n
+		signal[i] = envelope * math.Cos(2*math.Pi*float64(i)/8)
+		iq[i] = cmplx.Rect(envelope, 2*math.Pi*float64(i)/8+0.2*math.Sin(2*math.Pi*float64(i)/150))
+	}
+
+	types := []demod.DemodulationType{demod.AM, demod.FM, demod.USB, demod.LSB}
+	for _, demodType := range types {
+		name := demodType.String()
+
+		config := demod.DemodulatorConfig{Type: demodType, SampleRate: 48000, Deviation: 5000, Deemphasis: demod.Deemphasis75us}
+		d := demod.NewDemodulator(config)
+		whole, _ := d.Demodulate(signal)
+		d.Reset()
+		blocks := inBlocks(n, func(start, end int) []float64 {
+			output, _ := d.Demodulate(signal[start:end])
+			return output
+		})
+		assertSameOutput(t, name, whole, blocks)
+
+		iqd := demod.NewIQDemodulator(config)
+		wholeIQ, _ := iqd.DemodulateIQ(iq)
+		iqd.(demod.Demodulator).Reset()
+		blocksIQ := inBlocks(n, func(start, end int) []float64 {
+			output, _ := iqd.DemodulateIQ(iq[start:end])
+			return output
+		})
+		assertSameOutput(t, name+" I/Q", wholeIQ, blocksIQ)
+	}
+}
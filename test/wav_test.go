@@ -1,8 +1,14 @@
 package test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Vivirinter/sdr-parser/pkg/reader"
 )
@@ -55,3 +61,460 @@ func TestWAVReader(t *testing.T) {
 		t.Errorf("Expected 16 bits per sample, got %d", reader.Header.BitsPerSample)
 	}
 }
+
+func TestWAVSampleFormats(t *testing.T) {
+	samples := []float64{0, 0.5, -0.5, 0.999, -1.0, 0.25}
+
+	tests := []struct {
+		name      string
+		format    reader.SampleFormat
+		tolerance float64
+	}{
+		{"pcm8", reader.PCM8, 1.0 / 127},
+		{"pcm16", reader.PCM16, 1.0 / 32767},
+		{"pcm24", reader.PCM24, 1.0 / 8388607},
+		{"pcm32", reader.PCM32, 1e-9},
+		{"float32", reader.Float32, 1e-7},
+		{"float64", reader.Float64, 0},
+		{"extensible_pcm24", reader.SampleFormat{AudioFormat: reader.WaveFormatPCM, BitsPerSample: 24, Extensible: true}, 1.0 / 8388607},
+		{"extensible_float32", reader.SampleFormat{AudioFormat: reader.WaveFormatIEEEFloat, BitsPerSample: 32, Extensible: true}, 1e-7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.name+".wav")
+			if err := reader.WriteWavFileFormat(filename, samples, 48000, tt.format); err != nil {
+				t.Fatalf("Failed to write WAV file: %v", err)
+			}
+
+			r, err := reader.NewWAVReader(filename)
+			if err != nil {
+				t.Fatalf("Failed to open WAV file: %v", err)
+			}
+			defer r.Close()
+
+			if r.SampleFormat() != tt.format {
+				t.Errorf("Expected format %+v, got %+v", tt.format, r.SampleFormat())
+			}
+
+			got, err := r.ReadSamples()
+			if err != nil {
+				t.Fatalf("Failed to read samples: %v", err)
+			}
+			if len(got) != len(samples) {
+				t.Fatalf("Expected %d samples, got %d", len(samples), len(got))
+			}
+			for i := range samples {
+				if math.Abs(got[i]-samples[i]) > tt.tolerance {
+					t.Errorf("Sample %d: expected %f, got %f", i, samples[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+// riffBuilder assembles a WAVE file chunk by chunk
+type riffBuilder struct {
+	id   string // File ID, "RIFF" when empty
+	size uint32 // Overrides the RIFF size when non-zero
+	body bytes.Buffer
+}
+
+func (b *riffBuilder) chunk(id string, data []byte) {
+	b.sizedChunk(id, uint32(len(data)), data)
+}
+
+// sizedChunk writes a chunk whose size field differs from its length,
+// e.g. the RF64 placeholder 0xFFFFFFFF
+func (b *riffBuilder) sizedChunk(id string, size uint32, data []byte) {
+	b.body.WriteString(id)
+	binary.Write(&b.body, binary.LittleEndian, size)
+	b.body.Write(data)
+	if len(data)%2 == 1 {
+		b.body.WriteByte(0)
+	}
+}
+
+func (b *riffBuilder) write(t *testing.T, name string) string {
+	id, size := b.id, b.size
+	if id == "" {
+		id = "RIFF"
+	}
+	if size == 0 {
+		size = uint32(4 + b.body.Len())
+	}
+
+	var file bytes.Buffer
+	file.WriteString(id)
+	binary.Write(&file, binary.LittleEndian, size)
+	file.WriteString("WAVE")
+	file.Write(b.body.Bytes())
+
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, file.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return filename
+}
+
+func TestWAVChunkWalking(t *testing.T) {
+	var riff riffBuilder
+	chunk := riff.chunk
+
+	// fmt chunk with cbSize (18 bytes): mono 16-bit PCM at 8 kHz
+	var fmtBody bytes.Buffer
+	for _, v := range []interface{}{uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16), uint16(0)} {
+		binary.Write(&fmtBody, binary.LittleEndian, v)
+	}
+
+	var info bytes.Buffer
+	info.WriteString("INFO")
+	info.WriteString("INAM")
+	binary.Write(&info, binary.LittleEndian, uint32(5))
+	info.WriteString("test\x00\x00")
+
+	chunk("JUNK", make([]byte, 3))
+	chunk("fmt ", fmtBody.Bytes())
+	chunk("LIST", info.Bytes())
+	chunk("fact", []byte{3, 0, 0, 0})
+	chunk("auxi", []byte{1, 2, 3, 4, 5})
+	chunk("data", []byte{0xFF, 0x7F, 0x00, 0x00, 0x01, 0x80})
+	chunk("LIST", []byte("adtl"))
+	filename := riff.write(t, "chunks.wav")
+
+	r, err := reader.NewWAVReader(filename)
+	if err != nil {
+		t.Fatalf("Failed to create WAV reader: %v", err)
+	}
+	defer r.Close()
+
+	if len(r.Chunks) != 7 {
+		t.Errorf("Expected 7 chunks, got %d", len(r.Chunks))
+	}
+	if r.Header.Subchunk1Size != 18 || r.Header.SampleRate != 8000 {
+		t.Errorf("Unexpected fmt fields: %+v", r.Header)
+	}
+	if r.Metadata.Info["INAM"] != "test" {
+		t.Errorf("Expected INAM \"test\", got %q", r.Metadata.Info["INAM"])
+	}
+	if r.Metadata.FactSampleLength != 3 {
+		t.Errorf("Expected fact sample length 3, got %d", r.Metadata.FactSampleLength)
+	}
+	if auxi, err := r.ReadChunk("auxi"); err != nil || len(auxi) != 5 {
+		t.Errorf("Expected 5-byte auxi chunk, got %v (%v)", auxi, err)
+	}
+
+	samples, err := r.ReadSamples()
+	if err != nil {
+		t.Fatalf("Failed to read samples: %v", err)
+	}
+	expected := []float64{1, 0, -32767.0 / 32767.0}
+	if len(samples) != len(expected) {
+		t.Fatalf("Expected %d samples, got %d", len(expected), len(samples))
+	}
+	for i := range expected {
+		if math.Abs(samples[i]-expected[i]) > 1e-9 {
+			t.Errorf("Sample %d: expected %f, got %f", i, expected[i], samples[i])
+		}
+	}
+}
+
+func TestWAVTruncatedMetadata(t *testing.T) {
+	// A trailing LIST chunk that claims almost 4 GiB but is cut short does
+	// not stop the audio from being read
+	var riff riffBuilder
+	var fmtBody bytes.Buffer
+	for _, v := range []interface{}{uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+		binary.Write(&fmtBody, binary.LittleEndian, v)
+	}
+	riff.chunk("fmt ", fmtBody.Bytes())
+	riff.chunk("data", []byte{0xFF, 0x7F, 0x00, 0x00})
+	riff.sizedChunk("LIST", 0xFFFFFFF0, []byte("INFOINAM"))
+	filename := riff.write(t, "truncated.wav")
+
+	r, err := reader.NewWAVReader(filename)
+	if err != nil {
+		t.Fatalf("Failed to create WAV reader: %v", err)
+	}
+	defer r.Close()
+
+	if chunk, ok := r.FindChunk("LIST"); !ok || chunk.Size != 8 {
+		t.Errorf("Expected the LIST chunk limited to its 8 bytes, got %+v", chunk)
+	}
+	if len(r.Metadata.Info) != 0 {
+		t.Errorf("Expected no INFO entries, got %v", r.Metadata.Info)
+	}
+	samples, err := r.ReadSamples()
+	if err != nil {
+		t.Fatalf("Failed to read samples: %v", err)
+	}
+	if len(samples) != 2 || samples[0] != 1 || samples[1] != 0 {
+		t.Errorf("Expected samples [1 0], got %v", samples)
+	}
+}
+
+func TestWAVStereoIQ(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "iq.wav")
+	frames := [][2]int16{{32767, 0}, {0, 32767}, {-32767, 0}, {0, -32767}}
+
+	header := reader.WAVHeader{
+		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + uint32(len(frames)*4),
+		Format:        [4]byte{'W', 'A', 'V', 'E'},
+		Subchunk1ID:   [4]byte{'f', 'm', 't', ' '},
+		Subchunk1Size: 16,
+		AudioFormat:   1,
+		NumChannels:   2,
+		SampleRate:    96000,
+		ByteRate:      384000,
+		BlockAlign:    4,
+		BitsPerSample: 16,
+		Subchunk2ID:   [4]byte{'d', 'a', 't', 'a'},
+		Subchunk2Size: uint32(len(frames) * 4),
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &header)
+	binary.Write(&buf, binary.LittleEndian, frames)
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	samples, sampleRate, err := reader.ReadWavFileIQ(filename)
+	if err != nil {
+		t.Fatalf("Failed to read I/Q samples: %v", err)
+	}
+	if sampleRate != 96000 {
+		t.Errorf("Expected sample rate 96000, got %f", sampleRate)
+	}
+
+	expected := []complex128{1, 1i, -1, -1i}
+	if len(samples) != len(expected) {
+		t.Fatalf("Expected %d samples, got %d", len(expected), len(samples))
+	}
+	for i := range expected {
+		if samples[i] != expected[i] {
+			t.Errorf("Sample %d: expected %v, got %v", i, expected[i], samples[i])
+		}
+	}
+
+	// Mono reads return the left channel only
+	left, _, err := reader.ReadWavFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read samples: %v", err)
+	}
+	if len(left) != len(frames) || left[0] != 1 || left[1] != 0 {
+		t.Errorf("Expected left channel, got %v", left)
+	}
+}
+
+func TestWAVAuxiChunk(t *testing.T) {
+	var riff riffBuilder
+
+	var fmtBody bytes.Buffer
+	for _, v := range []interface{}{uint16(1), uint16(2), uint32(2400000), uint32(9600000), uint16(4), uint16(16)} {
+		binary.Write(&fmtBody, binary.LittleEndian, v)
+	}
+
+	// SYSTEMTIME fields: year, month, day of week, day, hour, minute, second, ms
+	var auxi bytes.Buffer
+	binary.Write(&auxi, binary.LittleEndian, [8]uint16{2024, 5, 3, 1, 12, 30, 15, 250})
+	binary.Write(&auxi, binary.LittleEndian, [8]uint16{2024, 5, 3, 1, 12, 31, 0, 0})
+	binary.Write(&auxi, binary.LittleEndian, [5]uint32{100100000, 2400000, 0, 2000000, 0})
+	auxi.Write(make([]byte, 16+96))
+
+	riff.chunk("fmt ", fmtBody.Bytes())
+	riff.chunk("auxi", auxi.Bytes())
+	riff.chunk("data", make([]byte, 16))
+	filename := riff.write(t, "SDRSharp_100100000Hz_IQ.wav")
+
+	r, err := reader.NewWAVReader(filename)
+	if err != nil {
+		t.Fatalf("Failed to create WAV reader: %v", err)
+	}
+	defer r.Close()
+
+	meta := r.Metadata.Auxi
+	if meta == nil {
+		t.Fatal("Expected auxi metadata")
+	}
+	if meta.CenterFreq != 100100000 || meta.ADFrequency != 2400000 || meta.Bandwidth != 2000000 {
+		t.Errorf("Unexpected auxi fields: %+v", meta)
+	}
+	start := time.Date(2024, 5, 1, 12, 30, 15, 250*int(time.Millisecond), time.UTC)
+	if !meta.StartTime.Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, meta.StartTime)
+	}
+	if meta.StopTime.Sub(meta.StartTime) != 44750*time.Millisecond {
+		t.Errorf("Unexpected stop time %v", meta.StopTime)
+	}
+}
+
+func TestWAVRF64(t *testing.T) {
+	for _, id := range []string{"RF64", "BW64"} {
+		riff := riffBuilder{id: id, size: 0xFFFFFFFF}
+
+		data := []byte{0xFF, 0x7F, 0x00, 0x00, 0x01, 0x80, 0x00, 0x00}
+
+		// ds64: riffSize, dataSize, sampleCount, tableLength
+		var ds64 bytes.Buffer
+		binary.Write(&ds64, binary.LittleEndian, uint64(0))
+		binary.Write(&ds64, binary.LittleEndian, uint64(len(data)))
+		binary.Write(&ds64, binary.LittleEndian, uint64(len(data)/2))
+		binary.Write(&ds64, binary.LittleEndian, uint32(0))
+
+		var fmtBody bytes.Buffer
+		for _, v := range []interface{}{uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+			binary.Write(&fmtBody, binary.LittleEndian, v)
+		}
+
+		riff.chunk("ds64", ds64.Bytes())
+		riff.chunk("fmt ", fmtBody.Bytes())
+		riff.sizedChunk("data", 0xFFFFFFFF, data)
+		filename := riff.write(t, "capture.wav")
+
+		r, err := reader.NewWAVReader(filename)
+		if err != nil {
+			t.Fatalf("%s: failed to create WAV reader: %v", id, err)
+		}
+		if r.DS64 == nil || r.DS64.SampleCount != 4 {
+			t.Errorf("%s: expected ds64 with 4 samples, got %+v", id, r.DS64)
+		}
+		if r.DataSize != uint64(len(data)) {
+			t.Errorf("%s: expected data size %d, got %d", id, len(data), r.DataSize)
+		}
+
+		samples, err := r.ReadSamples()
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to read samples: %v", id, err)
+		}
+		expected := []float64{1, 0, -1, 0}
+		if len(samples) != len(expected) {
+			t.Fatalf("%s: expected %d samples, got %d", id, len(expected), len(samples))
+		}
+		for i := range expected {
+			if math.Abs(samples[i]-expected[i]) > 1e-9 {
+				t.Errorf("%s: sample %d: expected %f, got %f", id, i, expected[i], samples[i])
+			}
+		}
+	}
+}
+
+func TestWAVStreaming(t *testing.T) {
+	for _, format := range []reader.SampleFormat{reader.PCM8, reader.PCM16, reader.Float32} {
+		filename := filepath.Join(t.TempDir(), "stream.wav")
+
+		// 1001 samples written in uneven blocks; PCM8 leaves an odd data size
+		samples := make([]float64, 1001)
+		for i := range samples {
+			samples[i] = 0.5 * math.Sin(2*math.Pi*float64(i)/50)
+		}
+
+		w, err := reader.NewWAVWriter(filename, 8000, format)
+		if err != nil {
+			t.Fatalf("Failed to create WAV writer: %v", err)
+		}
+		for start := 0; start < len(samples); start += 300 {
+			if err := w.WriteBlock(samples[start:min(start+300, len(samples))]); err != nil {
+				t.Fatalf("Failed to write block: %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Failed to close WAV writer: %v", err)
+		}
+
+		r, err := reader.NewWAVReader(filename)
+		if err != nil {
+			t.Fatalf("Failed to create WAV reader: %v", err)
+		}
+		defer r.Close()
+
+		if r.DataSize != uint64(len(samples)*format.BytesPerSample()) {
+			t.Errorf("%+v: expected data size %d, got %d", format, len(samples)*format.BytesPerSample(), r.DataSize)
+		}
+		if _, ok := r.FindChunk("JUNK"); !ok {
+			t.Errorf("%+v: expected JUNK chunk reserving space for ds64", format)
+		}
+
+		var read []float64
+		block := make([]float64, 256)
+		for {
+			n, err := r.ReadBlock(block)
+			read = append(read, block[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read block: %v", err)
+			}
+		}
+
+		if len(read) != len(samples) {
+			t.Fatalf("%+v: expected %d samples, got %d", format, len(samples), len(read))
+		}
+		for i := range samples {
+			if math.Abs(read[i]-samples[i]) > 1.0/100 {
+				t.Errorf("%+v: sample %d: expected %f, got %f", format, i, samples[i], read[i])
+				break
+			}
+		}
+	}
+}
+
+func TestWAVChannels(t *testing.T) {
+	left := []float64{0.5, -0.25, 0, 1}
+	right := []float64{-0.5, 0.25, 0.75, -1}
+	dir := t.TempDir()
+
+	whole := filepath.Join(dir, "whole.wav")
+	if err := reader.WriteWavFileChannels(whole, [][]float64{left, right}, 48000, reader.Float32); err != nil {
+		t.Fatalf("Failed to write stereo WAV file: %v", err)
+	}
+
+	streamed := filepath.Join(dir, "streamed.wav")
+	w, err := reader.NewWAVWriterChannels(streamed, 48000, 2, reader.Float32)
+	if err != nil {
+		t.Fatalf("Failed to create WAV writer: %v", err)
+	}
+	if err := w.WriteBlock(left); err == nil {
+		t.Error("Expected an error writing one channel to a stereo file")
+	}
+	for _, split := range [][2]int{{0, 3}, {3, 4}} {
+		block := [][]float64{left[split[0]:split[1]], right[split[0]:split[1]]}
+		if err := w.WriteChannels(block); err != nil {
+			t.Fatalf("Failed to write block: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close WAV writer: %v", err)
+	}
+
+	for _, filename := range []string{whole, streamed} {
+		r, err := reader.NewWAVReader(filename)
+		if err != nil {
+			t.Fatalf("Failed to create WAV reader: %v", err)
+		}
+		channels, err := r.ReadChannels()
+		r.Close()
+		if err != nil {
+			t.Fatalf("Failed to read channels: %v", err)
+		}
+		if r.Header.NumChannels != 2 || r.Header.BlockAlign != 8 {
+			t.Fatalf("%s: expected 2 channels of 4 bytes, got %d channels, block align %d",
+				filepath.Base(filename), r.Header.NumChannels, r.Header.BlockAlign)
+		}
+		for c, expected := range [][]float64{left, right} {
+			for i := range expected {
+				if channels[c][i] != expected[i] {
+					t.Errorf("%s: channel %d sample %d: expected %f, got %f",
+						filepath.Base(filename), c, i, expected[i], channels[c][i])
+				}
+			}
+		}
+	}
+
+	if err := reader.WriteWavFileChannels(whole, [][]float64{left, right[:2]}, 48000, reader.PCM16); err == nil {
+		t.Error("Expected an error for channels of different lengths")
+	}
+}